@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"image/color"
-	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -14,6 +17,8 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/archon/backend"
@@ -21,10 +26,29 @@ import (
 )
 
 const APP_NAME = "Archon"
+const APP_ID = "io.github.carpenterd777.archon"
 const DEFAULT_SESSION_NAME = "Untitled Session"
 const STARTING_WIDTH = 600
 const STARTING_HEIGHT = 400
 const MAX_WIN_TITLE_LENGTH = 50
+const MAX_TAB_TITLE_LENGTH = 30
+const NOT_EDITING = -1
+
+// Shown before the title of a session with unsaved changes, in the window title and on its tab.
+const DIRTY_MARKER = "*"
+
+// The keys of the preferences remembered between launches.
+const PREF_RECENT_SESSIONS = "recentSessions"
+const PREF_REOPEN_LAST = "reopenLastSession"
+
+const EXPORT_MARKDOWN = "Markdown"
+const EXPORT_HTML = "HTML"
+
+// The layouts offered for the time of each note when exporting a session.
+var EXPORT_TIME_FORMATS = []string{backend.DEFAULT_EXPORT_TIME_FORMAT, "15:04", "Jan 2 3:04 PM", "Jan 2 15:04"}
+
+// The file extension of each export format.
+var EXPORT_EXTENSIONS = map[string]string{EXPORT_MARKDOWN: ".md", EXPORT_HTML: ".html"}
 
 // Handles the rendering for NoteBoxes. Implements the fyne.WidgetRenderer interface.
 type MainInterfaceRenderer struct {
@@ -67,21 +91,56 @@ func (m *MainInterfaceRenderer) Destroy() {
 	// no-op, no resources to close
 }
 
+// A session open in a tab of the window, along with the state kept separately for each tab.
+type sessionTab struct {
+	item      *container.TabItem   // the tab of the session, showing its saving indicator
+	session   *backend.Session     // the session open in the tab
+	entry     *gui.EnterEntry      // the entry field adding notes to the session
+	indicator *gui.SavingIndicator // an indicator that flashes when the session is saved
+	autosaver *backend.Autosaver   // saves the session automatically to its file or its own journal, if autosaving has been started
+	editing   int                  // the index of the note being edited inline, or NOT_EDITING
+	tagFilter []string             // the tags a note must have to be shown in the list
+	search    string               // the text of the search bar
+}
+
 // Represents the main interface of the application window. Implements the widget.Widget interface.
+// Every open session has a tab; the list, search bar, and other views show the session of the active tab.
 type MainInterface struct {
 	widget.BaseWidget
-	session     *backend.Session       // The state of this application session
-	entry       *gui.EnterEntry        // The entry field
-	indicator   *gui.SavingIndicator   // an indicator that flashes when a save is initiated
-	infoButton  *widget.Button         // a button containing info for the session
-	boundTitle  binding.ExternalString // a binding for the session title
-	boundNumber binding.String         // a binding for the session number
-	window      fyne.Window            // the window this is rendered in
+	session     *backend.Session         // The state of this application session, open in the active tab
+	sessionTabs []*sessionTab            // every open session, in the order of their tabs
+	active      *sessionTab              // the tab being shown
+	tabs        *container.AppTabs       // the tabs of the open sessions
+	entryBox    *fyne.Container          // the container holding the entry field of the active tab
+	shortcuts   map[fyne.Shortcut]func() // the shortcuts added to the window and to the entry field of every tab
+	campaign    *backend.Campaign        // the campaign the session belongs to, if one is open
+	list        *gui.NoteList            // the list displaying the notes of the session
+	timeline    *gui.Timeline            // the view grouping the notes of the session by scene, shown in place of the list
+	sceneSelect *widget.Select           // the dropdown jumping to a scene of the session
+	startScreen *gui.StartScreen         // the screen offering recent sessions, shown in place of the list at startup
+	recent      *backend.RecentSessions  // the session files most recently opened or saved
+	preferences fyne.Preferences         // the preferences the recent sessions are remembered in
+	searchEntry *widget.Entry            // the search bar used to filter the notes of the session
+	tagButton   *widget.Button           // a button to choose the tags the notes are filtered by
+	tagFilter   []string                 // the tags a note must have to be shown in the list
+	visible     []int                    // the indexes of the notes shown in the list, or nil if every note is shown
+	editing     int                      // the index of the note being edited inline, or NOT_EDITING
+	gameTime    bool                     // whether notes show the in-game time they were taken instead of the real time
+	journalDir  string                   // the directory the journal of every tab is kept in, or empty if autosaving has not been started
+	entry       *gui.EnterEntry          // The entry field of the active tab
+	suggestions *gui.CommandSuggestions  // the slash commands suggested above the entry field
+	combatPanel *gui.CombatPanel         // the side panel tracking the fight of the session
+	kindSelect  *widget.Select           // the dropdown choosing the kind of the notes added through the entry field
+	infoButton  *widget.Button           // a button containing info for the session
+	boundTitle  binding.String           // a binding for the session title
+	boundNumber binding.String           // a binding for the session number
+	window      fyne.Window              // the window this is rendered in
 }
 
 // Bind the session info to the binding strings.
 func (m *MainInterface) BindSessionInfo() {
-	m.boundTitle = binding.BindString(&m.session.SessionTitle)
+	m.boundTitle = binding.NewString()
+	m.boundTitle.Set(m.session.SessionTitle)
 	m.boundNumber = binding.NewString()
 	m.boundNumber.Set(strconv.Itoa(m.session.SessionNumber))
 }
@@ -89,28 +148,97 @@ func (m *MainInterface) BindSessionInfo() {
 // Creates a renderer for the main window. Necessary to implement the widget.Widget inteface.
 func (m *MainInterface) CreateRenderer() fyne.WidgetRenderer {
 
-	list := widget.NewList(
+	m.list = gui.NewNoteList(
 		m.listLength,
-		m.listCreateItem,
 		m.listUpdateItem,
 	)
 
 	toolbar := widget.NewToolbar(
 		widget.NewToolbarAction(theme.DocumentSaveIcon(), m.Save),
 		widget.NewToolbarAction(theme.FolderOpenIcon(), m.Load),
+		widget.NewToolbarAction(theme.StorageIcon(), m.OpenCampaign),
+		widget.NewToolbarAction(theme.SearchIcon(), m.SearchAll),
+		widget.NewToolbarAction(theme.DownloadIcon(), m.Export),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.ContentUndoIcon(), m.Undo),
+		widget.NewToolbarAction(theme.ContentRedoIcon(), m.Redo),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.MediaPlayIcon(), m.ToggleCombatTracker),
 	)
-	m.indicator = gui.NewSavingIndicator()
+	m.timeline = gui.NewTimeline(m.session)
+	m.timeline.OnNoteTapped = m.ShowNote
+	m.timeline.Hide()
+	m.startScreen = gui.NewStartScreen(m.recent.Paths())
+	m.startScreen.SetReopenLast(m.preferences.Bool(PREF_REOPEN_LAST))
+	m.startScreen.OnOpenRecent = m.OpenRecent
+	m.startScreen.OnOpen = m.Load
+	m.startScreen.OnNewSession = func() {
+		m.hideStartScreen()
+		m.window.Canvas().Focus(m.entry)
+	}
+	m.startScreen.OnReopenLastChanged = m.SetReopenLast
+	m.list.Hide()
+	m.sceneSelect = widget.NewSelect(nil, func(string) {
+		m.sceneChosen()
+	})
+	m.sceneSelect.PlaceHolder = "Jump to scene"
+	m.refreshScenes()
+	m.searchEntry = widget.NewEntry()
+	m.searchEntry.SetPlaceHolder("Search notes")
+	m.searchEntry.OnChanged = func(string) {
+		m.RefreshNotes()
+	}
+	m.tagButton = widget.NewButton(m.getTagButtonText(), m.ShowTagFilter)
+	items := make([]*container.TabItem, 0, len(m.sessionTabs))
+	for _, tab := range m.sessionTabs {
+		items = append(items, tab.item)
+	}
+	m.tabs = container.NewAppTabs(items...)
+	m.tabs.SelectTab(m.active.item)
+	m.tabs.OnChanged = func(item *container.TabItem) {
+		for _, tab := range m.sessionTabs {
+			if tab.item == item {
+				m.activateTab(tab)
+			}
+		}
+	}
+	m.entryBox = container.NewMax(m.entry)
+	m.kindSelect = gui.NewKindSelect(func(kind backend.NoteKind) {
+		m.entry.Kind = kind
+	})
+	m.suggestions = gui.NewCommandSuggestions()
+	m.suggestions.OnChosen = func(command backend.SlashCommand) {
+		m.entry.Complete(command)
+		m.window.Canvas().Focus(m.entry)
+	}
+	for _, tab := range m.sessionTabs {
+		tab.entry.OnSuggest = m.suggestions.SetSuggestions
+	}
+	m.combatPanel = gui.NewCombatPanel(m.session.Combat)
+	m.combatPanel.OnStart = m.StartCombat
+	m.combatPanel.OnEnd = m.EndCombat
+	m.combatPanel.OnNextTurn = m.NextTurn
+	m.combatPanel.OnAdd = m.AddCombatant
+	m.combatPanel.OnRemove = m.RemoveCombatant
+	m.combatPanel.OnHP = m.ChangeHP
+	m.combatPanel.OnConditions = m.EditConditions
+	if m.session.Combat == nil {
+		m.combatPanel.Hide()
+	}
 	m.BindSessionInfo()
 	m.infoButton = widget.NewButton(
 		m.getInfoButtonText(),
 		m.HandleSessionInfoButton,
 	)
 	cont := container.NewBorder(
-		container.NewVBox(container.NewHBox(toolbar, m.infoButton), m.indicator),
-		m.entry,
-		nil,
+		container.NewVBox(
+			container.NewBorder(nil, nil, container.NewHBox(toolbar, m.infoButton), container.NewHBox(m.sceneSelect, m.tagButton), m.searchEntry),
+			m.tabs,
+		),
+		container.NewVBox(m.suggestions, container.NewBorder(nil, nil, m.kindSelect, nil, m.entryBox)),
 		nil,
-		list,
+		m.combatPanel,
+		container.NewMax(m.list, m.timeline, m.startScreen),
 	)
 	return &MainInterfaceRenderer{
 		cont: cont,
@@ -130,12 +258,12 @@ func (m *MainInterface) HandleSessionInfoButton() {
 	formSize := fyne.NewSize(m.window.Canvas().Size().Width*0.8, m.window.Canvas().Size().Height*0.5)
 	callback := func(confirm bool) {
 		if confirm {
+			title, _ := m.boundTitle.Get()
 			number, _ := m.boundNumber.Get()
 			numAsInt, _ := strconv.Atoi(number)
-			if numAsInt != m.session.SessionNumber {
-				m.session.SessionNumber = numAsInt
-			}
-			m.infoButton.SetText(m.getInfoButtonText())
+			m.SetSessionInfo(title, numAsInt)
+		} else {
+			m.BindSessionInfo()
 		}
 	}
 	dialog := dialog.NewForm("", "Confirm", "Cancel", []*widget.FormItem{titleForm, numberForm}, callback, m.window)
@@ -143,6 +271,39 @@ func (m *MainInterface) HandleSessionInfoButton() {
 	dialog.Show()
 }
 
+// Changes the title and number of the session. Both changes are undone together.
+func (m *MainInterface) SetSessionInfo(title string, number int) {
+	if err := m.session.History().Execute(&backend.SetSessionInfoCommand{Title: title, Number: number}); err != nil && err != backend.ErrNoChange {
+		dialog.ShowError(err, m.window)
+	}
+	m.refreshSessionInfo()
+	m.sessionChanged()
+}
+
+// Reverts the most recent change to the session.
+func (m *MainInterface) Undo() {
+	if err := m.session.History().Undo(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+	m.editing = NOT_EDITING
+	m.refreshSessionInfo()
+	m.RefreshNotes()
+	m.refreshCombat()
+	m.sessionChanged()
+}
+
+// Reapplies the most recently undone change to the session.
+func (m *MainInterface) Redo() {
+	if err := m.session.History().Redo(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+	m.editing = NOT_EDITING
+	m.refreshSessionInfo()
+	m.RefreshNotes()
+	m.refreshCombat()
+	m.sessionChanged()
+}
+
 // Open an existing session file. Shows a dialog box to open a file.
 func (m *MainInterface) Load() {
 	dialog.ShowFileOpen(
@@ -151,6 +312,101 @@ func (m *MainInterface) Load() {
 	)
 }
 
+// Open a campaign directory. Shows a dialog box to pick a folder, then the sessions of the campaign.
+// If the chosen folder is not yet a campaign, the user is asked to name a new one.
+func (m *MainInterface) OpenCampaign() {
+	dialog.ShowFolderOpen(
+		m.openCampaign,
+		m.window,
+	)
+}
+
+// Search every session of the open campaign, or of a chosen folder if no campaign is open.
+// Shows a dialog box listing the matching notes, any of which can be opened.
+func (m *MainInterface) SearchAll() {
+	queryEntry := widget.NewEntry()
+	queryEntry.SetText(m.searchEntry.Text)
+	queryForm := widget.NewFormItem("Search for", queryEntry)
+	callback := func(confirm bool) {
+		if !confirm {
+			return
+		}
+		if m.campaign != nil {
+			results, err := backend.SearchCampaign(m.campaign, queryEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, m.window)
+			}
+			m.showSearchResults(queryEntry.Text, results)
+			return
+		}
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, e error) {
+			if e != nil {
+				dialog.ShowError(e, m.window)
+				return
+			}
+			// the user pressed 'cancel'
+			if dir == nil {
+				return
+			}
+			results, err := backend.SearchDir(dir.Path(), queryEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, m.window)
+			}
+			m.showSearchResults(queryEntry.Text, results)
+		}, m.window)
+	}
+	dialog.ShowForm("Search sessions", "Search", "Cancel", []*widget.FormItem{queryForm}, callback, m.window)
+}
+
+// Export the current session. Shows a dialog box to choose the format and its options, then one to save the file.
+func (m *MainInterface) Export() {
+	m.ExportAs(EXPORT_MARKDOWN)
+}
+
+// Export the current session, starting with the passed format selected.
+// Shows a dialog box to choose the format and its options, then one to save the file.
+func (m *MainInterface) ExportAs(format string) {
+	formatSelect := widget.NewSelect([]string{EXPORT_MARKDOWN, EXPORT_HTML}, nil)
+	timeSelect := widget.NewSelect(EXPORT_TIME_FORMATS, nil)
+	timeSelect.SetSelected(backend.DEFAULT_EXPORT_TIME_FORMAT)
+	darkCheck := widget.NewCheck("", nil)
+	formatSelect.OnChanged = func(selected string) {
+		// only HTML supports themes
+		if selected == EXPORT_HTML {
+			darkCheck.Enable()
+		} else {
+			darkCheck.Disable()
+		}
+	}
+	formatSelect.SetSelected(format)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("Note time format", timeSelect),
+		widget.NewFormItem("Dark theme", darkCheck),
+	}
+	callback := func(confirm bool) {
+		if !confirm {
+			return
+		}
+		options := []backend.ExportOption{backend.WithTimeFormat(timeSelect.Selected)}
+		if darkCheck.Checked {
+			options = append(options, backend.WithDarkTheme())
+		}
+		content, err := m.exportContent(formatSelect.Selected, options)
+		if err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		saveDialog := dialog.NewFileSave(func(uc fyne.URIWriteCloser, e error) {
+			m.export(uc, e, content)
+		}, m.window)
+		saveDialog.SetFileName(m.session.ExportFileName() + EXPORT_EXTENSIONS[formatSelect.Selected])
+		saveDialog.Show()
+	}
+	dialog.ShowForm("Export session", "Export", "Cancel", items, callback, m.window)
+}
+
 // Save the current session. Show a dialog box if the user has yet to save before.
 func (m *MainInterface) Save() {
 	// if the user has yet to save their work
@@ -163,8 +419,11 @@ func (m *MainInterface) Save() {
 		err := m.session.Save()
 		if err != nil {
 			dialog.ShowError(err, m.window)
+		} else {
+			m.sessionSaved()
 		}
-		m.animateIndicator()
+		m.updateCampaign()
+		m.animateIndicator(m.active)
 		m.SetWindowTitle()
 	}
 }
@@ -181,23 +440,50 @@ func (m *MainInterface) SetWindowTitle() {
 		window_title = fmt.Sprintf("Session %d %s", m.session.SessionNumber, m.session.Path)
 	}
 
-	if len(window_title) > MAX_WIN_TITLE_LENGTH {
-		// subtract 3 to account for the max length, subtract 1 because indexing starts at 0
-		window_title = window_title[:MAX_WIN_TITLE_LENGTH-3-1] + "..."
+	window_title = shorten(window_title, MAX_WIN_TITLE_LENGTH)
+	if m.session.IsDirty() {
+		window_title = DIRTY_MARKER + window_title
 	}
 	window_title = fmt.Sprintf(window_title+" - %s", APP_NAME)
 	m.window.SetTitle(window_title)
 }
 
-// Flash the saving indicator.
-func (m *MainInterface) animateIndicator() {
+// Updates everything displaying the title and number of the session.
+func (m *MainInterface) refreshSessionInfo() {
+	m.BindSessionInfo()
+	m.infoButton.SetText(m.getInfoButtonText())
+	m.refreshTitles()
+}
+
+// Updates the window title and the text of the active tab, which show the title of the session and whether it has unsaved changes.
+func (m *MainInterface) refreshTitles() {
+	m.SetWindowTitle()
+	m.active.item.Text = tabTitle(m.session)
+	if m.tabs != nil {
+		m.tabs.Refresh()
+	}
+}
+
+// Updates the title of the passed tab, and the window title if it is the active tab.
+func (m *MainInterface) refreshTabTitle(tab *sessionTab) {
+	tab.item.Text = tabTitle(tab.session)
+	if tab == m.active {
+		m.SetWindowTitle()
+	}
+	if m.tabs != nil {
+		m.tabs.Refresh()
+	}
+}
+
+// Flash the saving indicator of the passed tab.
+func (m *MainInterface) animateIndicator(tab *sessionTab) {
 	disabledToForeground := canvas.NewColorRGBAAnimation(
 		theme.DisabledColor(),
 		theme.ForegroundColor(),
 		canvas.DurationShort,
 		func(c color.Color) {
-			m.indicator.SetColor(c)
-			m.indicator.Refresh()
+			tab.indicator.SetColor(c)
+			tab.indicator.Refresh()
 		})
 	disabledToForeground.AutoReverse = true
 	disabledToForeground.Start()
@@ -218,105 +504,1541 @@ func (m *MainInterface) getInfoButtonText() string {
 	if title != "" {
 		buttonText += " " + m.session.SessionTitle
 	}
+	if m.session.Clock != nil {
+		buttonText += " — " + m.session.GameCalendar().FormatShort(*m.session.Clock)
+	}
 
 	return buttonText
 }
 
+// Recomputes which notes match the search bar and redraws the list of notes.
+func (m *MainInterface) RefreshNotes() {
+	m.visible = nil
+	query := backend.ParseQuery("")
+	if m.searchEntry != nil {
+		query = backend.ParseQuery(m.searchEntry.Text)
+	}
+	if !query.Empty() || len(m.tagFilter) > 0 {
+		m.visible = make([]int, 0)
+		for i, note := range m.session.Notes {
+			if (query.Empty() || query.Matches(note.Content)) && note.HasTags(m.tagFilter) {
+				m.visible = append(m.visible, i)
+			}
+		}
+	}
+	if m.tagButton != nil {
+		m.tagButton.SetText(m.getTagButtonText())
+	}
+	if m.list != nil {
+		m.list.Refresh()
+	}
+	m.refreshScenes()
+}
+
+// Shows a dialog box to choose the tags the notes are filtered by. Only notes with every chosen tag are shown.
+func (m *MainInterface) ShowTagFilter() {
+	tags := m.session.Tags()
+	if len(tags) == 0 {
+		dialog.ShowInformation("Filter by tag", "No notes are tagged yet. Add a tag to a note by writing it with a #, like #loot.", m.window)
+		return
+	}
+
+	checks := container.NewVBox()
+	for _, tag := range tags {
+		check := widget.NewCheck(backend.TAG_PREFIX+tag, nil)
+		check.SetChecked(m.hasTagFilter(tag))
+		checks.Add(check)
+	}
+	callback := func(confirm bool) {
+		if !confirm {
+			return
+		}
+		filter := make([]string, 0)
+		for i, object := range checks.Objects {
+			if object.(*widget.Check).Checked {
+				filter = append(filter, tags[i])
+			}
+		}
+		m.SetTagFilter(filter)
+	}
+	content := container.NewVScroll(checks)
+	content.SetMinSize(fyne.NewSize(200, 200))
+	dialog.ShowCustomConfirm("Filter by tag", "Filter", "Cancel", content, callback, m.window)
+}
+
+// Shows only the notes tagged with every one of the passed tags. An empty filter shows every note.
+func (m *MainInterface) SetTagFilter(tags []string) {
+	m.tagFilter = tags
+	m.RefreshNotes()
+}
+
+// Adds a tag to the tags the notes are filtered by, or removes it if it is already filtered by.
+func (m *MainInterface) ToggleTagFilter(tag string) {
+	filter := make([]string, 0, len(m.tagFilter)+1)
+	for _, t := range m.tagFilter {
+		if t != tag {
+			filter = append(filter, t)
+		}
+	}
+	if len(filter) == len(m.tagFilter) {
+		filter = append(filter, tag)
+	}
+	m.SetTagFilter(filter)
+}
+
+// Reports whether the notes are filtered by the passed tag.
+func (m *MainInterface) hasTagFilter(tag string) bool {
+	for _, t := range m.tagFilter {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Builds the string that will serve as the tag filter button text.
+func (m *MainInterface) getTagButtonText() string {
+	if len(m.tagFilter) == 0 {
+		return "Tags"
+	}
+	return "Tags (" + strconv.Itoa(len(m.tagFilter)) + ")"
+}
+
+// Returns the index within the session of the note displayed at position i of the list.
+func (m *MainInterface) noteIndex(i int) int {
+	if m.visible == nil {
+		return i
+	}
+	return m.visible[i]
+}
+
 // Returns the length of the data the list widget is displaying.
 func (m *MainInterface) listLength() int {
-	return len(m.session.Notes)
+	if m.visible == nil {
+		return len(m.session.Notes)
+	}
+	return len(m.visible)
+}
+
+// Sets the note displayed by a note box of the list widget.
+func (m *MainInterface) listUpdateItem(i int, noteBox *gui.NoteBox) {
+	index := m.noteIndex(i)
+	noteBox.SetNote(m.session.Notes[index])
+	noteBox.OnEdit = func() {
+		m.EditNote(index)
+	}
+	noteBox.OnDelete = func() {
+		m.DeleteNote(index)
+	}
+	noteBox.OnMoveUp = func() {
+		m.MoveNote(index, index-1)
+	}
+	noteBox.OnMoveDown = func() {
+		m.MoveNote(index, index+1)
+	}
+	noteBox.OnEdited = func(content string) {
+		m.updateNote(index, content)
+	}
+	noteBox.OnEditCancelled = m.stopEditing
+	noteBox.OnTagTapped = m.ToggleTagFilter
+	noteBox.OnEntityTapped = m.ShowEntity
+	noteBox.OnKindChanged = func(kind backend.NoteKind) {
+		m.SetNoteKind(index, kind)
+	}
+	noteBox.SetMentions(m.findMentions(m.session.Notes[index].Content))
+	if m.gameTime {
+		calendar := m.session.GameCalendar()
+		noteBox.ShowGameTime(&calendar)
+	} else {
+		noteBox.ShowGameTime(nil)
+	}
+	noteBox.SetEditing(m.editing == index)
 }
 
-// Creates a template item for the list widget.
-func (m *MainInterface) listCreateItem() fyne.CanvasObject {
-	return gui.NewNoteBox("", time.Time{})
+// Switches the notes between showing the real time they were taken and the in-game time.
+func (m *MainInterface) ToggleGameTime() {
+	m.gameTime = !m.gameTime
+	m.RefreshNotes()
+	m.window.SetMainMenu(m.MainMenu())
 }
 
-// Sets the actual content of a template item for the list widget when it is displayed.
-func (m *MainInterface) listUpdateItem(i widget.ListItemID, o fyne.CanvasObject) {
-	o.(*gui.NoteBox).SetContent(m.session.Notes[i].Content)
-	o.(*gui.NoteBox).SetTime(m.session.Notes[i].Time)
+// Sets, advances, or stops the in-game clock. Shows a dialog box accepting the same input as the /clock command.
+func (m *MainInterface) SetGameClock() {
+	clockEntry := widget.NewEntry()
+	clockEntry.SetPlaceHolder("1 Hammer 1491 9:00, +2h, or off")
+	if m.session.Clock != nil {
+		clockEntry.SetText(m.session.GameCalendar().Format(*m.session.Clock))
+	}
+	items := []*widget.FormItem{widget.NewFormItem("In-game time", clockEntry)}
+	dialog.ShowForm("In-game time", "Set", "Cancel", items, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		m.runCommand("clock " + clockEntry.Text)
+	}, m.window)
 }
 
-// Creates a session from the loaded data. Displays a dialog box if there is an error loading the session.
-func (m *MainInterface) load(uc fyne.URIReadCloser, e error) {
-	// the user pressed 'cancel'
-	if uc == nil {
+// Shows or hides the timeline grouping the notes by scene in place of the list of notes.
+func (m *MainInterface) ToggleTimeline() {
+	m.hideStartScreen()
+	if m.timeline.Visible() {
+		m.timeline.Hide()
+		m.list.Show()
+	} else {
+		m.list.Hide()
+		m.timeline.Refresh()
+		m.timeline.Show()
+	}
+	m.window.SetMainMenu(m.MainMenu())
+}
+
+// Starts a new scene. Shows a dialog box to enter its title, numbering the scene if none is entered.
+func (m *MainInterface) NewScene() {
+	titleEntry := widget.NewEntry()
+	titleEntry.SetPlaceHolder("Scene " + strconv.Itoa(len(m.session.SceneMarkers())+1))
+	items := []*widget.FormItem{widget.NewFormItem("Title", titleEntry)}
+	dialog.ShowForm("New scene", "Start", "Cancel", items, func(confirm bool) {
+		if confirm {
+			m.runCommand("scene " + titleEntry.Text)
+		}
+	}, m.window)
+}
+
+// Marks a pause in play.
+func (m *MainInterface) Break() {
+	m.runCommand("break")
+}
+
+// Runs the slash command written without its prefix, as if it had been typed into the entry field.
+func (m *MainInterface) runCommand(text string) {
+	if err := m.entry.Commands().Run(m.session, backend.SLASH_PREFIX+text, m.entry.Kind); err != nil {
+		dialog.ShowError(err, m.window)
 		return
 	}
+	m.commandRun()
+}
+
+// Scrolls to the scene starting with the note at the passed index, clearing any filters that would hide it.
+func (m *MainInterface) JumpToScene(marker int) {
+	m.hideStartScreen()
+	if m.timeline.Visible() {
+		m.timeline.ScrollToScene(marker)
+		return
+	}
+	m.clearFilters()
+	m.list.ScrollTo(marker)
+}
 
-	builder := new(strings.Builder)
-	data := make([]byte, 1024)
+// Shows the note at index i of the session selected in the list, clearing any filters that would hide it.
+func (m *MainInterface) ShowNote(i int) {
+	m.hideStartScreen()
+	if m.timeline.Visible() {
+		m.ToggleTimeline()
+	}
+	m.clearFilters()
+	m.list.Select(i)
+}
 
-	// read data from file into array
-	for {
-		_, err := uc.Read(data)
-		if err == io.EOF {
-			break
+// Removes the tag filter and search so that every note is shown.
+func (m *MainInterface) clearFilters() {
+	m.tagFilter = nil
+	m.searchEntry.SetText("")
+	m.RefreshNotes()
+}
+
+// Jumps to the scene chosen in the scene dropdown, then clears the dropdown so that the same scene can be chosen again.
+func (m *MainInterface) sceneChosen() {
+	i := m.sceneSelect.SelectedIndex()
+	markers := m.session.SceneMarkers()
+	if i < 0 || i >= len(markers) {
+		return
+	}
+	m.JumpToScene(markers[i])
+	m.sceneSelect.ClearSelected()
+}
+
+// Updates the scene dropdown and the timeline to show the scenes of the session.
+func (m *MainInterface) refreshScenes() {
+	if m.sceneSelect == nil {
+		return
+	}
+	markers := m.session.SceneMarkers()
+	options := make([]string, 0, len(markers))
+	for _, marker := range markers {
+		options = append(options, m.session.Notes[marker].Content)
+	}
+	m.sceneSelect.Options = options
+	m.sceneSelect.Refresh()
+	if m.timeline.Visible() {
+		m.timeline.Refresh()
+	}
+}
+
+// Starts editing the note at index i of the session inline.
+func (m *MainInterface) EditNote(i int) {
+	m.editing = i
+	m.list.Refresh()
+}
+
+// Asks the user to confirm, then removes the note at index i from the session.
+func (m *MainInterface) DeleteNote(i int) {
+	callback := func(confirm bool) {
+		if !confirm {
+			return
 		}
-		if err != nil {
+		if err := m.session.History().Execute(&backend.DeleteNoteCommand{Index: i}); err != nil && err != backend.ErrNoChange {
 			dialog.ShowError(err, m.window)
 		}
+		m.editing = NOT_EDITING
+		m.RefreshNotes()
+		m.sessionChanged()
 	}
+	dialog.ShowConfirm("Delete note", "Are you sure you want to delete this note?", callback, m.window)
+}
 
-	// write data into string builder
-	builder.Write(data)
+// Moves the note at index from of the session to index to. Moves past either end of the notes are ignored.
+func (m *MainInterface) MoveNote(from int, to int) {
+	if to < 0 || to >= len(m.session.Notes) {
+		return
+	}
+	if err := m.session.History().Execute(&backend.MoveNoteCommand{From: from, To: to}); err != nil && err != backend.ErrNoChange {
+		dialog.ShowError(err, m.window)
+	}
+	m.editing = NOT_EDITING
+	m.RefreshNotes()
+	m.sessionChanged()
+}
 
-	// construct session from string
-	var err error
-	m.session, err = backend.FromJSON(builder.String())
-	m.entry.SetSession(m.session)
-	if err != nil {
+// Changes the kind of the note at index i of the session. The change can be undone.
+func (m *MainInterface) SetNoteKind(i int, kind backend.NoteKind) {
+	if err := m.session.History().Execute(&backend.SetKindCommand{Index: i, Kind: kind}); err != nil && err != backend.ErrNoChange {
 		dialog.ShowError(err, m.window)
 	}
-	m.session.Path = uc.URI().Path()
-	m.BindSessionInfo()
-	m.SetWindowTitle()
-	m.infoButton.SetText(m.getInfoButtonText())
+	m.RefreshNotes()
+	m.sessionChanged()
 }
 
-// Writes the current session to file, and displays a dialog box with any errors if they occur.
-func (m *MainInterface) save(uc fyne.URIWriteCloser, e error) {
-	// the user pressed 'cancel'
-	if uc == nil {
+// Replaces the content of the note being edited, then stops editing it.
+func (m *MainInterface) updateNote(i int, content string) {
+	command := &backend.UpdateNoteCommand{Index: i, Content: content, Time: time.Now()}
+	if err := m.session.History().Execute(command); err != nil && err != backend.ErrNoChange {
+		dialog.ShowError(err, m.window)
 		return
 	}
+	m.stopEditing()
+	m.sessionChanged()
+}
 
-	reader := strings.NewReader(m.session.ToJSON())
-	_, err := reader.WriteTo(uc)
+// Refreshes the list after a note is added through the entry field, and counts it towards the next autosave.
+func (m *MainInterface) noteAdded() {
+	m.refreshTitles()
+	m.hideStartScreen()
+	m.RefreshNotes()
+	m.list.ScrollToBottom()
+	if m.active.autosaver != nil {
+		m.active.autosaver.NoteAdded()
+	}
+}
 
-	if e != nil {
-		dialog.ShowError(e, m.window)
+// Shows or hides the combat tracker.
+func (m *MainInterface) ToggleCombatTracker() {
+	if m.combatPanel.Visible() {
+		m.combatPanel.Hide()
+	} else {
+		m.combatPanel.Show()
 	}
-	if err != nil {
+	m.Refresh()
+}
+
+// Starts tracking a fight, showing the combat tracker.
+func (m *MainInterface) StartCombat() {
+	m.changeCombat(m.session.StartCombat)
+	m.AddCombatant()
+}
+
+// Stops tracking the fight.
+func (m *MainInterface) EndCombat() {
+	m.changeCombat(m.session.EndCombat)
+}
+
+// Ends the turn of the current combatant, recording what happened during it.
+func (m *MainInterface) NextTurn() {
+	m.updateCombat(func(c *backend.Combat) error {
+		c.NextTurn()
+		return nil
+	})
+}
+
+// Adds a combatant to the fight. Shows a dialog box to enter its name, initiative, and hit points.
+func (m *MainInterface) AddCombatant() {
+	nameEntry := widget.NewEntry()
+	initiativeEntry := widget.NewEntry()
+	initiativeEntry.Validator = validateInteger
+	hpEntry := widget.NewEntry()
+	hpEntry.Validator = validateInteger
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Initiative", initiativeEntry),
+		widget.NewFormItem("Hit points", hpEntry),
+	}
+	dialog.ShowForm("Add combatant", "Add", "Cancel", items, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		initiative, _ := strconv.Atoi(initiativeEntry.Text)
+		hp, _ := strconv.Atoi(hpEntry.Text)
+		m.updateCombat(func(c *backend.Combat) error {
+			return c.AddCombatant(backend.Combatant{Name: nameEntry.Text, Initiative: initiative, HP: hp, MaxHP: hp})
+		})
+	}, m.window)
+}
+
+// Removes the combatant at index i from the fight.
+func (m *MainInterface) RemoveCombatant(i int) {
+	m.updateCombat(func(c *backend.Combat) error {
+		return c.RemoveCombatant(i)
+	})
+}
+
+// Damages or heals the combatant at index i. Shows a dialog box to enter the amount.
+func (m *MainInterface) ChangeHP(i int) {
+	amountEntry := widget.NewEntry()
+	amountEntry.Validator = validateInteger
+	change := widget.NewRadioGroup([]string{"Damage", "Heal"}, nil)
+	change.SetSelected("Damage")
+	change.Horizontal = true
+	items := []*widget.FormItem{
+		widget.NewFormItem("", change),
+		widget.NewFormItem("Amount", amountEntry),
+	}
+	dialog.ShowForm(m.session.Combat.Combatants[i].Name, "Apply", "Cancel", items, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		amount, _ := strconv.Atoi(amountEntry.Text)
+		m.updateCombat(func(c *backend.Combat) error {
+			if change.Selected == "Heal" {
+				return c.Heal(i, amount)
+			}
+			return c.Damage(i, amount)
+		})
+	}, m.window)
+}
+
+// Changes the conditions of the combatant at index i. Shows a dialog box to enter them, separated by commas.
+func (m *MainInterface) EditConditions(i int) {
+	conditionsEntry := widget.NewEntry()
+	conditionsEntry.SetText(strings.Join(m.session.Combat.Combatants[i].Conditions, ", "))
+	conditionsEntry.SetPlaceHolder("poisoned, prone")
+	items := []*widget.FormItem{widget.NewFormItem("Conditions", conditionsEntry)}
+	dialog.ShowForm(m.session.Combat.Combatants[i].Name, "Save", "Cancel", items, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		m.updateCombat(func(c *backend.Combat) error {
+			return c.SetConditions(i, strings.Split(conditionsEntry.Text, ","))
+		})
+	}, m.window)
+}
+
+// Makes a change to the fight, then refreshes the combat tracker and the notes. Shows the error of the change if it fails.
+func (m *MainInterface) changeCombat(change func() error) {
+	length := len(m.session.Notes)
+	if err := change(); err != nil {
 		dialog.ShowError(err, m.window)
+		return
 	}
-	m.session.Path = uc.URI().Path()
-	m.SetWindowTitle()
+	m.refreshCombat()
+	if len(m.session.Notes) > length {
+		m.noteAdded()
+	} else {
+		m.RefreshNotes()
+	}
+	m.sessionChanged()
 }
 
-// Create an interface. This interface composes the entire window.
-func NewMainInterface(window fyne.Window) *MainInterface {
-	session := backend.NewSession(DEFAULT_SESSION_NAME, backend.NO_SESSION_NUMBER)
-	mi := &MainInterface{session: session, window: window}
-	textEntry := gui.NewEnterEntry(mi.session)
-	mi.entry = textEntry
-	mi.ExtendBaseWidget(mi)
-	return mi
+// Makes a change to a copy of the fight that can be undone, then refreshes the combat tracker and the notes.
+func (m *MainInterface) updateCombat(change func(c *backend.Combat) error) {
+	m.changeCombat(func() error {
+		return m.session.ChangeCombat(change)
+	})
 }
 
-// Apply custom settings to the window.
-func setUpWindow(window fyne.Window) {
-	main := NewMainInterface(window)
-	main.window.SetTitle(main.session.SessionTitle + " - " + APP_NAME)
-	main.window.SetContent(main)
-	main.window.Resize(fyne.NewSize(STARTING_WIDTH, STARTING_HEIGHT))
-	main.window.Canvas().Focus(main.entry)
+// Validates that the text of an entry is a whole number.
+func validateInteger(text string) error {
+	if _, err := strconv.Atoi(text); err != nil {
+		return errors.New("Must be a whole number")
+	}
+	return nil
 }
 
-func main() {
-	a := app.New()
-	w := a.NewWindow(APP_NAME)
-	setUpWindow(w)
+// Updates the combat tracker to show the fight of the session. The tracker is shown whenever there is a fight.
+func (m *MainInterface) refreshCombat() {
+	if m.combatPanel == nil {
+		return
+	}
+	m.combatPanel.SetCombat(m.session.Combat)
+	if m.session.Combat != nil && !m.combatPanel.Visible() {
+		m.combatPanel.Show()
+		m.Refresh()
+	}
+}
+
+// Refreshes everything a slash command may have changed.
+func (m *MainInterface) commandRun() {
+	m.hideStartScreen()
+	m.editing = NOT_EDITING
+	m.refreshSessionInfo()
+	m.RefreshNotes()
+	m.refreshCombat()
+	m.sessionChanged()
+}
+
+// Exports the session in the format named by the argument of the /export command, Markdown unless named otherwise.
+func (m *MainInterface) runExport(call backend.SlashCall) error {
+	format := EXPORT_MARKDOWN
+	if len(call.Args) > 0 {
+		switch strings.ToLower(call.Args[0]) {
+		case "md", "markdown":
+			format = EXPORT_MARKDOWN
+		case "html":
+			format = EXPORT_HTML
+		default:
+			return errors.New("Cannot export to " + call.Args[0] + ", use /export md or /export html")
+		}
+	}
+	m.ExportAs(format)
+	return nil
+}
+
+// Shows an error that kept the text in the entry field from being added as a note.
+func (m *MainInterface) showError(err error) {
+	dialog.ShowError(err, m.window)
+}
+
+// Records that the session changed so that it will be autosaved.
+func (m *MainInterface) sessionChanged() {
+	m.refreshTitles()
+	if m.active.autosaver != nil {
+		m.active.autosaver.Changed()
+	}
+}
+
+// Records that the session was saved, so that it does not need to be autosaved. Only the journal of the active tab is cleared.
+func (m *MainInterface) sessionSaved() {
+	m.refreshTitles()
+	if m.active.autosaver == nil {
+		return
+	}
+	if err := m.active.autosaver.Saved(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+}
+
+// Starts saving the session of every tab automatically. Sessions that have not been saved to a file yet are saved to
+// a journal of their own in the passed directory. The saving indicator of a tab flashes on every automatic save of its session.
+func (m *MainInterface) StartAutosave(dir string) {
+	m.journalDir = dir
+	for _, tab := range m.sessionTabs {
+		m.startAutosave(tab)
+	}
+}
+
+// Starts saving the session of the passed tab automatically, to a journal no other tab uses.
+func (m *MainInterface) startAutosave(tab *sessionTab) {
+	tab.autosaver = backend.NewAutosaver(tab.session, m.newJournal())
+	tab.autosaver.OnSave = func(err error) {
+		if err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		m.animateIndicator(tab)
+		// sessions with a path are saved to their file, leaving no unsaved changes
+		m.refreshTabTitle(tab)
+	}
+	tab.autosaver.Start()
+}
+
+// Returns a journal for a tab that is neither the journal of another tab nor one left behind to recover.
+func (m *MainInterface) newJournal() *backend.Journal {
+	for n := 1; ; n++ {
+		path := backend.JournalPath(m.journalDir, n)
+		if _, err := os.Stat(path); err != nil && !m.journalOpen(path) {
+			return backend.NewJournal(path)
+		}
+	}
+}
+
+// Reports whether the journal at the passed path is the journal of an open tab.
+func (m *MainInterface) journalOpen(path string) bool {
+	for _, tab := range m.sessionTabs {
+		if tab.autosaver != nil && tab.autosaver.Journal().Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Asks the user whether to recover the sessions left in the journals in the passed directory, if there are any.
+// Each recovered session is opened in a tab and saved to the journal of that tab before the journal it was recovered from is cleared.
+// The journals are cleared if the user declines. Journals of open tabs are left alone.
+func (m *MainInterface) OfferRecovery(dir string) {
+	found, err := backend.FindJournals(dir)
+	if err != nil {
+		dialog.ShowError(err, m.window)
+		return
+	}
+	journals := make([]*backend.Journal, 0, len(found))
+	for _, journal := range found {
+		if !m.journalOpen(journal.Path) {
+			journals = append(journals, journal)
+		}
+	}
+	if len(journals) == 0 {
+		return
+	}
+
+	callback := func(confirm bool) {
+		for _, journal := range journals {
+			if !confirm {
+				if err := journal.Clear(); err != nil {
+					dialog.ShowError(err, m.window)
+				}
+				continue
+			}
+			session, err := journal.Recover()
+			if err != nil {
+				dialog.ShowError(err, m.window)
+				continue
+			}
+			m.OpenSession(session)
+			m.sessionChanged()
+			// the recovered journal is only cleared once the session is safe in the journal of its tab
+			if m.active.autosaver == nil {
+				continue
+			}
+			if err := m.active.autosaver.Save(); err != nil {
+				dialog.ShowError(err, m.window)
+				continue
+			}
+			if err := journal.Clear(); err != nil {
+				dialog.ShowError(err, m.window)
+			}
+		}
+	}
+	message := "Archon closed before your last session was saved. Recover it?"
+	if len(journals) > 1 {
+		message = fmt.Sprintf("Archon closed before %d sessions were saved. Recover them?", len(journals))
+	}
+	dialog.ShowConfirm("Recover unsaved sessions?", message, callback, m.window)
+}
+
+// Stops editing the note being edited inline and returns focus to the entry field.
+func (m *MainInterface) stopEditing() {
+	m.editing = NOT_EDITING
+	m.RefreshNotes()
+	m.window.Canvas().Focus(m.entry)
+}
+
+// Creates a session from the loaded data. Displays a dialog box if there is an error loading the session.
+func (m *MainInterface) load(uc fyne.URIReadCloser, e error) {
+	if e != nil {
+		dialog.ShowError(e, m.window)
+		return
+	}
+	// the user pressed 'cancel'
+	if uc == nil {
+		return
+	}
+
+	path := uc.URI().Path()
+	var size int64
+	if info, err := os.Stat(path); err == nil {
+		size = info.Size()
+	}
+	if size < backend.LARGE_SESSION_SIZE {
+		session, err := backend.ReadSession(uc, backend.WithPath(path), backend.WithSize(size))
+		uc.Close()
+		m.opened(session, path, err)
+		return
+	}
+
+	// large sessions are read in the background so that the window keeps responding and shows the progress of reading them
+	progress := dialog.NewProgress("Opening session", "Reading "+uc.URI().Name()+"…", m.window)
+	progress.Show()
+	readInBackground(uc, path, size, progress.SetValue, func(session *backend.Session, err error) {
+		progress.Hide()
+		m.opened(session, path, err)
+	})
+}
+
+// Reads a session on another goroutine, passing the fraction of it read so far to progress as it is read.
+// Returns at once. The reader is closed once the session is read, then done is called with the session and any error reading it.
+func readInBackground(uc fyne.URIReadCloser, path string, size int64, progress func(float64), done func(*backend.Session, error)) {
+	go func() {
+		session, err := backend.ReadSession(uc, backend.WithPath(path), backend.WithSize(size), backend.WithProgress(func(read int64, total int64) {
+			progress(float64(read) / float64(total))
+		}))
+		uc.Close()
+		done(session, err)
+	}()
+}
+
+// Displays a session read from the file at the passed path, remembering it as recently opened. If the session could not be read,
+// the open session is kept unchanged and a dialog box says what went wrong instead.
+func (m *MainInterface) opened(session *backend.Session, path string, err error) {
+	if err != nil {
+		m.showLoadError(err)
+		return
+	}
+	session.Path = path
+	m.OpenSession(session)
+	m.rememberSession(path)
+}
+
+// Opens the session file at the passed path. A session recovered from a backup is opened after warning about the corrupt file.
+// Files that no longer exist are forgotten.
+func (m *MainInterface) OpenRecent(path string) {
+	session, err := backend.Load(path)
+	if err != nil && !backend.RecoveredFromBackup(err) {
+		if errors.Is(err, fs.ErrNotExist) {
+			m.forgetSession(path)
+		}
+		m.showLoadError(err)
+		return
+	}
+	if err != nil {
+		dialog.ShowError(err, m.window)
+	}
+	m.opened(session, path, nil)
+}
+
+// Opens the session opened or saved most recently, if reopening it on startup was chosen.
+func (m *MainInterface) ReopenLastSession() {
+	if !m.preferences.Bool(PREF_REOPEN_LAST) {
+		return
+	}
+	if path, ok := m.recent.Last(); ok {
+		m.OpenRecent(path)
+	}
+}
+
+// Chooses whether the session opened or saved most recently is reopened on startup.
+func (m *MainInterface) SetReopenLast(reopenLast bool) {
+	m.preferences.SetBool(PREF_REOPEN_LAST, reopenLast)
+	if m.startScreen != nil {
+		m.startScreen.SetReopenLast(reopenLast)
+	}
+	m.window.SetMainMenu(m.MainMenu())
+}
+
+// Remembers the session file at the passed path as the one opened or saved most recently.
+func (m *MainInterface) rememberSession(path string) {
+	m.recent.Add(path)
+	m.recentChanged()
+}
+
+// Forgets the session file at the passed path, so that it is no longer offered as a recent session.
+func (m *MainInterface) forgetSession(path string) {
+	m.recent.Remove(path)
+	m.recentChanged()
+}
+
+// Stores the recent sessions in the preferences and updates everything offering them.
+func (m *MainInterface) recentChanged() {
+	m.preferences.SetString(PREF_RECENT_SESSIONS, m.recent.String())
+	if m.startScreen != nil {
+		m.startScreen.SetRecent(m.recent.Paths())
+	}
+	m.window.SetMainMenu(m.MainMenu())
+}
+
+// Replaces the start screen with the list of notes, if it is shown.
+func (m *MainInterface) hideStartScreen() {
+	if m.startScreen == nil || !m.startScreen.Visible() {
+		return
+	}
+	m.startScreen.Hide()
+	m.list.Show()
+}
+
+// Shows a dialog box describing why a session could not be opened: the file, where in it the problem is, and which field failed, as far as they are known.
+func (m *MainInterface) showLoadError(err error) {
+	var loadErr *backend.LoadError
+	if !errors.As(err, &loadErr) {
+		dialog.ShowError(err, m.window)
+		return
+	}
+	message := widget.NewLabel("The session that was open has been kept.")
+	content := container.NewVBox(message, widget.NewForm(loadErrorItems(loadErr)...))
+	dialog.ShowCustom("Could not open session", "OK", content, m.window)
+}
+
+// Builds the rows of the dialog box describing why a session could not be opened, leaving out what is not known.
+func loadErrorItems(e *backend.LoadError) []*widget.FormItem {
+	items := []*widget.FormItem{widget.NewFormItem("File", widget.NewLabel(e.Path))}
+	if e.Line > 0 {
+		position := fmt.Sprintf("Line %d, column %d (byte %d)", e.Line, e.Column, e.Offset)
+		items = append(items, widget.NewFormItem("Position", widget.NewLabel(position)))
+	}
+	if e.Field != "" {
+		items = append(items, widget.NewFormItem("Field", widget.NewLabel(e.Field)))
+	}
+	problem := widget.NewLabel(e.Err.Error())
+	problem.Wrapping = fyne.TextWrapWord
+	return append(items, widget.NewFormItem("Problem", problem))
+}
+
+// Replaces the session of the active tab with the passed session.
+func (m *MainInterface) SetSession(session *backend.Session) {
+	m.session = session
+	m.active.session = session
+	m.editing = NOT_EDITING
+	m.hideStartScreen()
+	m.entry.SetSession(m.session)
+	if m.active.autosaver != nil {
+		m.active.autosaver.SetSession(m.session)
+	}
+	m.refreshSessionInfo()
+	if m.timeline != nil {
+		m.timeline.SetSession(m.session)
+	}
+	m.RefreshNotes()
+	m.refreshCombat()
+}
+
+// Shows the passed session in a new tab, or switches to its tab if it is already open. The session replaces the session
+// of the active tab instead if that session is new and has not been changed, so that opening a file at startup does not leave an empty tab behind.
+func (m *MainInterface) OpenSession(session *backend.Session) {
+	for _, tab := range m.sessionTabs {
+		if tab.session == session || (session.Path != "" && tab.session.Path == session.Path) {
+			m.activateTab(tab)
+			return
+		}
+	}
+	if m.active.session.Path == "" && len(m.active.session.Notes) == 0 && !m.active.session.IsDirty() {
+		m.SetSession(session)
+		return
+	}
+	m.activateTab(m.newTab(session))
+}
+
+// Opens a new tab with an untitled session.
+func (m *MainInterface) NewTab() {
+	m.activateTab(m.newTab(backend.NewSession(DEFAULT_SESSION_NAME, backend.NO_SESSION_NUMBER)))
+}
+
+// Closes the active tab, showing the tab before it. Closing the last tab leaves a tab with an untitled session.
+// If the session has unsaved changes, the user is first asked whether to save them.
+func (m *MainInterface) CloseTab() {
+	m.confirmDiscard("closing it", m.closeTab)
+}
+
+// Closes the active tab without asking about unsaved changes. Only the autosaving of the closed tab is stopped.
+func (m *MainInterface) closeTab() {
+	closing := m.active
+	if closing.autosaver != nil {
+		closing.autosaver.Stop()
+	}
+	index := 0
+	for i, tab := range m.sessionTabs {
+		if tab == closing {
+			index = i
+		}
+	}
+	if len(m.sessionTabs) == 1 {
+		m.NewTab()
+	} else if index > 0 {
+		m.activateTab(m.sessionTabs[index-1])
+	} else {
+		m.activateTab(m.sessionTabs[1])
+	}
+	for i, tab := range m.sessionTabs {
+		if tab == closing {
+			m.sessionTabs = append(m.sessionTabs[:i], m.sessionTabs[i+1:]...)
+			break
+		}
+	}
+	if m.tabs != nil {
+		m.tabs.Remove(closing.item)
+		m.tabs.SelectTab(m.active.item)
+	}
+}
+
+// Creates a tab for the passed session, with its own entry field and saving indicator, after the other tabs.
+func (m *MainInterface) newTab(session *backend.Session) *sessionTab {
+	entry := gui.NewEnterEntry(session)
+	entry.OnNoteAdded = m.noteAdded
+	entry.OnError = m.showError
+	entry.OnCommand = m.commandRun
+	entry.Commands().Register(backend.NewSlashCommand("export", "md|html", "Export the session", m.runExport))
+	if m.suggestions != nil {
+		entry.OnSuggest = m.suggestions.SetSuggestions
+	}
+	m.addShortcuts(entry)
+
+	indicator := gui.NewSavingIndicator()
+	tab := &sessionTab{
+		item:      container.NewTabItem(tabTitle(session), indicator),
+		session:   session,
+		entry:     entry,
+		indicator: indicator,
+		editing:   NOT_EDITING,
+	}
+	m.sessionTabs = append(m.sessionTabs, tab)
+	if m.tabs != nil {
+		m.tabs.Append(tab.item)
+	}
+	if m.journalDir != "" {
+		m.startAutosave(tab)
+	}
+	return tab
+}
+
+// Shows the session of the passed tab, keeping the edit, filters, and search of the tab being left for when it is shown again.
+func (m *MainInterface) activateTab(tab *sessionTab) {
+	if tab == m.active {
+		return
+	}
+	if m.active != nil {
+		m.active.editing = m.editing
+		m.active.tagFilter = m.tagFilter
+		if m.searchEntry != nil {
+			m.active.search = m.searchEntry.Text
+		}
+	}
+	m.active = tab
+	m.session = tab.session
+	m.entry = tab.entry
+	m.editing = tab.editing
+	m.tagFilter = tab.tagFilter
+	if m.tabs == nil {
+		// the views are built from the active tab when the interface is first rendered
+		return
+	}
+
+	m.tabs.SelectTab(tab.item)
+	m.entryBox.Objects = []fyne.CanvasObject{tab.entry}
+	m.entryBox.Refresh()
+	m.kindSelect.SetSelected(gui.StyleOf(tab.entry.Kind).Label)
+	m.suggestions.SetSuggestions(nil)
+	m.hideStartScreen()
+	m.timeline.SetSession(m.session)
+	m.searchEntry.SetText(tab.search)
+	m.refreshSessionInfo()
+	m.RefreshNotes()
+	m.refreshCombat()
+	m.window.Canvas().Focus(m.entry)
+}
+
+// Closes the window. The user is first asked whether to save every session with unsaved changes, one tab at a time,
+// and the window stays open if any question is cancelled.
+func (m *MainInterface) Quit() {
+	m.quitFrom(0)
+}
+
+// Asks about the unsaved changes of the tabs from index first on, then closes the window.
+func (m *MainInterface) quitFrom(first int) {
+	for i := first; i < len(m.sessionTabs); i++ {
+		if m.sessionTabs[i].session.IsDirty() {
+			next := i + 1
+			m.activateTab(m.sessionTabs[i])
+			m.confirmDiscard("quitting", func() {
+				m.quitFrom(next)
+			})
+			return
+		}
+	}
+	// no session may be autosaved while, or after, the window closes
+	for _, tab := range m.sessionTabs {
+		if tab.autosaver != nil {
+			tab.autosaver.Stop()
+		}
+	}
+	m.window.Close()
+}
+
+// Asks whether to save the unsaved changes of the session of the active tab before the passed action, like "quitting",
+// then calls proceed unless the user cancels. Proceeds at once if the session has no unsaved changes.
+func (m *MainInterface) confirmDiscard(action string, proceed func()) {
+	if !m.session.IsDirty() {
+		proceed()
+		return
+	}
+	var confirm dialog.Dialog
+	saveButton := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		confirm.Hide()
+		m.saveThen(proceed)
+	})
+	saveButton.Importance = widget.HighImportance
+	discardButton := widget.NewButtonWithIcon("Discard", theme.DeleteIcon(), func() {
+		confirm.Hide()
+		m.sessionDiscarded()
+		proceed()
+	})
+	message := widget.NewLabel("Save the changes to " + m.session.Heading() + " before " + action + "?")
+	message.Wrapping = fyne.TextWrapWord
+	content := container.NewVBox(message, container.NewHBox(layout.NewSpacer(), discardButton, saveButton))
+	confirm = dialog.NewCustom("Unsaved changes", "Cancel", content, m.window)
+	confirm.Resize(fyne.NewSize(STARTING_WIDTH*2/3, 0))
+	confirm.Show()
+}
+
+// Saves the session of the active tab, asking where if it has never been saved, then calls proceed if it was saved.
+func (m *MainInterface) saveThen(proceed func()) {
+	if m.session.Path != "" {
+		m.Save()
+		if !m.session.IsDirty() {
+			proceed()
+		}
+		return
+	}
+	dialog.ShowFileSave(func(uc fyne.URIWriteCloser, e error) {
+		m.save(uc, e)
+		if !m.session.IsDirty() {
+			proceed()
+		}
+	}, m.window)
+}
+
+// Records that the unsaved changes of the session of the active tab were discarded, so they are not offered for recovery.
+func (m *MainInterface) sessionDiscarded() {
+	if m.active.autosaver == nil {
+		return
+	}
+	if err := m.active.autosaver.Saved(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+}
+
+// Adds the shortcuts of the window to an entry field. The entry field is almost always focused,
+// and focused widgets receive shortcuts before the window does.
+func (m *MainInterface) addShortcuts(entry *gui.EnterEntry) {
+	for shortcut, handler := range m.shortcuts {
+		handler := handler
+		entry.AddShortcut(shortcut, func(fyne.Shortcut) { handler() })
+	}
+}
+
+// Shortens the passed text to at most max characters, ending it with an ellipsis if it was cut.
+// Counted in characters rather than bytes, so that no character is cut in half.
+func shorten(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// Builds the text of the tab of a session from its number and title, marked if the session has unsaved changes.
+func tabTitle(session *backend.Session) string {
+	title := shorten(session.Heading(), MAX_TAB_TITLE_LENGTH)
+	if session.IsDirty() {
+		title = DIRTY_MARKER + title
+	}
+	return title
+}
+
+// Opens or creates the campaign in the chosen folder. Displays a dialog box if there is an error loading the campaign.
+func (m *MainInterface) openCampaign(dir fyne.ListableURI, e error) {
+	if e != nil {
+		dialog.ShowError(e, m.window)
+		return
+	}
+	// the user pressed 'cancel'
+	if dir == nil {
+		return
+	}
+
+	if backend.IsCampaignDir(dir.Path()) {
+		campaign, err := backend.LoadCampaign(dir.Path())
+		if err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		m.campaign = campaign
+		m.showCampaignBrowser()
+		return
+	}
+
+	nameEntry := widget.NewEntry()
+	nameForm := widget.NewFormItem("Campaign name", nameEntry)
+	callback := func(confirm bool) {
+		if !confirm {
+			return
+		}
+		campaign := backend.NewCampaign(nameEntry.Text, dir.Path())
+		if err := campaign.Save(); err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		m.campaign = campaign
+		m.showCampaignBrowser()
+	}
+	dialog.ShowForm("New campaign", "Create", "Cancel", []*widget.FormItem{nameForm}, callback, m.window)
+}
+
+// Shows the sessions of the open campaign, allowing the user to open one or start a new one.
+func (m *MainInterface) showCampaignBrowser() {
+	browser := gui.NewCampaignBrowser(m.campaign)
+	browserDialog := dialog.NewCustom("Campaign", "Close", browser, m.window)
+
+	browser.OnSelected = func(i int) {
+		session, err := m.campaign.OpenSession(i)
+		if err != nil {
+			dialog.ShowError(err, m.window)
+		}
+		if err != nil && !backend.RecoveredFromBackup(err) {
+			return
+		}
+		m.OpenSession(session)
+		browserDialog.Hide()
+	}
+	browser.OnNewSession = func() {
+		session, err := m.campaign.NewSession("")
+		if err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		m.OpenSession(session)
+		browserDialog.Hide()
+	}
+
+	browserDialog.Show()
+}
+
+// Shows a dialog box listing search results. Tapping a result opens its session and selects the note.
+func (m *MainInterface) showSearchResults(query string, results []backend.SearchResult) {
+	if len(results) == 0 {
+		dialog.ShowInformation("Search sessions", "No notes match \""+query+"\"", m.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int {
+			return len(results)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(searchResultText(results[i]))
+		},
+	)
+	resultsDialog := dialog.NewCustom(fmt.Sprintf("%d matching notes", len(results)), "Close", list, m.window)
+	list.OnSelected = func(i widget.ListItemID) {
+		m.openResult(results[i])
+		resultsDialog.Hide()
+	}
+	resultsDialog.Resize(fyne.NewSize(m.window.Canvas().Size().Width*0.8, m.window.Canvas().Size().Height*0.8))
+	resultsDialog.Show()
+}
+
+// Opens the session of a search result and selects its note, clearing any filters that would hide it.
+// If the session is already open in a tab, the note is found again in that tab, as it may have changed since it was saved.
+func (m *MainInterface) openResult(result backend.SearchResult) {
+	if result.Session != m.session {
+		m.OpenSession(result.Session)
+	}
+	i := result.IndexIn(m.session)
+	if i == -1 {
+		dialog.ShowInformation("Note not found", "The note is no longer in the open session "+m.session.Heading()+".", m.window)
+		return
+	}
+	m.ShowNote(i)
+}
+
+// Shows a dialog box listing the entities of the open campaign. Tapping an entity shows its page.
+func (m *MainInterface) ShowEntities() {
+	if m.campaign == nil {
+		dialog.ShowInformation("Entities", "Open a campaign to keep track of the people, places, factions, and items of its sessions.", m.window)
+		return
+	}
+
+	list := widget.NewList(
+		func() int {
+			return len(m.campaign.Entities)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			e := m.campaign.Entities[i]
+			o.(*widget.Label).SetText(e.Name + " (" + string(e.Kind) + ")")
+		},
+	)
+	addButton := widget.NewButtonWithIcon("Add entity", theme.ContentAddIcon(), func() {
+		m.EditEntity("")
+	})
+	entitiesDialog := dialog.NewCustom("Entities of "+m.campaign.Name, "Close", container.NewBorder(nil, addButton, nil, nil, list), m.window)
+	list.OnSelected = func(i widget.ListItemID) {
+		entitiesDialog.Hide()
+		m.ShowEntity(m.campaign.Entities[i].Name)
+	}
+	entitiesDialog.Resize(fyne.NewSize(m.window.Canvas().Size().Width*0.8, m.window.Canvas().Size().Height*0.8))
+	entitiesDialog.Show()
+}
+
+// Shows the page of the entity with the passed name: its description, and every note of the campaign that mentions it.
+// Tapping a note opens its session and selects the note.
+func (m *MainInterface) ShowEntity(name string) {
+	if m.campaign == nil {
+		return
+	}
+	e, ok := m.campaign.Entity(name)
+	if !ok {
+		dialog.ShowInformation("Entities", "There is no entity named "+name+" in this campaign.", m.window)
+		return
+	}
+	results, err := m.entityMentions(e.Name)
+	if err != nil {
+		dialog.ShowError(err, m.window)
+	}
+
+	details := widget.NewLabel(entityDetailsText(e))
+	details.Wrapping = fyne.TextWrapWord
+	list := widget.NewList(
+		func() int {
+			return len(results)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(searchResultText(results[i]))
+		},
+	)
+	mentionsLabel := widget.NewLabel(fmt.Sprintf("Mentioned in %d notes", len(results)))
+	var entityDialog dialog.Dialog
+	editButton := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		entityDialog.Hide()
+		m.EditEntity(e.Name)
+	})
+	content := container.NewBorder(container.NewVBox(details, mentionsLabel), editButton, nil, nil, list)
+	entityDialog = dialog.NewCustom(e.Name, "Close", content, m.window)
+	list.OnSelected = func(i widget.ListItemID) {
+		m.openResult(results[i])
+		entityDialog.Hide()
+	}
+	entityDialog.Resize(fyne.NewSize(m.window.Canvas().Size().Width*0.8, m.window.Canvas().Size().Height*0.8))
+	entityDialog.Show()
+}
+
+// Shows a dialog box to edit the entity with the passed name, or to add a new entity if the name is empty.
+// The campaign is saved when the user confirms.
+func (m *MainInterface) EditEntity(name string) {
+	if m.campaign == nil {
+		return
+	}
+	e, editing := m.campaign.Entity(name)
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(e.Name)
+	kinds := make([]string, 0, len(backend.ENTITY_KINDS))
+	for _, kind := range backend.ENTITY_KINDS {
+		kinds = append(kinds, string(kind))
+	}
+	kindSelect := widget.NewSelect(kinds, nil)
+	kindSelect.SetSelected(string(backend.ENTITY_NPC))
+	if editing {
+		kindSelect.SetSelected(string(e.Kind))
+	}
+	aliasEntry := widget.NewEntry()
+	aliasEntry.SetPlaceHolder("Other names, separated by commas")
+	aliasEntry.SetText(strings.Join(e.Aliases, ", "))
+	descriptionEntry := widget.NewMultiLineEntry()
+	descriptionEntry.SetText(e.Description)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Kind", kindSelect),
+		widget.NewFormItem("Aliases", aliasEntry),
+		widget.NewFormItem("Description", descriptionEntry),
+	}
+	callback := func(confirm bool) {
+		if !confirm {
+			return
+		}
+		updated := backend.Entity{
+			Name:        strings.TrimSpace(nameEntry.Text),
+			Kind:        backend.EntityKind(kindSelect.Selected),
+			Aliases:     parseAliases(aliasEntry.Text),
+			Description: descriptionEntry.Text,
+		}
+		var err error
+		if editing {
+			err = m.campaign.UpdateEntity(e.Name, updated)
+		} else {
+			err = m.campaign.AddEntity(updated)
+		}
+		if err == nil {
+			err = m.campaign.Save()
+		}
+		if err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+		m.RefreshNotes()
+	}
+	title := "New entity"
+	if editing {
+		title = "Edit " + e.Name
+	}
+	formDialog := dialog.NewForm(title, "Save", "Cancel", items, callback, m.window)
+	formDialog.Resize(fyne.NewSize(m.window.Canvas().Size().Width*0.8, m.window.Canvas().Size().Height*0.6))
+	formDialog.Show()
+}
+
+// Returns every note of the open campaign that mentions the entity with the passed name.
+// Notes of the session being edited come first and reflect any unsaved changes.
+func (m *MainInterface) entityMentions(name string) ([]backend.SearchResult, error) {
+	results := m.session.Mentioning(m.campaign, name)
+	saved, err := backend.MentionsInCampaign(m.campaign, name)
+	for _, result := range saved {
+		if m.session.Path == "" || result.Session.Path != m.session.Path {
+			results = append(results, result)
+		}
+	}
+	return results, err
+}
+
+// Returns the mentions of entities of the open campaign within the passed content, or none if no campaign is open.
+func (m *MainInterface) findMentions(content string) []backend.Mention {
+	if m.campaign == nil {
+		return nil
+	}
+	return m.campaign.FindMentions(content)
+}
+
+// Builds the text describing the kind, aliases, and description of an entity.
+func entityDetailsText(e backend.Entity) string {
+	text := string(e.Kind)
+	if len(e.Aliases) > 0 {
+		text += ", also known as " + strings.Join(e.Aliases, ", ")
+	}
+	if e.Description != "" {
+		text += "\n\n" + e.Description
+	}
+	return text
+}
+
+// Splits a comma separated list of aliases, dropping any that are blank.
+func parseAliases(text string) []string {
+	aliases := make([]string, 0)
+	for _, alias := range strings.Split(text, ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// Builds the text describing a single search result.
+func searchResultText(result backend.SearchResult) string {
+	title := result.Session.SessionTitle
+	if title == "" && result.Session.SessionNumber > backend.NO_SESSION_NUMBER {
+		title = "Session " + strconv.Itoa(result.Session.SessionNumber)
+	}
+	if title == "" && result.Session.Path != "" {
+		title = filepath.Base(result.Session.Path)
+	}
+	return title + ": " + result.Highlighted("«", "»")
+}
+
+// Records the current title and number of the session in its campaign, if it belongs to the open campaign.
+// A session saved into the directory of the open campaign for the first time is added to it.
+func (m *MainInterface) updateCampaign() {
+	if m.campaign == nil {
+		return
+	}
+	if !m.campaign.UpdateSession(m.session) {
+		if m.session.Path == "" || !m.campaign.InDirectory(m.session.Path) {
+			return
+		}
+		if err := m.campaign.AddSession(m.session); err != nil {
+			dialog.ShowError(err, m.window)
+			return
+		}
+	}
+	if err := m.campaign.Save(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+}
+
+// Writes the current session to file, and displays a dialog box with any errors if they occur.
+func (m *MainInterface) save(uc fyne.URIWriteCloser, e error) {
+	// the user pressed 'cancel'
+	if uc == nil {
+		return
+	}
+
+	if e != nil {
+		dialog.ShowError(e, m.window)
+	}
+	// the dialog has created the file, but the session is written by Session.Save so that the write is atomic
+	if err := uc.Close(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+	if err := m.session.SaveAs(uc.URI().Path()); err != nil {
+		dialog.ShowError(err, m.window)
+	} else {
+		m.sessionSaved()
+		m.rememberSession(m.session.Path)
+	}
+	m.updateCampaign()
+	m.SetWindowTitle()
+}
+
+// Renders the current session in the passed export format.
+func (m *MainInterface) exportContent(format string, options []backend.ExportOption) (string, error) {
+	if format == EXPORT_HTML {
+		return m.session.ToHTML(options...)
+	}
+	return m.session.ToMarkdown(options...), nil
+}
+
+// Writes an exported session to file, and displays a dialog box with any errors if they occur.
+func (m *MainInterface) export(uc fyne.URIWriteCloser, e error, content string) {
+	if e != nil {
+		dialog.ShowError(e, m.window)
+		return
+	}
+	// the user pressed 'cancel'
+	if uc == nil {
+		return
+	}
+
+	reader := strings.NewReader(content)
+	if _, err := reader.WriteTo(uc); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+	if err := uc.Close(); err != nil {
+		dialog.ShowError(err, m.window)
+	}
+}
+
+// Builds the menu bar of the window.
+func (m *MainInterface) MainMenu() *fyne.MainMenu {
+	exportItem := fyne.NewMenuItem("Export", nil)
+	exportItem.ChildMenu = fyne.NewMenu("",
+		fyne.NewMenuItem("Markdown…", func() { m.ExportAs(EXPORT_MARKDOWN) }),
+		fyne.NewMenuItem("HTML…", func() { m.ExportAs(EXPORT_HTML) }),
+	)
+	recentItems := make([]*fyne.MenuItem, 0)
+	for _, path := range m.recent.Paths() {
+		path := path
+		recentItems = append(recentItems, fyne.NewMenuItem(path, func() { m.OpenRecent(path) }))
+	}
+	if len(recentItems) == 0 {
+		recentItems = append(recentItems, fyne.NewMenuItem(gui.NO_RECENT_SESSIONS, nil))
+	}
+	reopenLabel := "Reopen last session on startup"
+	if m.preferences.Bool(PREF_REOPEN_LAST) {
+		reopenLabel = "Don't reopen last session on startup"
+	}
+	recentItems = append(recentItems,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(reopenLabel, func() { m.SetReopenLast(!m.preferences.Bool(PREF_REOPEN_LAST)) }),
+	)
+	recentItem := fyne.NewMenuItem("Recent", nil)
+	recentItem.ChildMenu = fyne.NewMenu("", recentItems...)
+
+	file := fyne.NewMenu("File",
+		fyne.NewMenuItem("New tab", m.NewTab),
+		fyne.NewMenuItem("Open…", m.Load),
+		recentItem,
+		fyne.NewMenuItem("Save", m.Save),
+		fyne.NewMenuItem("Open campaign…", m.OpenCampaign),
+		fyne.NewMenuItem("Entities…", m.ShowEntities),
+		fyne.NewMenuItemSeparator(),
+		exportItem,
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Close tab", m.CloseTab),
+		fyne.NewMenuItemSeparator(),
+		// replaces the Quit item fyne adds, which would quit without asking about unsaved changes
+		fyne.NewMenuItem("Quit", m.Quit),
+	)
+	edit := fyne.NewMenu("Edit",
+		fyne.NewMenuItem("Undo", m.Undo),
+		fyne.NewMenuItem("Redo", m.Redo),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Search sessions…", m.SearchAll),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("New scene…", m.NewScene),
+		fyne.NewMenuItem("Break", m.Break),
+	)
+	gameTimeLabel := "Show in-game time"
+	if m.gameTime {
+		gameTimeLabel = "Show real time"
+	}
+	timelineLabel := "Timeline"
+	if m.timeline != nil && m.timeline.Visible() {
+		timelineLabel = "Notes"
+	}
+	view := fyne.NewMenu("View",
+		fyne.NewMenuItem(timelineLabel, m.ToggleTimeline),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem(gameTimeLabel, m.ToggleGameTime),
+		fyne.NewMenuItem("In-game time…", m.SetGameClock),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Combat tracker", m.ToggleCombatTracker),
+	)
+	return fyne.NewMainMenu(file, edit, view)
+}
+
+// Create an interface. This interface composes the entire window.
+func NewMainInterface(window fyne.Window) *MainInterface {
+	session := backend.NewSession(DEFAULT_SESSION_NAME, backend.NO_SESSION_NUMBER)
+	preferences := fyne.CurrentApp().Preferences()
+	mi := &MainInterface{
+		window:      window,
+		editing:     NOT_EDITING,
+		preferences: preferences,
+		recent:      backend.ParseRecentSessions(preferences.String(PREF_RECENT_SESSIONS)),
+	}
+	mi.activateTab(mi.newTab(session))
+	mi.ExtendBaseWidget(mi)
+	return mi
+}
+
+// Apply custom settings to the window. Returns the interface composing the window.
+func setUpWindow(window fyne.Window) *MainInterface {
+	main := NewMainInterface(window)
+	main.window.SetTitle(main.session.SessionTitle + " - " + APP_NAME)
+	main.window.SetContent(main)
+	main.window.Resize(fyne.NewSize(STARTING_WIDTH, STARTING_HEIGHT))
+	main.window.Canvas().Focus(main.entry)
+	main.window.SetMainMenu(main.MainMenu())
+	main.window.SetCloseIntercept(main.Quit)
+
+	main.shortcuts = map[fyne.Shortcut]func(){
+		&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: desktop.ControlModifier}:                         main.Undo,
+		&desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: desktop.ControlModifier | desktop.ShiftModifier}: main.Redo,
+		&desktop.CustomShortcut{KeyName: fyne.KeyT, Modifier: desktop.ControlModifier}:                         main.NewTab,
+		&desktop.CustomShortcut{KeyName: fyne.KeyW, Modifier: desktop.ControlModifier}:                         main.CloseTab,
+	}
+	for shortcut, handler := range main.shortcuts {
+		handler := handler
+		main.window.Canvas().AddShortcut(shortcut, func(fyne.Shortcut) { handler() })
+	}
+	for _, tab := range main.sessionTabs {
+		main.addShortcuts(tab.entry)
+	}
+	main.ReopenLastSession()
+	return main
+}
+
+func main() {
+	if isCLI(os.Args[1:]) {
+		os.Exit(runCLI(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+	}
+
+	a := app.NewWithID(APP_ID)
+	w := a.NewWindow(APP_NAME)
+	mi := setUpWindow(w)
+	if dir, err := backend.DefaultJournalDir(); err == nil {
+		mi.StartAutosave(dir)
+		mi.OfferRecovery(dir)
+	}
 	w.ShowAndRun()
 }
@@ -1,8 +1,19 @@
 package main
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
 	"github.com/archon/gui"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -33,4 +44,453 @@ var _ = Describe("App", func() {
 		setUpWindow(window)
 		Expect(window.Canvas().Size()).To(Equal(fyne.NewSize(STARTING_WIDTH, STARTING_HEIGHT)))
 	})
+
+	It("should title the window after a session that is opened", func() {
+		setUpWindow(window)
+		main := window.Content().(*MainInterface)
+		main.SetSession(backend.NewSession("The Conquest at Calimport", 3))
+		Expect(window.Title()).To(Equal("The Conquest at Calimport - " + APP_NAME))
+	})
+
+	It("should only list the notes matching the search bar", func() {
+		setUpWindow(window)
+		main := window.Content().(*MainInterface)
+		main.session.AddNote(backend.NewNote("Arrived in Calimport", time.Now()))
+		main.session.AddNote(backend.NewNote("Xenthe almost died", time.Now()))
+		main.searchEntry.SetText("xenthe")
+		Expect(main.listLength()).To(Equal(1))
+		Expect(main.noteIndex(0)).To(Equal(1))
+
+		main.searchEntry.SetText("")
+		Expect(main.listLength()).To(Equal(2))
+	})
+
+	It("should replace the content of a note edited inline", func() {
+		setUpWindow(window)
+		main := window.Content().(*MainInterface)
+		main.session.AddNote(backend.NewNote("Xenthe almsot died", time.Now()))
+		main.EditNote(0)
+		main.updateNote(0, "Xenthe almost died")
+		Expect(main.session.Notes[0].Content).To(Equal("Xenthe almost died"))
+		Expect(main.editing).To(Equal(NOT_EDITING))
+	})
+
+	It("should undo and redo changes to the session info", func() {
+		setUpWindow(window)
+		main := window.Content().(*MainInterface)
+		main.SetSessionInfo("The Conquest at Calimport", 3)
+		Expect(window.Title()).To(Equal(DIRTY_MARKER + "The Conquest at Calimport - " + APP_NAME))
+
+		main.Undo()
+		Expect(main.session.SessionTitle).To(Equal(DEFAULT_SESSION_NAME))
+		Expect(main.session.SessionNumber).To(Equal(backend.NO_SESSION_NUMBER))
+
+		main.Redo()
+		Expect(main.session.SessionTitle).To(Equal("The Conquest at Calimport"))
+	})
+
+	It("should autosave to the journal after enough notes are entered", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main := setUpWindow(window)
+		main.StartAutosave(dir)
+		defer main.active.autosaver.Stop()
+
+		for i := 0; i < backend.DEFAULT_AUTOSAVE_NOTE_COUNT; i++ {
+			test.Type(main.entry, "Xenthe almost died")
+			main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		}
+		Expect(main.active.autosaver.Journal().Exists()).To(BeTrue())
+	})
+
+	It("should autosave every tab to its own journal and clear only the journal of the tab discarded", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main := setUpWindow(window)
+		main.StartAutosave(dir)
+		first := main.active
+		main.NewTab()
+		second := main.active
+		defer first.autosaver.Stop()
+		defer second.autosaver.Stop()
+		Expect(second.autosaver.Journal().Path).ToNot(Equal(first.autosaver.Journal().Path))
+
+		for _, tab := range []*sessionTab{first, second} {
+			main.activateTab(tab)
+			for i := 0; i < backend.DEFAULT_AUTOSAVE_NOTE_COUNT; i++ {
+				test.Type(main.entry, "Xenthe almost died")
+				main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+			}
+		}
+		Expect(first.autosaver.Journal().Exists()).To(BeTrue())
+		Expect(second.autosaver.Journal().Exists()).To(BeTrue())
+
+		main.CloseTab()
+		tapButton(window, "Discard")
+		Expect(main.active).To(Equal(first))
+		Expect(first.autosaver.Journal().Exists()).To(BeTrue())
+		Expect(second.autosaver.Journal().Exists()).To(BeFalse())
+	})
+
+	It("should offer to recover every session left in a journal", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		left := []*backend.Journal{backend.NewJournal(backend.JournalPath(dir, 1)), backend.NewJournal(filepath.Join(dir, backend.JOURNAL_FILE_NAME))}
+		for i, journal := range left {
+			session := backend.NewSession("The Conquest at Calimport", i+1)
+			session.AddNote(backend.NewNote("Xenthe almost died", time.Now()))
+			journal.Append(session)
+		}
+
+		main := setUpWindow(window)
+		main.StartAutosave(dir)
+		main.OfferRecovery(dir)
+		tapButton(window, "Yes")
+		Expect(main.sessionTabs).To(HaveLen(2))
+		for _, tab := range main.sessionTabs {
+			defer tab.autosaver.Stop()
+			Expect(tab.session.IsDirty()).To(BeTrue())
+			Expect(tab.autosaver.Journal().Exists()).To(BeTrue())
+		}
+		journals, _ := backend.FindJournals(dir)
+		Expect(journals).To(HaveLen(2))
+		Expect(left[1].Exists()).To(BeFalse())
+	})
+
+	It("should export the session in the chosen format", func() {
+		main := setUpWindow(window)
+		markdown, _ := main.exportContent(EXPORT_MARKDOWN, nil)
+		Expect(markdown).To(HavePrefix("# " + DEFAULT_SESSION_NAME))
+		html, _ := main.exportContent(EXPORT_HTML, nil)
+		Expect(html).To(HavePrefix("<!DOCTYPE html>"))
+	})
+
+	It("should only list the notes with every tag filtered by", func() {
+		main := setUpWindow(window)
+		main.session.AddNote(backend.NewNote("Found a wand #loot", time.Now()))
+		main.session.AddNote(backend.NewNote("Goblins attacked #combat #loot", time.Now()))
+		main.ToggleTagFilter("combat")
+		Expect(main.listLength()).To(Equal(1))
+		Expect(main.noteIndex(0)).To(Equal(1))
+
+		main.ToggleTagFilter("combat")
+		Expect(main.listLength()).To(Equal(2))
+	})
+
+	It("should only link mentions of entities when a campaign is open", func() {
+		main := setUpWindow(window)
+		Expect(main.findMentions("Sailed to Calimport")).To(BeEmpty())
+
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main.campaign = backend.NewCampaign("Calimport", dir)
+		main.campaign.AddEntity(backend.Entity{Name: "Calimport", Kind: backend.ENTITY_LOCATION})
+		Expect(main.findMentions("Sailed to Calimport")).To(HaveLen(1))
+	})
+
+	It("should add sessions saved into the directory of the open campaign to it", func() {
+		main := setUpWindow(window)
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main.campaign = backend.NewCampaign("Calimport", dir)
+		main.session.Path = filepath.Join(dir, "session.json")
+		main.Save()
+		Expect(main.campaign.Sessions).To(HaveLen(1))
+
+		loaded, err := backend.LoadCampaign(dir)
+		Expect(err).To(BeNil())
+		Expect(loaded.Sessions).To(HaveLen(1))
+	})
+
+	It("should show the combat tracker when combat starts", func() {
+		main := setUpWindow(window)
+		Expect(main.combatPanel.Visible()).To(BeFalse())
+		test.Type(main.entry, "/combat start")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(main.combatPanel.Visible()).To(BeTrue())
+		Expect(main.listLength()).To(Equal(1))
+
+		main.Undo()
+		Expect(main.session.Combat).To(BeNil())
+		Expect(main.listLength()).To(Equal(0))
+	})
+
+	It("should open sessions longer than a single read", func() {
+		main := setUpWindow(window)
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		s := backend.NewSession("The Long Road", 4)
+		for i := 0; i < 100; i++ {
+			s.AddNote(backend.NewNote("The party walks for another mile", time.Now()))
+		}
+		s.Path = filepath.Join(dir, "session.json")
+		Expect(s.Save()).To(Succeed())
+
+		uc, err := storage.Reader(storage.NewFileURI(s.Path))
+		Expect(err).ToNot(HaveOccurred())
+		main.load(uc, nil)
+		Expect(main.session.SessionTitle).To(Equal("The Long Road"))
+		Expect(main.session.Notes).To(HaveLen(100))
+		Expect(main.session.Path).To(Equal(s.Path))
+	})
+
+	It("should read large sessions in the background and report when they are read", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		s := backend.NewSession("The Long Road", 4)
+		for i := 0; i < 20000; i++ {
+			s.AddNote(backend.NewNote("The party walks for another mile", time.Now()))
+		}
+		s.Path = filepath.Join(dir, "session.json")
+		Expect(s.Save()).To(Succeed())
+		info, _ := os.Stat(s.Path)
+		Expect(info.Size()).To(BeNumerically(">=", backend.LARGE_SESSION_SIZE))
+
+		uc, err := storage.Reader(storage.NewFileURI(s.Path))
+		Expect(err).ToNot(HaveOccurred())
+		var progress atomic.Value
+		read := make(chan *backend.Session, 1)
+		failed := make(chan error, 1)
+		readInBackground(uc, s.Path, info.Size(), func(fraction float64) {
+			progress.Store(fraction)
+		}, func(session *backend.Session, err error) {
+			failed <- err
+			read <- session
+		})
+		var session *backend.Session
+		Eventually(read).Should(Receive(&session))
+		Expect(failed).To(Receive(BeNil()))
+		Expect(session.Notes).To(HaveLen(20000))
+		Expect(progress.Load()).To(Equal(1.0))
+	})
+
+	It("should keep the open session when a file cannot be opened", func() {
+		main := setUpWindow(window)
+		main.session.Path = "/notes/current.json"
+		test.Type(main.entry, "Xenthe almost died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "broken.json")
+		Expect(os.WriteFile(path, []byte("{\n\"Notes\": [}"), 0600)).To(Succeed())
+
+		uc, err := storage.Reader(storage.NewFileURI(path))
+		Expect(err).ToNot(HaveOccurred())
+		main.load(uc, nil)
+		Expect(main.session.Path).To(Equal("/notes/current.json"))
+		Expect(main.session.Notes).To(HaveLen(1))
+
+		test.Type(main.entry, "Xenthe died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(main.session.Notes).To(HaveLen(2))
+	})
+
+	It("should describe where a file could not be read", func() {
+		loadErr := &backend.LoadError{Path: "/notes/broken.json", Offset: 12, Line: 2, Column: 11, Field: "", Err: errors.New("invalid character '}'")}
+		items := loadErrorItems(loadErr)
+		Expect(items).To(HaveLen(3))
+		Expect(items[1].Widget.(*widget.Label).Text).To(Equal("Line 2, column 11 (byte 12)"))
+		loadErr.Field = "Notes.1.Content"
+		Expect(loadErrorItems(loadErr)).To(HaveLen(4))
+	})
+
+	It("should start on the start screen until a note is added", func() {
+		main := setUpWindow(window)
+		Expect(main.startScreen.Visible()).To(BeTrue())
+		Expect(main.list.Visible()).To(BeFalse())
+		test.Type(main.entry, "Xenthe almost died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(main.startScreen.Visible()).To(BeFalse())
+		Expect(main.list.Visible()).To(BeTrue())
+	})
+
+	It("should remember opened sessions and reopen the last one on startup", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		s := backend.NewSession("The Long Road", 4)
+		s.Path = filepath.Join(dir, "session.json")
+		Expect(s.Save()).To(Succeed())
+
+		main := setUpWindow(window)
+		main.OpenRecent(s.Path)
+		Expect(main.recent.Paths()).To(Equal([]string{s.Path}))
+		main.SetReopenLast(true)
+
+		reopened := setUpWindow(app.NewWindow(APP_NAME))
+		Expect(reopened.session.SessionTitle).To(Equal("The Long Road"))
+		Expect(reopened.startScreen.Visible()).To(BeFalse())
+	})
+
+	It("should forget recent sessions whose files are gone", func() {
+		main := setUpWindow(window)
+		main.rememberSession("/notes/missing.json")
+		main.OpenRecent("/notes/missing.json")
+		Expect(main.recent.Paths()).To(BeEmpty())
+		Expect(app.Preferences().String(PREF_RECENT_SESSIONS)).To(BeEmpty())
+	})
+
+	It("should keep a separate session, entry field, and dirty flag in every tab", func() {
+		main := setUpWindow(window)
+		first := main.active
+		test.Type(main.entry, "Xenthe almost died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(first.session.IsDirty()).To(BeTrue())
+
+		main.NewTab()
+		Expect(main.sessionTabs).To(HaveLen(2))
+		Expect(main.tabs.Items).To(HaveLen(2))
+		Expect(main.entry).ToNot(Equal(first.entry))
+		Expect(main.session.IsDirty()).To(BeFalse())
+		Expect(main.listLength()).To(Equal(0))
+		main.SetSessionInfo("Last week", 3)
+		Expect(window.Title()).To(HavePrefix(DIRTY_MARKER + "Last week"))
+		Expect(main.active.item.Text).To(Equal(DIRTY_MARKER + "Session 3: Last week"))
+
+		main.tabs.SelectTab(first.item)
+		Expect(main.active).To(Equal(first))
+		Expect(main.listLength()).To(Equal(1))
+		Expect(window.Title()).To(HavePrefix(DIRTY_MARKER + DEFAULT_SESSION_NAME))
+	})
+
+	It("should shorten long tab titles without cutting characters in half", func() {
+		title := strings.Repeat("é", MAX_TAB_TITLE_LENGTH+5)
+		shortened := tabTitle(backend.NewSession(title, backend.NO_SESSION_NUMBER))
+		Expect(utf8.ValidString(shortened)).To(BeTrue())
+		Expect([]rune(shortened)).To(HaveLen(MAX_TAB_TITLE_LENGTH))
+	})
+
+	It("should shorten long window titles without cutting characters in half", func() {
+		main := setUpWindow(window)
+		main.session.SessionTitle = strings.Repeat("é", MAX_WIN_TITLE_LENGTH+5)
+		main.SetWindowTitle()
+		shortened := strings.TrimSuffix(window.Title(), " - "+APP_NAME)
+		Expect(utf8.ValidString(shortened)).To(BeTrue())
+		Expect([]rune(shortened)).To(HaveLen(MAX_WIN_TITLE_LENGTH))
+		Expect(shortened).To(HaveSuffix("..."))
+	})
+
+	It("should open sessions in new tabs unless the active tab is empty", func() {
+		main := setUpWindow(window)
+		opened := backend.NewSession("Opened", 1)
+		opened.Path = "/notes/opened.json"
+		main.OpenSession(opened)
+		Expect(main.sessionTabs).To(HaveLen(1))
+		Expect(main.session).To(Equal(opened))
+
+		other := backend.NewSession("Other", 2)
+		main.OpenSession(other)
+		Expect(main.sessionTabs).To(HaveLen(2))
+		reopened := backend.NewSession("Opened", 1)
+		reopened.Path = "/notes/opened.json"
+		main.OpenSession(reopened)
+		Expect(main.sessionTabs).To(HaveLen(2))
+		Expect(main.session).To(Equal(opened))
+	})
+
+	It("should leave an untitled session when the last tab is closed", func() {
+		main := setUpWindow(window)
+		opened := backend.NewSession("Opened", 1)
+		opened.Path = "/notes/opened.json"
+		main.OpenSession(opened)
+		main.NewTab()
+		main.CloseTab()
+		Expect(main.session.SessionTitle).To(Equal("Opened"))
+		main.CloseTab()
+		Expect(main.sessionTabs).To(HaveLen(1))
+		Expect(main.tabs.Items).To(HaveLen(1))
+		Expect(main.session.SessionTitle).To(Equal(DEFAULT_SESSION_NAME))
+	})
+
+	It("should offer to jump to every scene", func() {
+		main := setUpWindow(window)
+		main.window.Resize(fyne.NewSize(STARTING_WIDTH, STARTING_HEIGHT))
+		test.Type(main.entry, "/scene Ambush")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(main.sceneSelect.Options).To(Equal([]string{"Ambush"}))
+
+		main.ToggleTimeline()
+		Expect(main.timeline.Visible()).To(BeTrue())
+		Expect(main.list.Visible()).To(BeFalse())
+		main.ShowNote(0)
+		Expect(main.list.Visible()).To(BeTrue())
+	})
+
+	It("should mark the window title until unsaved changes are saved", func() {
+		main := setUpWindow(window)
+		test.Type(main.entry, "Xenthe almost died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(window.Title()).To(HavePrefix(DIRTY_MARKER))
+		Expect(main.active.item.Text).To(HavePrefix(DIRTY_MARKER))
+
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main.session.Path = filepath.Join(dir, "session.json")
+		main.Save()
+		Expect(window.Title()).To(Equal(DEFAULT_SESSION_NAME + " - " + APP_NAME))
+		Expect(main.active.item.Text).To(Equal(DEFAULT_SESSION_NAME))
+	})
+
+	It("should quit at once when there are no unsaved changes", func() {
+		main := setUpWindow(window)
+		closed := false
+		window.SetOnClosed(func() {
+			closed = true
+		})
+		main.Quit()
+		Expect(closed).To(BeTrue())
+	})
+
+	It("should stop autosaving every tab when quitting", func() {
+		dir, _ := os.MkdirTemp("", "archon-app")
+		defer os.RemoveAll(dir)
+		main := setUpWindow(window)
+		main.StartAutosave(dir)
+		main.NewTab()
+		for _, tab := range main.sessionTabs {
+			defer tab.autosaver.Stop()
+			Expect(tab.autosaver.Running()).To(BeTrue())
+		}
+		main.Quit()
+		for _, tab := range main.sessionTabs {
+			Expect(tab.autosaver.Running()).To(BeFalse())
+		}
+	})
+
+	It("should ask before discarding unsaved changes", func() {
+		main := setUpWindow(window)
+		closed := false
+		window.SetOnClosed(func() {
+			closed = true
+		})
+		test.Type(main.entry, "Xenthe almost died")
+		main.entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		main.NewTab()
+
+		// the tab with unsaved changes is shown while asking about it
+		main.Quit()
+		Expect(closed).To(BeFalse())
+		Expect(main.listLength()).To(Equal(1))
+		tapButton(window, "Cancel")
+		Expect(closed).To(BeFalse())
+
+		main.CloseTab()
+		Expect(main.sessionTabs).To(HaveLen(2))
+		tapButton(window, "Discard")
+		Expect(main.sessionTabs).To(HaveLen(1))
+		main.Quit()
+		Expect(closed).To(BeTrue())
+	})
 })
+
+// Taps the button with the passed text in the dialog on top of the window.
+func tapButton(window fyne.Window, text string) {
+	top := window.Canvas().Overlays().Top()
+	Expect(top).ToNot(BeNil())
+	for _, o := range test.LaidOutObjects(top) {
+		if button, ok := o.(*widget.Button); ok && button.Text == text {
+			test.Tap(button)
+			return
+		}
+	}
+	Fail("No button labelled " + text)
+}
@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+const DEFAULT_AUTOSAVE_INTERVAL = 2 * time.Minute
+const DEFAULT_AUTOSAVE_NOTE_COUNT = 5
+
+// Saves a session automatically after a number of notes have been added or after an interval has passed with unsaved changes.
+// Sessions with a path are saved to that path, sessions without one are appended to a recovery journal.
+// Saving on an interval happens on another goroutine, so the session must be changed through its history while the autosaver runs.
+type Autosaver struct {
+	session    *Session      // the session being saved
+	journal    *Journal      // the journal sessions without a path are saved to
+	interval   time.Duration // how long unsaved changes may wait before being saved, or 0 to never save on an interval
+	noteCount  int           // how many notes may be added before being saved, or 0 to never save on note count
+	changes    int           // the number of changes since the last save
+	notesAdded int           // the number of notes added since the last save
+	stop       chan struct{} // closed to stop saving on an interval
+	lock       sync.Mutex    // guards the fields above, as saving on an interval happens on another goroutine
+	saving     sync.Mutex    // held while saving, so that the journal is not cleared while a snapshot is appended to it
+	// Called with the result of every automatic save, if set. The result of a save made on an interval is reported
+	// as soon as it is made, on the goroutine saving on an interval.
+	OnSave func(error)
+}
+
+// An option to customize the constructor for creating a new autosaver.
+type AutosaverOption func(a *Autosaver)
+
+// Create a new Autosaver for the passed session, journaling sessions without a path to the passed journal.
+// The autosaver does nothing on an interval until it is started.
+func NewAutosaver(s *Session, journal *Journal, options ...AutosaverOption) *Autosaver {
+	autosaver := Autosaver{
+		session:   s,
+		journal:   journal,
+		interval:  DEFAULT_AUTOSAVE_INTERVAL,
+		noteCount: DEFAULT_AUTOSAVE_NOTE_COUNT,
+	}
+	for _, option := range options {
+		option(&autosaver)
+	}
+	return &autosaver
+}
+
+// Option to save unsaved changes after the passed interval. An interval of 0 disables saving on an interval.
+func WithAutosaveInterval(interval time.Duration) AutosaverOption {
+	return func(a *Autosaver) {
+		a.interval = interval
+	}
+}
+
+// Option to save after the passed number of notes have been added. A count of 0 disables saving on note count.
+func WithAutosaveNoteCount(count int) AutosaverOption {
+	return func(a *Autosaver) {
+		a.noteCount = count
+	}
+}
+
+// Starts saving unsaved changes on an interval, until Stop is called.
+func (a *Autosaver) Start() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.stop != nil || a.interval <= 0 {
+		return
+	}
+	a.stop = make(chan struct{})
+	go a.run(a.stop, a.interval)
+}
+
+// Stops saving on an interval.
+func (a *Autosaver) Stop() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.stop != nil {
+		close(a.stop)
+		a.stop = nil
+	}
+}
+
+// Reports whether the autosaver is saving on an interval, from when it is started until it is stopped.
+func (a *Autosaver) Running() bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.stop != nil
+}
+
+// Returns the journal sessions without a path are saved to.
+func (a *Autosaver) Journal() *Journal {
+	return a.journal
+}
+
+// Sets the session being saved. Changes to the previous session are forgotten.
+func (a *Autosaver) SetSession(s *Session) {
+	a.saving.Lock()
+	defer a.saving.Unlock()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.session = s
+	a.changes = 0
+	a.notesAdded = 0
+}
+
+// Records that the session has changed.
+func (a *Autosaver) Changed() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.changes++
+}
+
+// Records that a note was added to the session, saving it if enough notes have been added since the last save.
+func (a *Autosaver) NoteAdded() {
+	a.lock.Lock()
+	a.changes++
+	a.notesAdded++
+	due := a.noteCount > 0 && a.notesAdded >= a.noteCount
+	a.lock.Unlock()
+
+	if due {
+		err := a.Save()
+		if a.OnSave != nil {
+			a.OnSave(err)
+		}
+	}
+}
+
+// Records that the session was saved by some other means, so there are no unsaved changes left to journal.
+func (a *Autosaver) Saved() error {
+	a.saving.Lock()
+	defer a.saving.Unlock()
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.changes = 0
+	a.notesAdded = 0
+	return a.journal.Clear()
+}
+
+// Saves the session to its path, or to the journal if it does not have one.
+// Changes recorded while the session is being saved are left to be saved next time.
+func (a *Autosaver) Save() error {
+	a.saving.Lock()
+	defer a.saving.Unlock()
+	a.lock.Lock()
+	session, changes, notesAdded := a.session, a.changes, a.notesAdded
+	a.lock.Unlock()
+
+	var err error
+	if session.savedPath() != "" {
+		err = session.write()
+		if err == nil {
+			err = a.journal.Clear()
+		}
+	} else {
+		err = a.journal.Append(session)
+	}
+	if err == nil {
+		a.lock.Lock()
+		a.changes -= changes
+		a.notesAdded -= notesAdded
+		a.lock.Unlock()
+	}
+	return err
+}
+
+// Saves unsaved changes every interval until the stop channel is closed, reporting the result of each save.
+func (a *Autosaver) run(stop chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			a.lock.Lock()
+			due := a.changes > 0
+			a.lock.Unlock()
+			if due {
+				err := a.Save()
+				if a.OnSave != nil {
+					a.OnSave(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Autosaver", func() {
+	var dir string
+	var journal *Journal
+	var session *Session
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-autosave")
+		Expect(err).To(BeNil())
+		journal = NewJournal(filepath.Join(dir, JOURNAL_FILE_NAME))
+		session = NewSession("The Conquest at Calimport", 3)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should journal sessions without a path once enough notes are added", func() {
+		autosaver := NewAutosaver(session, journal, WithAutosaveNoteCount(2))
+		session.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		autosaver.NoteAdded()
+		Expect(journal.Exists()).To(BeFalse())
+
+		session.AddNote(NewNote("Xenthe almost died", time.Now()))
+		autosaver.NoteAdded()
+		Expect(journal.Exists()).To(BeTrue())
+		recovered, _ := journal.Recover()
+		Expect(recovered.Notes).To(HaveLen(2))
+	})
+
+	It("should save sessions with a path to that path and clear the journal", func() {
+		journal.Append(session)
+		session.Path = filepath.Join(dir, "session.json")
+		autosaver := NewAutosaver(session, journal, WithAutosaveNoteCount(1))
+		session.AddNote(NewNote("Xenthe almost died", time.Now()))
+		autosaver.NoteAdded()
+
+		Expect(journal.Exists()).To(BeFalse())
+		saved, err := Load(session.Path)
+		Expect(err).To(BeNil())
+		Expect(saved.Notes).To(HaveLen(1))
+	})
+
+	It("should keep the backups of the session file from explicit saves only", func() {
+		session.Path = filepath.Join(dir, "session.json")
+		Expect(session.Save()).To(BeNil())
+		session.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		Expect(session.Save()).To(BeNil())
+
+		autosaver := NewAutosaver(session, journal, WithAutosaveNoteCount(1))
+		for i := 0; i < MAX_SESSION_BACKUPS+1; i++ {
+			session.AddNote(NewNote("Xenthe almost died", time.Now()))
+			autosaver.NoteAdded()
+		}
+		backup, err := Load(BackupPath(session.Path, 1))
+		Expect(err).To(BeNil())
+		Expect(backup.Notes).To(BeEmpty())
+		_, err = os.Stat(BackupPath(session.Path, 2))
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should report every automatic save", func() {
+		autosaver := NewAutosaver(session, journal, WithAutosaveNoteCount(1))
+		saves := 0
+		autosaver.OnSave = func(err error) {
+			Expect(err).To(BeNil())
+			saves++
+		}
+		autosaver.NoteAdded()
+		autosaver.NoteAdded()
+		Expect(saves).To(Equal(2))
+	})
+
+	It("should save unsaved changes on an interval once started", func() {
+		autosaver := NewAutosaver(session, journal, WithAutosaveInterval(10*time.Millisecond), WithAutosaveNoteCount(0))
+		saved := make(chan error, 1)
+		autosaver.OnSave = func(err error) {
+			saved <- err
+		}
+		autosaver.Changed()
+		autosaver.Start()
+		defer autosaver.Stop()
+		Expect(autosaver.Running()).To(BeTrue())
+
+		// the save is reported without waiting for another change
+		Eventually(saved).Should(Receive(BeNil()))
+		Expect(journal.Exists()).To(BeTrue())
+	})
+
+	It("should save every change made through the history while saving on an interval", func() {
+		session.Path = filepath.Join(dir, "session.json")
+		autosaver := NewAutosaver(session, journal, WithAutosaveInterval(time.Millisecond), WithAutosaveNoteCount(0))
+		autosaver.Start()
+		for i := 0; i < 100; i++ {
+			session.History().Execute(&AddNoteCommand{Note: NewNote("Xenthe almost died", time.Now())})
+			autosaver.Changed()
+		}
+		autosaver.Stop()
+		Expect(autosaver.Running()).To(BeFalse())
+
+		Expect(autosaver.Save()).To(BeNil())
+		Expect(session.IsDirty()).To(BeFalse())
+		saved, err := Load(session.Path)
+		Expect(err).To(BeNil())
+		Expect(saved.Notes).To(HaveLen(100))
+	})
+
+	It("should not save on an interval when nothing changed", func() {
+		autosaver := NewAutosaver(session, journal, WithAutosaveInterval(10*time.Millisecond))
+		autosaver.Start()
+		defer autosaver.Stop()
+
+		Consistently(journal.Exists, 50*time.Millisecond).Should(BeFalse())
+	})
+
+	It("should clear the journal when the session is saved by other means", func() {
+		autosaver := NewAutosaver(session, journal)
+		journal.Append(session)
+		Expect(autosaver.Saved()).To(BeNil())
+		Expect(journal.Exists()).To(BeFalse())
+	})
+})
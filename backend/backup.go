@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const MAX_SESSION_BACKUPS = 3
+const BACKUP_FORMAT = "%s.%d.bak"
+
+// Returned by Load when a session file is corrupt but one of its backups could be loaded instead.
+type CorruptSessionError struct {
+	Path   string // the path of the corrupt session file
+	Backup string // the path of the backup that was loaded instead, if any
+	Err    error  // the error encountered reading the corrupt session file
+}
+
+// Describes the corrupt file and the backup used in its place. Necessary to implement the error interface.
+func (e *CorruptSessionError) Error() string {
+	if e.Backup == "" {
+		return fmt.Sprintf("Session file %s is corrupt and has no valid backup: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("Session file %s is corrupt (%v). Loaded the most recent backup, %s, instead", e.Path, e.Err, e.Backup)
+}
+
+// Returns the error that made the session file unreadable.
+func (e *CorruptSessionError) Unwrap() error {
+	return e.Err
+}
+
+// Reports whether the error came from loading a corrupt session file that was replaced by one of its backups.
+// When it did, the session returned alongside the error is the backup and can be used.
+func RecoveredFromBackup(err error) bool {
+	var corrupt *CorruptSessionError
+	return errors.As(err, &corrupt) && corrupt.Backup != ""
+}
+
+// Returns the path of the nth most recent backup of a session file, starting from 1.
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf(BACKUP_FORMAT, path, n)
+}
+
+// Writes data to a file so that the file holds either its old contents or the new data, never a partial write.
+// The data is written to a temporary file in the same directory, flushed to disk, then renamed over the target.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	// removing the temporary file fails harmlessly once it has been renamed
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// Flushes a directory to disk so that a rename within it survives a power loss.
+// Not every platform supports this, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
+
+// Copies the session file at path to its most recent backup, shifting older backups back and dropping the oldest.
+// Nothing happens if the file does not exist or does not hold a valid session, so that valid backups are never
+// pushed out by a corrupt file.
+func rotateBackups(path string, perm fs.FileMode) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := FromJSON(string(data)); err != nil {
+		return nil
+	}
+
+	for n := MAX_SESSION_BACKUPS - 1; n >= 1; n-- {
+		err := os.Rename(BackupPath(path, n), BackupPath(path, n+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return writeFileAtomic(BackupPath(path, 1), data, perm)
+}
+
+// Loads the most recent backup of a session file that holds a valid session.
+// Returns the session and the path of the backup, or an empty session and path if no backup is valid.
+func loadNewestBackup(path string) (*Session, string) {
+	for n := 1; n <= MAX_SESSION_BACKUPS; n++ {
+		data, err := os.ReadFile(BackupPath(path, n))
+		if err != nil {
+			continue
+		}
+		s, err := FromJSON(string(data))
+		if err != nil {
+			continue
+		}
+		return s, BackupPath(path, n)
+	}
+	return &Session{}, ""
+}
@@ -0,0 +1,339 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const MINUTES_PER_HOUR = 60
+const HOURS_PER_DAY = 24
+const MINUTES_PER_DAY = MINUTES_PER_HOUR * HOURS_PER_DAY
+
+// The name of the calendar used by sessions that have not chosen one.
+const DEFAULT_CALENDAR = "harptos"
+
+// Matches a single part of an in-game duration, like "3d" or "90m", capturing the amount and the unit.
+var gameDurationPattern = regexp.MustCompile(`(\d+)\s*([dhm])`)
+
+// Matches a time of day like "14:30", capturing the hour and the minute.
+var timeOfDayPattern = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+
+// A point in time within the game world, counted in minutes from the first day of year 0 of its calendar.
+type GameTime int64
+
+// A month of a calendar. Festivals are single days that fall between months and have no day number.
+type Month struct {
+	Name     string // the name of the month, like "Flamerule"
+	Days     int    // the number of days in the month
+	Festival bool   `json:",omitempty"` // whether the month is a festival day outside of the regular months
+}
+
+// A fantasy calendar that in-game dates are written in. Every year has the same months.
+type Calendar struct {
+	Name     string   // the name the calendar is chosen by, like "harptos"
+	Months   []Month  // the months of a year, in order
+	Weekdays []string // the names of the days of the week, in order, or empty if days are not named
+	Era      string   // written after the year, like "DR", or empty
+}
+
+// The days in a year of the calendar.
+func (c Calendar) DaysPerYear() int {
+	days := 0
+	for _, month := range c.Months {
+		days += month.Days
+	}
+	return days
+}
+
+// An in-game date and time of day within a calendar.
+type GameDate struct {
+	Year   int // the year
+	Month  int // the index of the month within the months of the calendar
+	Day    int // the day of the month, starting at 1
+	Hour   int // the hour of the day, from 0 to 23
+	Minute int // the minute of the hour, from 0 to 59
+}
+
+// Returns the date and time of day that the time falls on.
+func (c Calendar) Date(t GameTime) GameDate {
+	minutes := int64(t)
+	days := floorDiv(minutes, MINUTES_PER_DAY)
+	minutes -= days * MINUTES_PER_DAY
+	daysPerYear := int64(c.DaysPerYear())
+	year := floorDiv(days, daysPerYear)
+	dayOfYear := int(days - year*daysPerYear)
+
+	date := GameDate{Year: int(year), Hour: int(minutes) / MINUTES_PER_HOUR, Minute: int(minutes) % MINUTES_PER_HOUR}
+	for i, month := range c.Months {
+		if dayOfYear < month.Days {
+			date.Month = i
+			date.Day = dayOfYear + 1
+			break
+		}
+		dayOfYear -= month.Days
+	}
+	return date
+}
+
+// Returns the time at the passed date and time of day. Returns an error if the date does not exist in the calendar.
+func (c Calendar) Time(date GameDate) (GameTime, error) {
+	if date.Month < 0 || date.Month >= len(c.Months) {
+		return 0, errors.New("There is no such month in the " + c.Name + " calendar")
+	}
+	month := c.Months[date.Month]
+	if date.Day < 1 || date.Day > month.Days {
+		return 0, fmt.Errorf("%s has %d days", month.Name, month.Days)
+	}
+	if date.Hour < 0 || date.Hour >= HOURS_PER_DAY || date.Minute < 0 || date.Minute >= MINUTES_PER_HOUR {
+		return 0, fmt.Errorf("%d:%02d is not a time of day", date.Hour, date.Minute)
+	}
+	days := int64(date.Year) * int64(c.DaysPerYear())
+	for _, m := range c.Months[:date.Month] {
+		days += int64(m.Days)
+	}
+	days += int64(date.Day - 1)
+	return GameTime(days*MINUTES_PER_DAY + int64(date.Hour*MINUTES_PER_HOUR+date.Minute)), nil
+}
+
+// Returns the name of the day of the week that the time falls on, or an empty string if the calendar does not name its days.
+func (c Calendar) Weekday(t GameTime) string {
+	if len(c.Weekdays) == 0 {
+		return ""
+	}
+	days := floorDiv(int64(t), MINUTES_PER_DAY)
+	weekday := days % int64(len(c.Weekdays))
+	if weekday < 0 {
+		weekday += int64(len(c.Weekdays))
+	}
+	return c.Weekdays[weekday]
+}
+
+// Writes out the time in full, like "Day 3 of Flamerule, 1491 DR, 14:30". Festivals are written by name, like "Midsummer, 1491 DR, 09:00".
+func (c Calendar) Format(t GameTime) string {
+	date := c.Date(t)
+	text := c.dayText(date) + ", " + c.yearText(date.Year) + ", " + timeOfDayText(date)
+	if weekday := c.Weekday(t); weekday != "" {
+		text = weekday + ", " + text
+	}
+	return text
+}
+
+// Writes out the time briefly, without the year, like "3 Flamerule 14:30".
+func (c Calendar) FormatShort(t GameTime) string {
+	date := c.Date(t)
+	month := c.Months[date.Month]
+	if month.Festival {
+		return month.Name + " " + timeOfDayText(date)
+	}
+	return fmt.Sprintf("%d %s %s", date.Day, month.Name, timeOfDayText(date))
+}
+
+// Writes out the day of the date, like "Day 3 of Flamerule".
+func (c Calendar) dayText(date GameDate) string {
+	month := c.Months[date.Month]
+	if month.Festival {
+		return month.Name
+	}
+	return fmt.Sprintf("Day %d of %s", date.Day, month.Name)
+}
+
+// Writes out the year, followed by the era of the calendar if it has one.
+func (c Calendar) yearText(year int) string {
+	if c.Era == "" {
+		return strconv.Itoa(year)
+	}
+	return strconv.Itoa(year) + " " + c.Era
+}
+
+// Writes out the time of day of the date, like "14:30".
+func timeOfDayText(date GameDate) string {
+	return fmt.Sprintf("%02d:%02d", date.Hour, date.Minute)
+}
+
+// Parses a date written with the name of its month, like "3 Flamerule 1491", "Flamerule 3, 1491 DR 14:30", or "Midsummer 1491".
+// The day comes before the year, and the time of day is midnight unless one is written. Dates written by Format can be parsed.
+func (c Calendar) Parse(text string) (GameTime, error) {
+	rest := strings.ToLower(text)
+	month := -1
+	// longer names first, so that a month whose name contains another is not mistaken for it
+	order := make([]int, len(c.Months))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(c.Months[order[i]].Name) > len(c.Months[order[j]].Name)
+	})
+	for _, i := range order {
+		name := strings.ToLower(c.Months[i].Name)
+		if index := strings.Index(rest, name); index != -1 {
+			month = i
+			rest = rest[:index] + " " + rest[index+len(name):]
+			break
+		}
+	}
+	if month == -1 {
+		return 0, errors.New("Write the date with the name of its month, like 1 " + c.Months[0].Name + " 1491")
+	}
+	if c.Era != "" {
+		rest = strings.ReplaceAll(rest, strings.ToLower(c.Era), " ")
+	}
+
+	date := GameDate{Month: month, Day: 1}
+	numbers := make([]int, 0)
+	for _, field := range strings.FieldsFunc(rest, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' }) {
+		if match := timeOfDayPattern.FindStringSubmatch(field); match != nil {
+			date.Hour, _ = strconv.Atoi(match[1])
+			date.Minute, _ = strconv.Atoi(match[2])
+			continue
+		}
+		if field == "day" || field == "of" || c.isWeekday(field) {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimRight(field, "stndrh"))
+		if err != nil {
+			return 0, errors.New(field + " is not part of a date")
+		}
+		numbers = append(numbers, number)
+	}
+
+	wanted := 2
+	if c.Months[month].Festival {
+		wanted = 1
+	}
+	if len(numbers) != wanted {
+		if wanted == 1 {
+			return 0, errors.New("Write the year after " + c.Months[month].Name)
+		}
+		return 0, errors.New("Write both the day and the year of the date, like 1 " + c.Months[month].Name + " 1491")
+	}
+	date.Year = numbers[len(numbers)-1]
+	if wanted == 2 {
+		date.Day = numbers[0]
+	}
+	return c.Time(date)
+}
+
+// Reports whether the word is the name of a day of the week, ignoring case.
+func (c Calendar) isWeekday(word string) bool {
+	for _, weekday := range c.Weekdays {
+		if strings.EqualFold(weekday, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses an amount of in-game time like "3d", "2h30m", or "90m".
+// Returns an error if the text is not an amount of time or if the amount is too large to keep track of.
+func ParseGameDuration(text string) (GameTime, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	matches := gameDurationPattern.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 || strings.TrimSpace(gameDurationPattern.ReplaceAllString(text, "")) != "" {
+		return 0, errors.New(text + " is not an amount of time, write it like 3d, 2h30m, or 90m")
+	}
+	tooLarge := errors.New(text + " is too long an amount of time")
+	var duration GameTime
+	for _, match := range matches {
+		amount, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, tooLarge
+		}
+		var unit int64 = 1
+		switch match[2] {
+		case "d":
+			unit = MINUTES_PER_DAY
+		case "h":
+			unit = MINUTES_PER_HOUR
+		}
+		// amounts are never negative, so only adding too much can overflow
+		if amount > (math.MaxInt64-int64(duration))/unit {
+			return 0, tooLarge
+		}
+		duration += GameTime(amount * unit)
+	}
+	return duration, nil
+}
+
+// Divides, rounding towards negative infinity, so that times before year 0 fall on the right day.
+func floorDiv(a int64, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// The calendar of the Forgotten Realms. Twelve months of thirty days, with five festivals between them. Leap years are not counted.
+var HARPTOS = Calendar{
+	Name: "harptos",
+	Months: []Month{
+		{Name: "Hammer", Days: 30},
+		{Name: "Midwinter", Days: 1, Festival: true},
+		{Name: "Alturiak", Days: 30},
+		{Name: "Ches", Days: 30},
+		{Name: "Tarsakh", Days: 30},
+		{Name: "Greengrass", Days: 1, Festival: true},
+		{Name: "Mirtul", Days: 30},
+		{Name: "Kythorn", Days: 30},
+		{Name: "Flamerule", Days: 30},
+		{Name: "Midsummer", Days: 1, Festival: true},
+		{Name: "Eleasis", Days: 30},
+		{Name: "Eleint", Days: 30},
+		{Name: "Highharvestide", Days: 1, Festival: true},
+		{Name: "Marpenoth", Days: 30},
+		{Name: "Uktar", Days: 30},
+		{Name: "Feast of the Moon", Days: 1, Festival: true},
+		{Name: "Nightal", Days: 30},
+	},
+	Era: "DR",
+}
+
+// The calendars that sessions can choose between, keyed by lowercase name.
+var calendars = map[string]Calendar{
+	HARPTOS.Name: HARPTOS,
+}
+
+// Makes a calendar available to sessions. Registering a calendar with the same name as another replaces it.
+// Returns an error if the calendar has no months or a month has no days.
+func RegisterCalendar(c Calendar) error {
+	if len(c.Months) == 0 {
+		return errors.New("Calendars must have at least one month")
+	}
+	for _, month := range c.Months {
+		if month.Days < 1 {
+			return errors.New(month.Name + " must have at least one day")
+		}
+	}
+	calendars[strings.ToLower(c.Name)] = c
+	return nil
+}
+
+// Returns the calendar with the passed name, ignoring case, and whether there is one.
+func CalendarNamed(name string) (Calendar, bool) {
+	c, ok := calendars[strings.ToLower(name)]
+	return c, ok
+}
+
+// Returns the names of every available calendar, sorted.
+func CalendarNames() []string {
+	names := make([]string, 0, len(calendars))
+	for name := range calendars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the calendar the session writes in-game dates in, or the default calendar if it has not chosen an available one.
+func (s *Session) GameCalendar() Calendar {
+	if c, ok := CalendarNamed(s.Calendar); ok {
+		return c
+	}
+	c, _ := CalendarNamed(DEFAULT_CALENDAR)
+	return c
+}
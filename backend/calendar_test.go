@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Calendar", func() {
+	It("should have 365 days in a year of Harptos", func() {
+		Expect(HARPTOS.DaysPerYear()).To(Equal(365))
+	})
+
+	It("should convert between times and dates", func() {
+		date := GameDate{Year: 1491, Month: 8, Day: 3, Hour: 14, Minute: 30}
+		t, err := HARPTOS.Time(date)
+		Expect(err).To(BeNil())
+		Expect(HARPTOS.Date(t)).To(Equal(date))
+		Expect(HARPTOS.Date(t + MINUTES_PER_DAY*28).Month).To(Equal(9))
+	})
+
+	It("should refuse dates that do not exist", func() {
+		_, err := HARPTOS.Time(GameDate{Year: 1491, Month: 1, Day: 2})
+		Expect(err).ToNot(BeNil())
+		_, err = HARPTOS.Time(GameDate{Year: 1491, Month: 0, Day: 1, Hour: 24})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should write out dates", func() {
+		t, _ := HARPTOS.Time(GameDate{Year: 1491, Month: 8, Day: 3, Hour: 14, Minute: 30})
+		Expect(HARPTOS.Format(t)).To(Equal("Day 3 of Flamerule, 1491 DR, 14:30"))
+		Expect(HARPTOS.FormatShort(t)).To(Equal("3 Flamerule 14:30"))
+		festival, _ := HARPTOS.Time(GameDate{Year: 1491, Month: 9, Day: 1, Hour: 9})
+		Expect(HARPTOS.Format(festival)).To(Equal("Midsummer, 1491 DR, 09:00"))
+	})
+
+	It("should parse dates", func() {
+		expected, _ := HARPTOS.Time(GameDate{Year: 1491, Month: 8, Day: 3, Hour: 14, Minute: 30})
+		for _, text := range []string{"3 Flamerule 1491 14:30", "flamerule 3rd, 1491 DR 14:30", "Day 3 of Flamerule, 1491 DR, 14:30"} {
+			t, err := HARPTOS.Parse(text)
+			Expect(err).To(BeNil(), text)
+			Expect(t).To(Equal(expected), text)
+		}
+		t, err := HARPTOS.Parse("Feast of the Moon 1491")
+		Expect(err).To(BeNil())
+		Expect(HARPTOS.Months[HARPTOS.Date(t).Month].Name).To(Equal("Feast of the Moon"))
+	})
+
+	It("should refuse dates it cannot parse", func() {
+		for _, text := range []string{"3 1491", "Flamerule 1491", "3 Flamerule 1491 noon", "31 Flamerule 1491"} {
+			_, err := HARPTOS.Parse(text)
+			Expect(err).ToNot(BeNil(), text)
+		}
+	})
+
+	It("should name the days of the week of calendars that have them", func() {
+		week := Calendar{Name: "week", Months: []Month{{Name: "Only", Days: 28}}, Weekdays: []string{"Sun", "Moon"}}
+		Expect(week.Weekday(0)).To(Equal("Sun"))
+		Expect(week.Weekday(MINUTES_PER_DAY)).To(Equal("Moon"))
+		Expect(week.Format(MINUTES_PER_DAY)).To(Equal("Moon, Day 2 of Only, 0, 00:00"))
+		Expect(HARPTOS.Weekday(0)).To(BeEmpty())
+	})
+
+	It("should parse amounts of in-game time", func() {
+		duration, err := ParseGameDuration("1d 2h30m")
+		Expect(err).To(BeNil())
+		Expect(duration).To(Equal(GameTime(MINUTES_PER_DAY + 2*MINUTES_PER_HOUR + 30)))
+		_, err = ParseGameDuration("a while")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should refuse amounts of in-game time too large to keep track of", func() {
+		_, err := ParseGameDuration("99999999999999999999d")
+		Expect(err).ToNot(BeNil())
+		_, err = ParseGameDuration("9999999999999999d")
+		Expect(err).ToNot(BeNil())
+		_, err = ParseGameDuration("9223372036854775807m 1m")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should register calendars", func() {
+		Expect(RegisterCalendar(Calendar{Name: "Empty"})).ToNot(Succeed())
+		Expect(RegisterCalendar(Calendar{Name: "Simple", Months: []Month{{Name: "Month", Days: 10}}})).To(Succeed())
+		c, ok := CalendarNamed("simple")
+		Expect(ok).To(BeTrue())
+		Expect(c.DaysPerYear()).To(Equal(10))
+		Expect(CalendarNames()).To(ContainElement("harptos"))
+	})
+
+	It("should stamp notes with the in-game time while the clock is running", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Before the clock", time.Now()))
+		Expect(s.Notes[0].GameTime).To(BeNil())
+
+		clock, _ := HARPTOS.Parse("1 Hammer 1491")
+		s.History().Execute(&SetClockCommand{Clock: &clock})
+		s.AddNote(NewNote("After the clock", time.Now()))
+		Expect(*s.Notes[1].GameTime).To(Equal(clock))
+
+		var loaded Session
+		Expect(json.Unmarshal([]byte(s.ToJSON()), &loaded)).To(Succeed())
+		Expect(*loaded.Clock).To(Equal(clock))
+		Expect(*loaded.Notes[1].GameTime).To(Equal(clock))
+	})
+
+	It("should set, advance, and stop the clock by command", func() {
+		s := NewSession("Test", 1)
+		registry := NewCommandRegistry(DefaultSlashCommands(NewRoller())...)
+		Expect(registry.Run(s, "/clock +1h", KIND_NARRATIVE)).ToNot(Succeed())
+		Expect(registry.Run(s, "/clock 1 Hammer 1491 9:00", KIND_NARRATIVE)).To(Succeed())
+		Expect(registry.Run(s, "/clock +1d2h", KIND_NARRATIVE)).To(Succeed())
+		Expect(HARPTOS.Format(*s.Clock)).To(Equal("Day 2 of Hammer, 1491 DR, 11:00"))
+		Expect(registry.Run(s, "/clock -30m", KIND_NARRATIVE)).To(Succeed())
+		Expect(HARPTOS.FormatShort(*s.Clock)).To(Equal("2 Hammer 10:30"))
+		Expect(registry.Run(s, "/undo", KIND_NARRATIVE)).To(Succeed())
+		Expect(HARPTOS.FormatShort(*s.Clock)).To(Equal("2 Hammer 11:00"))
+		Expect(registry.Run(s, "/clock off", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.Clock).To(BeNil())
+	})
+
+	It("should change the calendar by command", func() {
+		s := NewSession("Test", 1)
+		RegisterCalendar(Calendar{Name: "Tiny", Months: []Month{{Name: "Month", Days: 10}}})
+		registry := NewCommandRegistry(DefaultSlashCommands(NewRoller())...)
+		Expect(registry.Run(s, "/calendar tiny", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.GameCalendar().Name).To(Equal("Tiny"))
+		Expect(registry.Run(s, "/calendar gregorian", KIND_NARRATIVE)).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,224 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const CAMPAIGN_MANIFEST_NAME = "campaign.json"
+const SESSION_FILE_FORMAT = "session-%03d.json"
+
+// Describes a single session file belonging to a campaign.
+type CampaignEntry struct {
+	File          string    // the name of the session file, relative to the campaign directory
+	SessionTitle  string    // the title of the session at the time it was last recorded in the campaign
+	SessionNumber int       // the number of the session within the campaign
+	Date          time.Time // the date and time the session began
+}
+
+// Represents a campaign, a collection of sessions played by the same group.
+// A campaign is persisted as a directory holding a manifest and every session file.
+type Campaign struct {
+	Name        string          // the name of the campaign
+	Description string          // a free-form description of the campaign
+	Created     time.Time       // the date and time the campaign was created
+	Sessions    []CampaignEntry // the sessions belonging to this campaign, in the order they were played
+	Entities    []Entity        // the people, places, factions, and items that recur across the sessions, changed through AddEntity, UpdateEntity, and RemoveEntity
+	Path        string          `json:"-"` // the directory this campaign is saved in
+	mentions    *mentionIndex   // finds mentions of the entities, built when first needed and cleared when they change
+}
+
+// Create a new Campaign that will be saved in the given directory.
+func NewCampaign(name string, dir string) *Campaign {
+	return &Campaign{
+		Name:     name,
+		Created:  time.Now(),
+		Sessions: make([]CampaignEntry, 0),
+		Entities: make([]Entity, 0),
+		Path:     dir,
+	}
+}
+
+// Returns the session number that the next session created in this campaign should use.
+func (c *Campaign) NextSessionNumber() int {
+	next := 1
+	for _, entry := range c.Sessions {
+		if entry.SessionNumber >= next {
+			next = entry.SessionNumber + 1
+		}
+	}
+	return next
+}
+
+// Returns the full path to the session file of the entry at index i.
+func (c *Campaign) SessionPath(i int) string {
+	return filepath.Join(c.Path, c.Sessions[i].File)
+}
+
+// Creates a new session in this campaign, numbered after the latest session.
+// Numbers whose session file already exists without being in the manifest are skipped, so that no file is overwritten.
+// The session file and the campaign manifest are both written to disk.
+func (c *Campaign) NewSession(sessionTitle string) (*Session, error) {
+	number := c.NextSessionNumber()
+	path := filepath.Join(c.Path, fmt.Sprintf(SESSION_FILE_FORMAT, number))
+	for {
+		_, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return &Session{}, err
+		}
+		number++
+		path = filepath.Join(c.Path, fmt.Sprintf(SESSION_FILE_FORMAT, number))
+	}
+	session := NewSession(sessionTitle, number)
+	session.Path = path
+
+	if err := c.makeDir(); err != nil {
+		return &Session{}, err
+	}
+	if err := session.Save(); err != nil {
+		return &Session{}, err
+	}
+	c.Sessions = append(c.Sessions, c.entryFor(session))
+	if err := c.Save(); err != nil {
+		return &Session{}, err
+	}
+	return session, nil
+}
+
+// Adds an existing session to this campaign. The session must already be saved inside the campaign directory.
+func (c *Campaign) AddSession(s *Session) error {
+	if s.Path == "" {
+		return errors.New("Session must be saved before it can be added to a campaign")
+	}
+	if c.indexOf(s) != -1 {
+		return nil
+	}
+	if _, err := c.relativePath(s.Path); err != nil {
+		return err
+	}
+	c.Sessions = append(c.Sessions, c.entryFor(s))
+	return nil
+}
+
+// Updates the entry of a session in this campaign to reflect its current title, number, and date.
+// Returns false if the session does not belong to this campaign.
+func (c *Campaign) UpdateSession(s *Session) bool {
+	i := c.indexOf(s)
+	if i == -1 {
+		return false
+	}
+	c.Sessions[i] = c.entryFor(s)
+	return true
+}
+
+// Loads the session of the entry at index i.
+// Like Load, a session recovered from a backup is returned along with the error describing the corrupt file.
+func (c *Campaign) OpenSession(i int) (*Session, error) {
+	if i < 0 || i >= len(c.Sessions) {
+		return &Session{}, errors.New("No session at index " + fmt.Sprint(i) + " in campaign")
+	}
+	s, err := Load(c.SessionPath(i))
+	if err != nil && !RecoveredFromBackup(err) {
+		return &Session{}, err
+	}
+	s.Path = c.SessionPath(i)
+	return s, err
+}
+
+// Writes the campaign manifest to its directory, creating the directory if it does not exist.
+// The manifest is replaced in one step, so a failed write leaves the previous manifest intact.
+func (c *Campaign) Save() error {
+	UserRW := fs.FileMode(0600)
+	if err := c.makeDir(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.Path, CAMPAIGN_MANIFEST_NAME), data, UserRW)
+}
+
+// Load a campaign from the manifest in the specified directory.
+// In the case of an error, returns an empty campaign and an error.
+func LoadCampaign(dir string) (*Campaign, error) {
+	data, err := os.ReadFile(filepath.Join(dir, CAMPAIGN_MANIFEST_NAME))
+	if err != nil {
+		return &Campaign{}, err
+	}
+
+	c := Campaign{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return &Campaign{}, err
+	}
+	if c.Sessions == nil {
+		c.Sessions = make([]CampaignEntry, 0)
+	}
+	if c.Entities == nil {
+		c.Entities = make([]Entity, 0)
+	}
+	c.Path = dir
+	return &c, nil
+}
+
+// Reports whether the specified directory contains a campaign manifest.
+func IsCampaignDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, CAMPAIGN_MANIFEST_NAME))
+	return err == nil && !info.IsDir()
+}
+
+// Creates the campaign directory if it does not already exist.
+func (c *Campaign) makeDir() error {
+	UserRWX := fs.FileMode(0700)
+	return os.MkdirAll(c.Path, UserRWX)
+}
+
+// Builds a campaign entry describing the passed session.
+func (c *Campaign) entryFor(s *Session) CampaignEntry {
+	file, _ := c.relativePath(s.Path)
+	return CampaignEntry{
+		File:          file,
+		SessionTitle:  s.SessionTitle,
+		SessionNumber: s.SessionNumber,
+		Date:          s.Date,
+	}
+}
+
+// Returns the index of the entry for the passed session, or -1 if it is not part of this campaign.
+func (c *Campaign) indexOf(s *Session) int {
+	file, err := c.relativePath(s.Path)
+	if err != nil {
+		return -1
+	}
+	for i, entry := range c.Sessions {
+		if entry.File == file {
+			return i
+		}
+	}
+	return -1
+}
+
+// Reports whether the file at the passed path lies inside the campaign directory.
+func (c *Campaign) InDirectory(path string) bool {
+	_, err := c.relativePath(path)
+	return err == nil
+}
+
+// Converts a session path to a path relative to the campaign directory.
+// Returns an error if the path lies outside of the campaign directory.
+func (c *Campaign) relativePath(path string) (string, error) {
+	rel, err := filepath.Rel(c.Path, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.New("Session file " + path + " is not inside the campaign directory " + c.Path)
+	}
+	return rel, nil
+}
@@ -0,0 +1,140 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Campaigns", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-campaign")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should create a Campaign without crashing", func() {
+		createCampaign := func() {
+			NewCampaign("Calimport", dir)
+		}
+		Expect(createCampaign).ShouldNot(Panic())
+	})
+
+	It("should number the first session of a campaign as session 1", func() {
+		c := NewCampaign("Calimport", dir)
+		s, err := c.NewSession("")
+		Expect(err).To(BeNil())
+		Expect(s.SessionNumber).To(Equal(1))
+	})
+
+	It("should number new sessions after the latest session in the campaign", func() {
+		c := NewCampaign("Calimport", dir)
+		c.NewSession("")
+		c.NewSession("")
+		s, _ := c.NewSession("The Return of Aust Redwyn")
+		Expect(s.SessionNumber).To(Equal(3))
+		Expect(c.Sessions).To(HaveLen(3))
+	})
+
+	It("should not overwrite session files missing from the manifest", func() {
+		c := NewCampaign("Calimport", dir)
+		stray := filepath.Join(dir, "session-001.json")
+		Expect(os.WriteFile(stray, []byte("kept"), 0600)).To(Succeed())
+		s, err := c.NewSession("")
+		Expect(err).To(BeNil())
+		Expect(s.SessionNumber).To(Equal(2))
+		Expect(s.Path).To(Equal(filepath.Join(dir, "session-002.json")))
+		data, _ := os.ReadFile(stray)
+		Expect(string(data)).To(Equal("kept"))
+	})
+
+	It("should replace the manifest without leaving temporary files behind", func() {
+		c := NewCampaign("Calimport", dir)
+		Expect(c.Save()).To(Succeed())
+		c.Name = "The Fall of Calimport"
+		Expect(c.Save()).To(Succeed())
+		entries, _ := os.ReadDir(dir)
+		Expect(entries).To(HaveLen(1))
+		loaded, err := LoadCampaign(dir)
+		Expect(err).To(BeNil())
+		Expect(loaded.Name).To(Equal("The Fall of Calimport"))
+	})
+
+	It("should save new sessions inside the campaign directory", func() {
+		c := NewCampaign("Calimport", dir)
+		s, _ := c.NewSession("")
+		Expect(filepath.Dir(s.Path)).To(Equal(dir))
+		Expect(s.Path).To(BeAnExistingFile())
+	})
+
+	It("should write a manifest that can be loaded again", func() {
+		c := NewCampaign("Calimport", dir)
+		c.NewSession("The Conquest at Calimport")
+		Expect(IsCampaignDir(dir)).To(BeTrue())
+
+		c2, err := LoadCampaign(dir)
+		Expect(err).To(BeNil())
+		Expect(c2.Name).To(Equal("Calimport"))
+		Expect(c2.Path).To(Equal(dir))
+		Expect(c2.Sessions).To(HaveLen(1))
+		Expect(c2.Sessions[0].SessionTitle).To(Equal("The Conquest at Calimport"))
+	})
+
+	It("should open the sessions it contains", func() {
+		c := NewCampaign("Calimport", dir)
+		c.NewSession("The Conquest at Calimport")
+		s, err := c.OpenSession(0)
+		Expect(err).To(BeNil())
+		Expect(s.SessionTitle).To(Equal("The Conquest at Calimport"))
+		Expect(s.Path).To(Equal(c.SessionPath(0)))
+	})
+
+	It("should return an error when opening a session that does not exist", func() {
+		c := NewCampaign("Calimport", dir)
+		_, err := c.OpenSession(0)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should update the entry of a session when it changes", func() {
+		c := NewCampaign("Calimport", dir)
+		s, _ := c.NewSession("")
+		s.SessionTitle = "Reunion in the Face of Adversity"
+		Expect(c.UpdateSession(s)).To(BeTrue())
+		Expect(c.Sessions[0].SessionTitle).To(Equal(s.SessionTitle))
+	})
+
+	It("should not update sessions that are not part of the campaign", func() {
+		c := NewCampaign("Calimport", dir)
+		s := NewSession("Elsewhere", 1)
+		s.Path = filepath.Join(os.TempDir(), "elsewhere.json")
+		Expect(c.UpdateSession(s)).To(BeFalse())
+	})
+
+	It("should not add sessions saved outside of the campaign directory", func() {
+		c := NewCampaign("Calimport", dir)
+		s := NewSession("Elsewhere", 1)
+		s.Path = filepath.Join(os.TempDir(), "elsewhere.json")
+		Expect(c.AddSession(s)).ToNot(BeNil())
+		Expect(c.InDirectory(s.Path)).To(BeFalse())
+		Expect(c.InDirectory(filepath.Join(dir, "session.json"))).To(BeTrue())
+	})
+
+	It("should not add unsaved sessions", func() {
+		c := NewCampaign("Calimport", dir)
+		Expect(c.AddSession(NewSession("Unsaved", 1))).ToNot(BeNil())
+	})
+
+	It("should return an error when loading a directory that is not a campaign", func() {
+		_, err := LoadCampaign(dir)
+		Expect(err).ToNot(BeNil())
+		Expect(IsCampaignDir(dir)).To(BeFalse())
+	})
+})
@@ -0,0 +1,322 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A creature taking part in a combat.
+type Combatant struct {
+	Name       string   // the name of the creature, like "Goblin 2"
+	Initiative int      // the initiative the creature rolled, higher goes first
+	HP         int      // the current hit points of the creature
+	MaxHP      int      // the maximum hit points of the creature, or 0 if healing is not limited
+	Conditions []string `json:",omitempty"` // the conditions affecting the creature, like "poisoned"
+}
+
+// Reports whether the creature has dropped to 0 hit points.
+func (c Combatant) IsDown() bool {
+	return c.HP <= 0
+}
+
+// A fight being tracked turn by turn. What happens during a turn is recorded as notes when the turn ends.
+type Combat struct {
+	Combatants []Combatant // every creature in the fight, in initiative order
+	Round      int         // the current round, starting at 1
+	Turn       int         // the index of the combatant whose turn it is
+	Pending    []string    `json:",omitempty"` // what has happened during the current turn, not yet recorded as a note
+	emitted    []string    // the content of the notes recorded by changes not yet added to the session
+}
+
+// Create a new Combat in its first round, without any combatants.
+func NewCombat() *Combat {
+	return &Combat{Combatants: make([]Combatant, 0), Round: 1}
+}
+
+// Returns a copy of the combat that can be changed without changing the original.
+func (c *Combat) Clone() *Combat {
+	clone := *c
+	clone.Combatants = make([]Combatant, len(c.Combatants))
+	for i, combatant := range c.Combatants {
+		combatant.Conditions = append([]string(nil), combatant.Conditions...)
+		clone.Combatants[i] = combatant
+	}
+	clone.Pending = append([]string(nil), c.Pending...)
+	clone.emitted = nil
+	return &clone
+}
+
+// Returns the combatant whose turn it is, or false if there are no combatants.
+func (c *Combat) Current() (Combatant, bool) {
+	if c.Turn < 0 || c.Turn >= len(c.Combatants) {
+		return Combatant{}, false
+	}
+	return c.Combatants[c.Turn], true
+}
+
+// Adds a creature to the fight in initiative order, after any creatures with the same initiative.
+// The turn stays with the creature whose turn it is. Returns an error if the creature has no name.
+func (c *Combat) AddCombatant(combatant Combatant) error {
+	combatant.Name = strings.TrimSpace(combatant.Name)
+	if combatant.Name == "" {
+		return errors.New("Combatants must have a name")
+	}
+	i := sort.Search(len(c.Combatants), func(i int) bool {
+		return c.Combatants[i].Initiative < combatant.Initiative
+	})
+	c.Combatants = append(c.Combatants, Combatant{})
+	copy(c.Combatants[i+1:], c.Combatants[i:])
+	c.Combatants[i] = combatant
+	if i <= c.Turn && len(c.Combatants) > 1 {
+		c.Turn++
+	}
+	return nil
+}
+
+// Removes the creature at index i from the fight. If it was its turn, what happened during the turn is recorded
+// and the turn passes to the next creature, beginning a new round after the last creature.
+func (c *Combat) RemoveCombatant(i int) error {
+	if err := c.checkIndex(i); err != nil {
+		return err
+	}
+	if i == c.Turn {
+		c.flush()
+	}
+	c.Combatants = append(c.Combatants[:i], c.Combatants[i+1:]...)
+	if i < c.Turn {
+		c.Turn--
+	}
+	if c.Turn >= len(c.Combatants) {
+		c.Turn = 0
+		if len(c.Combatants) > 0 {
+			c.Round++
+		}
+	}
+	return nil
+}
+
+// Removes hit points from the creature at index i, to no lower than 0. Records the creature dropping if it does.
+func (c *Combat) Damage(i int, amount int) error {
+	if err := c.checkIndex(i); err != nil {
+		return err
+	}
+	if amount < 0 {
+		return errors.New("Damage cannot be negative")
+	}
+	combatant := &c.Combatants[i]
+	wasDown := combatant.IsDown()
+	combatant.HP -= amount
+	if combatant.HP < 0 {
+		combatant.HP = 0
+	}
+	if !wasDown && combatant.IsDown() {
+		c.record(combatant.Name + " dropped")
+	}
+	return nil
+}
+
+// Restores hit points to the creature at index i, to no higher than its maximum. Records the creature getting back up if it does.
+func (c *Combat) Heal(i int, amount int) error {
+	if err := c.checkIndex(i); err != nil {
+		return err
+	}
+	if amount < 0 {
+		return errors.New("Healing cannot be negative")
+	}
+	combatant := &c.Combatants[i]
+	wasDown := combatant.IsDown()
+	combatant.HP += amount
+	if combatant.MaxHP > 0 && combatant.HP > combatant.MaxHP {
+		combatant.HP = combatant.MaxHP
+	}
+	if wasDown && !combatant.IsDown() {
+		c.record(combatant.Name + " is back up")
+	}
+	return nil
+}
+
+// Replaces the conditions of the creature at index i, recording every condition gained or lost.
+// Conditions are lowercased and duplicates are removed.
+func (c *Combat) SetConditions(i int, conditions []string) error {
+	if err := c.checkIndex(i); err != nil {
+		return err
+	}
+	combatant := &c.Combatants[i]
+	cleaned := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		condition = strings.ToLower(strings.TrimSpace(condition))
+		if condition != "" && !containsString(cleaned, condition) {
+			cleaned = append(cleaned, condition)
+		}
+	}
+	for _, condition := range cleaned {
+		if !containsString(combatant.Conditions, condition) {
+			c.record(combatant.Name + " is " + condition)
+		}
+	}
+	for _, condition := range combatant.Conditions {
+		if !containsString(cleaned, condition) {
+			c.record(combatant.Name + " is no longer " + condition)
+		}
+	}
+	combatant.Conditions = cleaned
+	return nil
+}
+
+// Ends the turn of the current creature, recording what happened during it, and passes the turn to the next creature.
+// After the last creature, a new round begins.
+func (c *Combat) NextTurn() {
+	c.flush()
+	if len(c.Combatants) == 0 {
+		return
+	}
+	c.Turn++
+	if c.Turn >= len(c.Combatants) {
+		c.Turn = 0
+		c.Round++
+	}
+}
+
+// Records that something happened during the current turn.
+func (c *Combat) record(event string) {
+	c.Pending = append(c.Pending, event)
+}
+
+// Emits a note of everything that happened during the current turn, like "Round 3: Goblin 2 dropped".
+func (c *Combat) flush() {
+	if len(c.Pending) == 0 {
+		return
+	}
+	c.emit(fmt.Sprintf("Round %d: %s", c.Round, strings.Join(c.Pending, ", ")))
+	c.Pending = nil
+}
+
+// Emits a note to be added to the session.
+func (c *Combat) emit(content string) {
+	c.emitted = append(c.emitted, content)
+}
+
+// Returns an error if there is no combatant at index i.
+func (c *Combat) checkIndex(i int) error {
+	if i < 0 || i >= len(c.Combatants) {
+		return errors.New("No combatant at index " + fmt.Sprint(i))
+	}
+	return nil
+}
+
+// Reports whether the string is in the list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Replaces the combat of a session and adds the notes recorded while changing it.
+type CombatCommand struct {
+	Combat   *Combat // the new state of the combat, or nil if there is no combat
+	Notes    []Note  // the notes to add to the session
+	previous *Combat // the combat before the change
+	added    int     // the number of notes added
+}
+
+// Replaces the combat and adds the notes, remembering the previous combat.
+func (c *CombatCommand) Do(s *Session) error {
+	c.previous = s.Combat
+	s.Combat = c.Combat
+	before := len(s.Notes)
+	for _, note := range c.Notes {
+		s.AddNote(note)
+	}
+	c.added = len(s.Notes) - before
+	return nil
+}
+
+// Removes the added notes and restores the previous combat.
+func (c *CombatCommand) Undo(s *Session) error {
+	s.Notes = s.Notes[:len(s.Notes)-c.added]
+	s.Combat = c.previous
+	return nil
+}
+
+// Starts tracking a fight. Records a note that combat started. Returns an error if a fight is already being tracked.
+func (s *Session) StartCombat() error {
+	if s.Combat != nil {
+		return errors.New("Combat has already started")
+	}
+	combat := NewCombat()
+	combat.emit("Combat started")
+	return s.executeCombat(combat)
+}
+
+// Changes the fight being tracked. The change is made to a copy of the combat so that it can be undone,
+// and any notes it records are added to the session. Returns an error if no fight is being tracked or the change fails.
+func (s *Session) ChangeCombat(change func(c *Combat) error) error {
+	if s.Combat == nil {
+		return errors.New("Combat has not started")
+	}
+	combat := s.Combat.Clone()
+	if err := change(combat); err != nil {
+		return err
+	}
+	return s.executeCombat(combat)
+}
+
+// Stops tracking the fight, recording what happened during the last turn and how many rounds the fight lasted.
+func (s *Session) EndCombat() error {
+	if s.Combat == nil {
+		return errors.New("Combat has not started")
+	}
+	combat := s.Combat.Clone()
+	combat.flush()
+	rounds := "rounds"
+	if combat.Round == 1 {
+		rounds = "round"
+	}
+	combat.emit(fmt.Sprintf("Combat ended after %d %s", combat.Round, rounds))
+	notes := combatNotes(combat.emitted)
+	return s.History().Execute(&CombatCommand{Combat: nil, Notes: notes})
+}
+
+// Replaces the combat of the session with the passed one, adding the notes it recorded.
+func (s *Session) executeCombat(combat *Combat) error {
+	notes := combatNotes(combat.emitted)
+	combat.emitted = nil
+	return s.History().Execute(&CombatCommand{Combat: combat, Notes: notes})
+}
+
+// Creates combat notes with the passed contents.
+func combatNotes(contents []string) []Note {
+	notes := make([]Note, 0, len(contents))
+	for _, content := range contents {
+		note := NewNote(content, time.Now())
+		note.Kind = KIND_COMBAT
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+// Starts, advances, or ends the combat being tracked.
+func runCombat(call SlashCall) error {
+	if len(call.Args) != 1 {
+		return errors.New("Type /combat start, /combat next, or /combat end")
+	}
+	s := call.Session
+	switch strings.ToLower(call.Args[0]) {
+	case "start":
+		return s.StartCombat()
+	case "next":
+		return s.ChangeCombat(func(c *Combat) error {
+			c.NextTurn()
+			return nil
+		})
+	case "end":
+		return s.EndCombat()
+	}
+	return errors.New("Type /combat start, /combat next, or /combat end")
+}
@@ -0,0 +1,161 @@
+package backend
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Combat", func() {
+	var s *Session
+
+	BeforeEach(func() {
+		s = NewSession("Test", 1)
+		Expect(s.StartCombat()).To(Succeed())
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			c.AddCombatant(Combatant{Name: "Xenthe", Initiative: 15, HP: 20, MaxHP: 20})
+			c.AddCombatant(Combatant{Name: "Goblin 1", Initiative: 18, HP: 7, MaxHP: 7})
+			return c.AddCombatant(Combatant{Name: "Goblin 2", Initiative: 12, HP: 7, MaxHP: 7})
+		})).To(Succeed())
+	})
+
+	names := func(c *Combat) []string {
+		result := make([]string, 0)
+		for _, combatant := range c.Combatants {
+			result = append(result, combatant.Name)
+		}
+		return result
+	}
+
+	It("should record that combat started", func() {
+		Expect(s.Notes).To(HaveLen(1))
+		Expect(s.Notes[0].Content).To(Equal("Combat started"))
+		Expect(s.Notes[0].Kind).To(Equal(KIND_COMBAT))
+		Expect(s.StartCombat()).ToNot(Succeed())
+	})
+
+	It("should keep combatants in initiative order", func() {
+		Expect(names(s.Combat)).To(Equal([]string{"Goblin 1", "Xenthe", "Goblin 2"}))
+	})
+
+	It("should keep the turn with the current combatant when combatants join", func() {
+		current, _ := s.Combat.Current()
+		Expect(current.Name).To(Equal("Xenthe"))
+	})
+
+	It("should refuse combatants without a name", func() {
+		Expect(s.Combat.Clone().AddCombatant(Combatant{Name: " "})).ToNot(Succeed())
+	})
+
+	It("should advance turns and rounds", func() {
+		next := func(c *Combat) error {
+			c.NextTurn()
+			return nil
+		}
+		Expect(s.ChangeCombat(next)).To(Succeed())
+		current, _ := s.Combat.Current()
+		Expect(current.Name).To(Equal("Goblin 2"))
+		Expect(s.ChangeCombat(next)).To(Succeed())
+		Expect(s.Combat.Round).To(Equal(2))
+		current, _ = s.Combat.Current()
+		Expect(current.Name).To(Equal("Goblin 1"))
+	})
+
+	It("should record what happened during a turn when it ends", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			return c.Damage(2, 10)
+		})).To(Succeed())
+		Expect(s.Combat.Combatants[2].HP).To(Equal(0))
+		Expect(s.Notes).To(HaveLen(1))
+
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			c.NextTurn()
+			return nil
+		})).To(Succeed())
+		Expect(s.Notes).To(HaveLen(2))
+		Expect(s.Notes[1].Content).To(Equal("Round 1: Goblin 2 dropped"))
+		Expect(s.Notes[1].Kind).To(Equal(KIND_COMBAT))
+	})
+
+	It("should heal no higher than the maximum", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			c.Damage(1, 25)
+			return c.Heal(1, 30)
+		})).To(Succeed())
+		Expect(s.Combat.Combatants[1].HP).To(Equal(20))
+		Expect(s.Combat.Pending).To(Equal([]string{"Xenthe dropped", "Xenthe is back up"}))
+	})
+
+	It("should record conditions gained and lost", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			return c.SetConditions(1, []string{"Poisoned", "prone", "poisoned"})
+		})).To(Succeed())
+		Expect(s.Combat.Combatants[1].Conditions).To(Equal([]string{"poisoned", "prone"}))
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			return c.SetConditions(1, []string{"prone"})
+		})).To(Succeed())
+		Expect(s.Combat.Pending).To(Equal([]string{"Xenthe is poisoned", "Xenthe is prone", "Xenthe is no longer poisoned"}))
+	})
+
+	It("should pass the turn on when the current combatant is removed", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			return c.RemoveCombatant(1)
+		})).To(Succeed())
+		current, _ := s.Combat.Current()
+		Expect(current.Name).To(Equal("Goblin 2"))
+	})
+
+	It("should record the turn of a removed combatant and begin a new round after the last one", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			c.NextTurn()
+			c.Damage(0, 7)
+			return c.RemoveCombatant(2)
+		})).To(Succeed())
+		Expect(s.Notes[len(s.Notes)-1].Content).To(Equal("Round 1: Goblin 1 dropped"))
+		Expect(s.Combat.Pending).To(BeEmpty())
+		Expect(s.Combat.Round).To(Equal(2))
+		current, _ := s.Combat.Current()
+		Expect(current.Name).To(Equal("Goblin 1"))
+	})
+
+	It("should undo changes to the combat along with the notes they recorded", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			c.Damage(2, 10)
+			c.NextTurn()
+			return nil
+		})).To(Succeed())
+		Expect(s.Notes).To(HaveLen(2))
+		Expect(s.History().Undo()).To(Succeed())
+		Expect(s.Notes).To(HaveLen(1))
+		Expect(s.Combat.Combatants[2].HP).To(Equal(7))
+		Expect(s.Combat.Turn).To(Equal(1))
+	})
+
+	It("should record the end of combat", func() {
+		Expect(s.ChangeCombat(func(c *Combat) error {
+			return c.Damage(0, 7)
+		})).To(Succeed())
+		Expect(s.EndCombat()).To(Succeed())
+		Expect(s.Combat).To(BeNil())
+		Expect(s.Notes[len(s.Notes)-2].Content).To(Equal("Round 1: Goblin 1 dropped"))
+		Expect(s.Notes[len(s.Notes)-1].Content).To(Equal("Combat ended after 1 round"))
+		Expect(s.ChangeCombat(func(c *Combat) error { return nil })).ToNot(Succeed())
+	})
+
+	It("should keep the combat when saved and loaded", func() {
+		var loaded Session
+		Expect(json.Unmarshal([]byte(s.ToJSON()), &loaded)).To(Succeed())
+		Expect(names(loaded.Combat)).To(Equal(names(s.Combat)))
+		Expect(loaded.Combat.Turn).To(Equal(s.Combat.Turn))
+	})
+
+	It("should be run by command", func() {
+		registry := NewCommandRegistry(DefaultSlashCommands(NewRoller())...)
+		Expect(registry.Run(s, "/combat next", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.Combat.Turn).To(Equal(2))
+		Expect(registry.Run(s, "/combat end", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.Combat).To(BeNil())
+		Expect(registry.Run(s, "/combat flee", KIND_NARRATIVE)).ToNot(Succeed())
+	})
+})
@@ -0,0 +1,225 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Starts a note that rolls dice, like "/roll 4d6kh3 for strength".
+const ROLL_COMMAND = "/roll"
+
+// The most dice a single term of an expression can roll, and the most sides a die can have.
+const MAX_DICE = 1000
+const MAX_SIDES = 10000
+
+// Matches a roll written inline within a note, like "[[1d20+5]]", capturing the expression.
+var inlineRollPattern = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+
+// Matches a single term of a dice expression, like "4d6kh3", "d%", or "5".
+var diceTermPattern = regexp.MustCompile(`^(\d*)[dD](\d+|%)((?:[kd][hl]?)(\d+))?$`)
+
+// The result of a single die of a roll.
+type DieResult struct {
+	Sides   int  // the number of sides of the die
+	Value   int  // the face the die landed on
+	Dropped bool `json:",omitempty"` // whether the die was left out of the total by a keep or drop modifier
+}
+
+// A dice expression that was rolled, along with every die that was rolled for it.
+type Roll struct {
+	Expression string      // the dice expression as written, like 4d6kh3
+	Dice       []DieResult // every die rolled, in the order they were written and rolled
+	Total      int         // the sum of the kept dice and any constants
+}
+
+// Describes the result of the roll, like "4d6kh3 = 14 [6, 5, 3, (2)]". Dropped dice are in parentheses.
+func (r Roll) String() string {
+	if len(r.Dice) == 0 {
+		return fmt.Sprintf("%s = %d", r.Expression, r.Total)
+	}
+	faces := make([]string, 0, len(r.Dice))
+	for _, die := range r.Dice {
+		if die.Dropped {
+			faces = append(faces, "("+strconv.Itoa(die.Value)+")")
+		} else {
+			faces = append(faces, strconv.Itoa(die.Value))
+		}
+	}
+	return fmt.Sprintf("%s = %d [%s]", r.Expression, r.Total, strings.Join(faces, ", "))
+}
+
+// Rolls dice expressions. Supports sums of dice and constants, like "2d6+3" or "1d20-1+1d4",
+// percentile dice written "d%", and keeping or dropping the highest or lowest dice, like "4d6kh3" or "2d20dl1".
+type Roller struct {
+	rng *rand.Rand // the source of every die rolled
+}
+
+// An option to customize the constructor for creating a new roller.
+type RollerOption func(r *Roller)
+
+// Option to create a roller whose rolls are determined by the passed seed. Rollers with the same seed roll the same dice.
+func WithSeed(seed int64) RollerOption {
+	return func(r *Roller) {
+		r.rng = rand.New(rand.NewSource(seed))
+	}
+}
+
+// Create a new Roller, seeded from the current time unless a seed is passed.
+func NewRoller(options ...RollerOption) *Roller {
+	roller := &Roller{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
+	for _, option := range options {
+		option(roller)
+	}
+	return roller
+}
+
+// Rolls a dice expression. Returns an error if the expression is not valid.
+func (r *Roller) Roll(expression string) (Roll, error) {
+	expression = strings.Join(strings.Fields(expression), "")
+	roll := Roll{Expression: expression, Dice: make([]DieResult, 0)}
+	if expression == "" {
+		return roll, errors.New("Write the dice to roll, like 2d6+3")
+	}
+
+	sign := 1
+	start := 0
+	for i := 0; i <= len(expression); i++ {
+		if i < len(expression) && expression[i] != '+' && expression[i] != '-' {
+			continue
+		}
+		// a sign at the very start applies to the first term
+		if i == 0 {
+			if expression[i] == '-' {
+				sign = -1
+			}
+			start = 1
+			continue
+		}
+		total, dice, err := r.rollTerm(expression[start:i])
+		if err != nil {
+			return roll, err
+		}
+		roll.Total += sign * total
+		roll.Dice = append(roll.Dice, dice...)
+		if i < len(expression) && expression[i] == '-' {
+			sign = -1
+		} else {
+			sign = 1
+		}
+		start = i + 1
+	}
+	return roll, nil
+}
+
+// Rolls the dice in a note. A note starting with /roll has the expression after it rolled and replaced by its result,
+// and every expression written inline between double brackets, like [[1d20+5]], has the result added after it.
+// Returns the content with the results written in, and every roll in the order written.
+// Only an invalid expression after /roll is an error; invalid inline expressions are left as written.
+func (r *Roller) RollNote(content string) (string, []Roll, error) {
+	rolls := make([]Roll, 0)
+	if fields := strings.Fields(content); len(fields) > 0 && strings.EqualFold(fields[0], ROLL_COMMAND) {
+		if len(fields) < 2 {
+			return content, rolls, errors.New("Write the dice to roll after " + ROLL_COMMAND + ", like " + ROLL_COMMAND + " 2d6+3")
+		}
+		roll, err := r.Roll(fields[1])
+		if err != nil {
+			return content, rolls, err
+		}
+		rolls = append(rolls, roll)
+		content = strings.TrimSpace("Rolled " + roll.String() + " " + strings.Join(fields[2:], " "))
+	}
+
+	content = inlineRollPattern.ReplaceAllStringFunc(content, func(match string) string {
+		roll, err := r.Roll(inlineRollPattern.FindStringSubmatch(match)[1])
+		if err != nil {
+			return match
+		}
+		rolls = append(rolls, roll)
+		return "[[" + roll.String() + "]]"
+	})
+	return content, rolls, nil
+}
+
+// Rolls a single term of an expression, either a constant or a group of dice.
+// Returns the total of the term and every die rolled for it.
+func (r *Roller) rollTerm(term string) (int, []DieResult, error) {
+	if term == "" {
+		return 0, nil, errors.New("Dice expressions cannot have two signs in a row or end with a sign")
+	}
+	if constant, err := strconv.Atoi(term); err == nil {
+		return constant, nil, nil
+	}
+
+	match := diceTermPattern.FindStringSubmatch(strings.ToLower(term))
+	if match == nil {
+		return 0, nil, errors.New(term + " is not a valid roll, write dice like 2d6, d20, or 4d6kh3")
+	}
+	count := 1
+	if match[1] != "" {
+		count, _ = strconv.Atoi(match[1])
+	}
+	sides := 100
+	if match[2] != "%" {
+		sides, _ = strconv.Atoi(match[2])
+	}
+	if count < 1 || count > MAX_DICE {
+		return 0, nil, fmt.Errorf("%s must roll between 1 and %d dice", term, MAX_DICE)
+	}
+	if sides < 1 || sides > MAX_SIDES {
+		return 0, nil, fmt.Errorf("%s must roll dice of between 1 and %d sides", term, MAX_SIDES)
+	}
+
+	dice := make([]DieResult, count)
+	for i := range dice {
+		dice[i] = DieResult{Sides: sides, Value: r.rng.Intn(sides) + 1}
+	}
+	if match[3] != "" {
+		n, _ := strconv.Atoi(match[4])
+		if n > count {
+			return 0, nil, fmt.Errorf("%s cannot keep or drop more dice than it rolls", term)
+		}
+		dropDice(dice, strings.TrimSuffix(match[3], match[4]), n)
+	}
+
+	total := 0
+	for _, die := range dice {
+		if !die.Dropped {
+			total += die.Value
+		}
+	}
+	return total, dice, nil
+}
+
+// Marks dice as dropped according to a keep or drop modifier: "k" or "kh" keeps the highest n dice, "kl" the lowest,
+// "d" or "dl" drops the lowest n dice, and "dh" the highest.
+func dropDice(dice []DieResult, modifier string, n int) {
+	order := make([]int, len(dice))
+	for i := range order {
+		order[i] = i
+	}
+	// lowest first, earlier dice first among equals
+	sort.SliceStable(order, func(i, j int) bool {
+		return dice[order[i]].Value < dice[order[j]].Value
+	})
+
+	var dropped []int
+	switch modifier {
+	case "k", "kh":
+		dropped = order[:len(dice)-n]
+	case "kl":
+		dropped = order[n:]
+	case "d", "dl":
+		dropped = order[:n]
+	case "dh":
+		dropped = order[len(dice)-n:]
+	}
+	for _, i := range dropped {
+		dice[i].Dropped = true
+	}
+}
@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dice", func() {
+	It("should roll the same dice with the same seed", func() {
+		first, err := NewRoller(WithSeed(42)).Roll("4d6+2d8")
+		Expect(err).To(BeNil())
+		second, _ := NewRoller(WithSeed(42)).Roll("4d6+2d8")
+		Expect(second).To(Equal(first))
+	})
+
+	It("should total the dice and constants of an expression", func() {
+		roll, err := NewRoller(WithSeed(7)).Roll("2d6 + 3 - 1d4")
+		Expect(err).To(BeNil())
+		Expect(roll.Expression).To(Equal("2d6+3-1d4"))
+		Expect(roll.Dice).To(HaveLen(3))
+		Expect(roll.Total).To(Equal(roll.Dice[0].Value + roll.Dice[1].Value + 3 - roll.Dice[2].Value))
+	})
+
+	It("should roll dice within their sides", func() {
+		roll, _ := NewRoller(WithSeed(1)).Roll("100d20")
+		for _, die := range roll.Dice {
+			Expect(die.Sides).To(Equal(20))
+			Expect(die.Value).To(BeNumerically(">=", 1))
+			Expect(die.Value).To(BeNumerically("<=", 20))
+		}
+	})
+
+	It("should keep the highest dice", func() {
+		roll, err := NewRoller(WithSeed(3)).Roll("4d6kh3")
+		Expect(err).To(BeNil())
+		lowest, total := 0, 0
+		for i, die := range roll.Dice {
+			total += die.Value
+			if die.Value < roll.Dice[lowest].Value {
+				lowest = i
+			}
+		}
+		for i, die := range roll.Dice {
+			Expect(die.Dropped).To(Equal(i == lowest))
+		}
+		Expect(roll.Total).To(Equal(total - roll.Dice[lowest].Value))
+	})
+
+	It("should drop the lowest dice", func() {
+		roll, _ := NewRoller(WithSeed(3)).Roll("2d20dl1")
+		dropped := roll.Dice[0]
+		kept := roll.Dice[1]
+		if kept.Dropped {
+			dropped, kept = kept, dropped
+		}
+		Expect(dropped.Dropped).To(BeTrue())
+		Expect(dropped.Value).To(BeNumerically("<=", kept.Value))
+		Expect(roll.Total).To(Equal(kept.Value))
+	})
+
+	It("should roll percentile dice", func() {
+		roll, err := NewRoller(WithSeed(5)).Roll("d%")
+		Expect(err).To(BeNil())
+		Expect(roll.Dice[0].Sides).To(Equal(100))
+	})
+
+	It("should refuse invalid expressions", func() {
+		roller := NewRoller(WithSeed(1))
+		for _, expression := range []string{"", "2d", "d0", "2x6", "1d6+", "3d6kh4", "1001d6"} {
+			_, err := roller.Roll(expression)
+			Expect(err).ToNot(BeNil(), expression)
+		}
+	})
+
+	It("should describe rolls with their dice", func() {
+		roll := Roll{
+			Expression: "2d20kh1",
+			Dice:       []DieResult{{Sides: 20, Value: 4, Dropped: true}, {Sides: 20, Value: 17}},
+			Total:      17,
+		}
+		Expect(roll.String()).To(Equal("2d20kh1 = 17 [(4), 17]"))
+	})
+
+	It("should replace a /roll command with its result", func() {
+		content, rolls, err := NewRoller(WithSeed(9)).RollNote("/roll 1d20+5 to climb the wall")
+		Expect(err).To(BeNil())
+		Expect(rolls).To(HaveLen(1))
+		Expect(content).To(Equal("Rolled " + rolls[0].String() + " to climb the wall"))
+	})
+
+	It("should refuse a /roll command without valid dice", func() {
+		_, _, err := NewRoller().RollNote("/roll")
+		Expect(err).ToNot(BeNil())
+		_, _, err = NewRoller().RollNote("/roll fireball")
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should write the results of inline rolls into the note", func() {
+		content, rolls, err := NewRoller(WithSeed(9)).RollNote("Xenthe attacks [[1d20+5]] for [[1d8+3]] damage")
+		Expect(err).To(BeNil())
+		Expect(rolls).To(HaveLen(2))
+		Expect(content).To(Equal("Xenthe attacks [[" + rolls[0].String() + "]] for [[" + rolls[1].String() + "]] damage"))
+	})
+
+	It("should leave invalid inline rolls as written", func() {
+		content, rolls, err := NewRoller().RollNote("See [[the map]]")
+		Expect(err).To(BeNil())
+		Expect(rolls).To(BeEmpty())
+		Expect(content).To(Equal("See [[the map]]"))
+	})
+
+	It("should keep the rolls of a note when saved and loaded", func() {
+		s := NewSession("Test", 1)
+		note := NewNote("Rolled", time.Now())
+		note.Rolls = []Roll{{Expression: "1d4", Dice: []DieResult{{Sides: 4, Value: 3}}, Total: 3}}
+		s.AddNote(note)
+		var loaded Session
+		Expect(json.Unmarshal([]byte(s.ToJSON()), &loaded)).To(Succeed())
+		Expect(loaded.Notes[0].Rolls).To(Equal(note.Rolls))
+	})
+})
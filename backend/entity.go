@@ -0,0 +1,251 @@
+package backend
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The kind of thing an entity of a campaign is.
+type EntityKind string
+
+const (
+	ENTITY_NPC      EntityKind = "NPC"
+	ENTITY_LOCATION EntityKind = "Location"
+	ENTITY_FACTION  EntityKind = "Faction"
+	ENTITY_ITEM     EntityKind = "Item"
+)
+
+// Every kind of entity, in the order they are offered to the user.
+var ENTITY_KINDS = []EntityKind{ENTITY_NPC, ENTITY_LOCATION, ENTITY_FACTION, ENTITY_ITEM}
+
+// A person, place, faction, or item that recurs across the sessions of a campaign.
+type Entity struct {
+	Name        string     // the name the entity is known by
+	Kind        EntityKind // what kind of thing the entity is
+	Aliases     []string   `json:",omitempty"` // other names the entity is mentioned by
+	Description string     `json:",omitempty"` // a free-form description of the entity
+}
+
+// Represents a mention of an entity within the content of a note.
+type Mention struct {
+	Entity string // the name of the entity mentioned
+	Start  int    // the byte offset within the content where the mention begins
+	End    int    // the byte offset within the content where the mention ends
+}
+
+// Returns the name and every alias of the entity.
+func (e Entity) Names() []string {
+	return append([]string{e.Name}, e.Aliases...)
+}
+
+// Adds an entity to the registry of this campaign.
+// Returns an error if the entity has no name, or if one of its names is already used by another entity.
+func (c *Campaign) AddEntity(e Entity) error {
+	if err := c.validateEntity(e, ""); err != nil {
+		return err
+	}
+	c.Entities = append(c.Entities, e)
+	c.mentions = nil
+	return nil
+}
+
+// Replaces the entity with the passed name in the registry of this campaign.
+// Returns an error if there is no such entity, or if the replacement is not valid.
+func (c *Campaign) UpdateEntity(name string, e Entity) error {
+	i := c.entityIndex(name)
+	if i == -1 {
+		return errors.New("No entity named " + name + " in campaign")
+	}
+	if err := c.validateEntity(e, c.Entities[i].Name); err != nil {
+		return err
+	}
+	c.Entities[i] = e
+	c.mentions = nil
+	return nil
+}
+
+// Removes the entity with the passed name from the registry of this campaign.
+// Returns false if there is no such entity.
+func (c *Campaign) RemoveEntity(name string) bool {
+	i := c.entityIndex(name)
+	if i == -1 {
+		return false
+	}
+	c.Entities = append(c.Entities[:i], c.Entities[i+1:]...)
+	c.mentions = nil
+	return true
+}
+
+// Returns the entity known by the passed name or alias, ignoring case.
+// Returns false if no entity in this campaign is known by that name.
+func (c *Campaign) Entity(name string) (Entity, bool) {
+	i := c.entityIndex(name)
+	if i == -1 {
+		return Entity{}, false
+	}
+	return c.Entities[i], true
+}
+
+// Returns every mention of an entity of this campaign within the passed text, in the order they appear.
+// Names are matched as whole words, ignoring case. Where names overlap, the longest one is mentioned.
+func (c *Campaign) FindMentions(text string) []Mention {
+	mentions := make([]Mention, 0)
+	if c.mentions == nil {
+		c.mentions = c.mentionPattern()
+	}
+	pattern, owners := c.mentions.pattern, c.mentions.owners
+	if pattern == nil {
+		return mentions
+	}
+
+	for pos := 0; pos < len(text); {
+		match := pattern.FindStringIndex(text[pos:])
+		if match == nil {
+			break
+		}
+		start, end := pos+match[0], pos+match[1]
+		if !isWordBoundary(text, start) || !isWordBoundary(text, end) {
+			// the name is part of a longer word, so look again from the next character
+			_, size := utf8.DecodeRuneInString(text[start:])
+			pos = start + size
+			continue
+		}
+		owner, ok := owners[strings.ToLower(text[start:end])]
+		if !ok {
+			// case folding matched a name that lowercases differently
+			e, _ := c.Entity(text[start:end])
+			owner = e.Name
+		}
+		mentions = append(mentions, Mention{
+			Entity: owner,
+			Start:  start,
+			End:    end,
+		})
+		pos = end
+	}
+	return mentions
+}
+
+// Returns every note in this session that mentions the entity of the campaign with the passed name.
+// Each result highlights the mentions of the entity.
+func (s *Session) Mentioning(c *Campaign, name string) []SearchResult {
+	results := make([]SearchResult, 0)
+	e, ok := c.Entity(name)
+	if !ok {
+		return results
+	}
+	for i, note := range s.Notes {
+		matches := make([][]int, 0)
+		for _, mention := range c.FindMentions(note.Content) {
+			if mention.Entity == e.Name {
+				matches = append(matches, []int{mention.Start, mention.End})
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		snippet, highlights := buildSnippet(note.Content, matches)
+		results = append(results, SearchResult{
+			Session:    s,
+			NoteIndex:  i,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+	return results
+}
+
+// Returns every note in every session of a campaign that mentions the entity with the passed name.
+// Results are ordered by session, then by note.
+func MentionsInCampaign(c *Campaign, name string) ([]SearchResult, error) {
+	results := make([]SearchResult, 0)
+	for i := range c.Sessions {
+		s, err := c.OpenSession(i)
+		if err != nil && !RecoveredFromBackup(err) {
+			return results, err
+		}
+		results = append(results, s.Mentioning(c, name)...)
+	}
+	return results, nil
+}
+
+// Returns the index of the entity known by the passed name or alias, ignoring case, or -1 if there is none.
+func (c *Campaign) entityIndex(name string) int {
+	name = strings.TrimSpace(name)
+	for i, e := range c.Entities {
+		for _, n := range e.Names() {
+			if strings.EqualFold(n, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Checks that an entity has a name and that none of its names are used by another entity.
+// The entity named replacing is ignored, so that an entity can be replaced by an updated version of itself.
+func (c *Campaign) validateEntity(e Entity, replacing string) error {
+	if strings.TrimSpace(e.Name) == "" {
+		return errors.New("Entities must have a name")
+	}
+	for _, name := range e.Names() {
+		i := c.entityIndex(name)
+		if i != -1 && c.Entities[i].Name != replacing {
+			return errors.New("The name " + name + " is already used by " + c.Entities[i].Name)
+		}
+	}
+	return nil
+}
+
+// The pattern matching the names of the entities of a campaign, kept so that it is only compiled when they change.
+type mentionIndex struct {
+	pattern *regexp.Regexp    // matches any name of any entity, or nil if there are no names
+	owners  map[string]string // the name of the entity owning each lowercased name
+}
+
+// Builds a pattern matching any name of any entity, longest names first so that the longest overlapping name wins.
+// Also records the name of the entity owning each lowercased name. The pattern is nil if there are no names.
+func (c *Campaign) mentionPattern() *mentionIndex {
+	owners := make(map[string]string)
+	names := make([]string, 0)
+	for _, e := range c.Entities {
+		for _, name := range e.Names() {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			owners[strings.ToLower(name)] = e.Name
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return &mentionIndex{owners: owners}
+	}
+
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	for i, name := range names {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	return &mentionIndex{pattern: regexp.MustCompile("(?i)" + strings.Join(names, "|")), owners: owners}
+}
+
+// Reports whether the byte offset i of the text does not lie between two characters of the same word.
+func isWordBoundary(text string, i int) bool {
+	if i == 0 || i == len(text) {
+		return true
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i:])
+	return !isWordRune(before) || !isWordRune(after)
+}
+
+// Reports whether the rune can be part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
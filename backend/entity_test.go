@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Entities", func() {
+	var dir string
+	var c *Campaign
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-entities")
+		Expect(err).To(BeNil())
+		c = NewCampaign("Calimport", dir)
+		c.AddEntity(Entity{Name: "Aust Redwyn", Kind: ENTITY_NPC, Aliases: []string{"Aust"}})
+		c.AddEntity(Entity{Name: "Calimport", Kind: ENTITY_LOCATION})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should find an entity by its name or any alias, ignoring case", func() {
+		e, ok := c.Entity("aust")
+		Expect(ok).To(BeTrue())
+		Expect(e.Name).To(Equal("Aust Redwyn"))
+		_, ok = c.Entity("Xenthe")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should not add entities without a name", func() {
+		Expect(c.AddEntity(Entity{Kind: ENTITY_ITEM})).ToNot(BeNil())
+	})
+
+	It("should not add entities whose names are already used", func() {
+		Expect(c.AddEntity(Entity{Name: "Someone", Aliases: []string{"AUST"}})).ToNot(BeNil())
+		Expect(c.Entities).To(HaveLen(2))
+	})
+
+	It("should update and remove entities", func() {
+		Expect(c.UpdateEntity("Calimport", Entity{Name: "Calimport", Kind: ENTITY_LOCATION, Description: "A city"})).To(BeNil())
+		e, _ := c.Entity("Calimport")
+		Expect(e.Description).To(Equal("A city"))
+		Expect(c.RemoveEntity("Calimport")).To(BeTrue())
+		Expect(c.RemoveEntity("Calimport")).To(BeFalse())
+	})
+
+	It("should find mentions of entities, preferring the longest name", func() {
+		text := "Aust Redwyn left Calimport, and aust never returned"
+		Expect(c.FindMentions(text)).To(Equal([]Mention{
+			{Entity: "Aust Redwyn", Start: 0, End: 11},
+			{Entity: "Calimport", Start: 17, End: 26},
+			{Entity: "Aust Redwyn", Start: 32, End: 36},
+		}))
+	})
+
+	It("should find mentions of entities as they change", func() {
+		text := "Xenthe sailed to Calimport"
+		Expect(c.FindMentions(text)).To(HaveLen(1))
+		Expect(c.AddEntity(Entity{Name: "Xenthe", Kind: ENTITY_NPC})).To(BeNil())
+		Expect(c.FindMentions(text)).To(HaveLen(2))
+		Expect(c.UpdateEntity("Xenthe", Entity{Name: "Xenthe the Bold", Aliases: []string{"Xenthe"}, Kind: ENTITY_NPC})).To(BeNil())
+		Expect(c.FindMentions(text)[0].Entity).To(Equal("Xenthe the Bold"))
+		Expect(c.RemoveEntity("Calimport")).To(BeTrue())
+		Expect(c.FindMentions(text)).To(HaveLen(1))
+	})
+
+	It("should only find mentions of whole words", func() {
+		Expect(c.FindMentions("Austin went to Calimporters")).To(BeEmpty())
+	})
+
+	It("should list the notes of a session mentioning an entity", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Met Aust at the docks", time.Now()))
+		s.AddNote(NewNote("Sailed to Calimport", time.Now()))
+		results := s.Mentioning(c, "Aust Redwyn")
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].NoteIndex).To(Equal(0))
+		Expect(results[0].Highlighted("[", "]")).To(Equal("Met [Aust] at the docks"))
+	})
+
+	It("should list the notes of every session of a campaign mentioning an entity", func() {
+		first, _ := c.NewSession("")
+		first.AddNote(NewNote("Sailed to Calimport", time.Now()))
+		first.Save()
+		second, _ := c.NewSession("")
+		second.AddNote(NewNote("Left Calimport behind", time.Now()))
+		second.Save()
+		results, err := MentionsInCampaign(c, "calimport")
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+	})
+
+	It("should save entities with the campaign", func() {
+		Expect(c.Save()).To(BeNil())
+		loaded, err := LoadCampaign(dir)
+		Expect(err).To(BeNil())
+		Expect(loaded.Entities).To(Equal(c.Entities))
+	})
+})
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const DEFAULT_EXPORT_TIME_FORMAT = "3:04 PM"
+const EXPORT_DATE_FORMAT = "Monday, January 2, 2006"
+
+// The characters that cannot be used in file names on some systems, replaced when naming the file of an export.
+const UNSAFE_FILE_NAME_CHARACTERS = `<>:"/\|?*`
+
+// Settings that customize how a session is exported.
+type exportSettings struct {
+	timeFormat string // the layout used to format the time of each note, as accepted by time.Format
+	darkTheme  bool   // whether exports that support themes use a dark theme
+}
+
+// An option to customize how a session is exported.
+type ExportOption func(e *exportSettings)
+
+// Option to format the time of each note with the passed layout, as accepted by time.Format.
+func WithTimeFormat(layout string) ExportOption {
+	return func(e *exportSettings) {
+		e.timeFormat = layout
+	}
+}
+
+// Builds the export settings from the default settings and the passed options.
+func newExportSettings(options []ExportOption) exportSettings {
+	settings := exportSettings{timeFormat: DEFAULT_EXPORT_TIME_FORMAT}
+	for _, option := range options {
+		option(&settings)
+	}
+	return settings
+}
+
+// Returns the heading of the session, built from its number and title.
+func (s *Session) Heading() string {
+	heading := ""
+	if s.SessionNumber > NO_SESSION_NUMBER {
+		heading = "Session " + strconv.Itoa(s.SessionNumber)
+		if s.SessionTitle != "" {
+			heading += ": "
+		}
+	}
+	heading += s.SessionTitle
+	if heading == "" {
+		heading = "Session notes"
+	}
+	return heading
+}
+
+// Returns a name for a file the session is exported to, without an extension. The name is the heading of the session,
+// with characters that cannot be used in file names replaced by underscores.
+func (s *Session) ExportFileName() string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(UNSAFE_FILE_NAME_CHARACTERS, r) {
+			return '_'
+		}
+		return r
+	}, s.Heading())
+	// names ending in a space or a dot are not allowed on Windows
+	return strings.TrimRight(name, " .")
+}
@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The version of the session file format written by this version of Archon.
+// Increase it and register a migration whenever the format changes in a way that needs one. Optional fields that older
+// versions can ignore, and that decode as their zero value from older files, do not change the version.
+const CURRENT_FORMAT_VERSION = 2
+
+// The format version of session files written before the format was versioned.
+const UNVERSIONED_FORMAT = 0
+
+// A decoded session document, keyed by field name.
+type Document map[string]interface{}
+
+// Upgrades a session document from one format version to the next by modifying it in place.
+type Migration func(doc Document) error
+
+// The migrations registered for each format version, keyed by the version they upgrade from.
+var migrations = map[int]Migration{
+	UNVERSIONED_FORMAT: migrateUnversioned,
+	1:                  migrateTags,
+}
+
+// Returned when a session file was written in a format newer than this version of Archon understands.
+type UnsupportedFormatError struct {
+	Version int // the format version of the session file
+}
+
+// Describes the unsupported version. Necessary to implement the error interface.
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf(
+		"This session was written by a newer version of Archon (format version %d, this version supports up to %d). Update Archon to open it",
+		e.Version,
+		CURRENT_FORMAT_VERSION,
+	)
+}
+
+// Registers a migration that upgrades session documents from the passed format version to the next one.
+// Registering a migration for a version replaces the previous one.
+func RegisterMigration(from int, m Migration) {
+	migrations[from] = m
+}
+
+// Upgrades a session document to the current format version, one version at a time.
+// Returns an error if the document is newer than the current version or if a migration is missing or fails.
+func migrate(doc Document) error {
+	version, err := documentVersion(doc)
+	if err != nil {
+		return err
+	}
+	if version > CURRENT_FORMAT_VERSION {
+		return &UnsupportedFormatError{Version: version}
+	}
+
+	for ; version < CURRENT_FORMAT_VERSION; version++ {
+		m, ok := migrations[version]
+		if !ok {
+			return fmt.Errorf("No migration from session format version %d to %d", version, version+1)
+		}
+		if err := m(doc); err != nil {
+			return fmt.Errorf("Could not migrate session from format version %d to %d: %w", version, version+1, err)
+		}
+		doc["FormatVersion"] = json.Number(fmt.Sprint(version + 1))
+	}
+	return nil
+}
+
+// Returns the format version of a session document. Documents without a version predate versioning.
+func documentVersion(doc Document) (int, error) {
+	raw, ok := doc["FormatVersion"]
+	if !ok || raw == nil {
+		return UNVERSIONED_FORMAT, nil
+	}
+	number, ok := raw.(json.Number)
+	if !ok {
+		return 0, fmt.Errorf("Session format version %v is not a number", raw)
+	}
+	version, err := number.Int64()
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("Session format version %v is not a valid version", raw)
+	}
+	return int(version), nil
+}
+
+// Upgrades documents written before the format was versioned. Their fields are unchanged, so only the version is added.
+func migrateUnversioned(doc Document) error {
+	return nil
+}
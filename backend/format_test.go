@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session file format", func() {
+	It("should serialize the current format version", func() {
+		s := NewSession("The Conquest at Calimport", 0)
+		Expect(s.ToJSON()).To(ContainSubstring("\"FormatVersion\":" + strconv.Itoa(CURRENT_FORMAT_VERSION)))
+	})
+
+	It("should migrate sessions written before the format was versioned", func() {
+		data := `{"Notes":[{"Content":"Test string","Time":"2021-07-15T14:38:04.732366749-04:00"}],"Date":"2021-07-15T14:38:04.732366058-04:00","SessionTitle":"Test session","SessionNumber":2}`
+		s, err := FromJSON(data)
+		Expect(err).To(BeNil())
+		Expect(s.FormatVersion).To(Equal(CURRENT_FORMAT_VERSION))
+		Expect(s.SessionTitle).To(Equal("Test session"))
+		Expect(s.Notes[0].Content).To(Equal("Test string"))
+	})
+
+	It("should refuse sessions written in a newer format version", func() {
+		data := `{"FormatVersion":` + strconv.Itoa(CURRENT_FORMAT_VERSION+1) + `,"Notes":[],"SessionTitle":"From the future"}`
+		_, err := FromJSON(data)
+		Expect(err).To(BeAssignableToTypeOf(&UnsupportedFormatError{}))
+		Expect(err.Error()).To(ContainSubstring("newer version of Archon"))
+	})
+
+	It("should refuse sessions with an invalid format version", func() {
+		_, err := FromJSON(`{"FormatVersion":"one","Notes":[]}`)
+		Expect(err).ToNot(BeNil())
+		_, err = FromJSON(`{"FormatVersion":-1,"Notes":[]}`)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should apply every migration between the document version and the current one", func() {
+		applied := make([]int, 0)
+		for from := UNVERSIONED_FORMAT; from < CURRENT_FORMAT_VERSION; from++ {
+			from := from
+			previous := migrations[from]
+			defer RegisterMigration(from, previous)
+			RegisterMigration(from, func(doc Document) error {
+				applied = append(applied, from)
+				return previous(doc)
+			})
+		}
+
+		_, err := FromJSON(`{"Notes":[],"SessionTitle":"Old"}`)
+		Expect(err).To(BeNil())
+		Expect(applied).To(HaveLen(CURRENT_FORMAT_VERSION - UNVERSIONED_FORMAT))
+	})
+
+	It("should not replace a file written in a newer format version with a backup", func() {
+		dir, _ := os.MkdirTemp("", "archon-format")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "session.json")
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		s.Save()
+		s.Save()
+		os.WriteFile(path, []byte(`{"FormatVersion":`+strconv.Itoa(CURRENT_FORMAT_VERSION+1)+`}`), 0600)
+
+		_, err := Load(path)
+		Expect(err).To(BeAssignableToTypeOf(&UnsupportedFormatError{}))
+	})
+})
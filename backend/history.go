@@ -0,0 +1,335 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Returned by a Command that left the session unchanged. Such commands are not recorded in the history.
+var ErrNoChange = errors.New("Command made no change to the session")
+
+// A reversible change to a session.
+type Command interface {
+	Do(s *Session) error   // applies the change to the session
+	Undo(s *Session) error // reverts the change, restoring the session to how it was before Do
+}
+
+// Records the commands applied to a session so they can be undone and redone.
+type History struct {
+	session *Session  // the session the commands are applied to
+	undone  []Command // commands that can be redone, most recently undone last
+	done    []Command // commands that can be undone, most recently done last
+}
+
+// Create a new, empty History for the passed session.
+func NewHistory(s *Session) *History {
+	return &History{
+		session: s,
+		undone:  make([]Command, 0),
+		done:    make([]Command, 0),
+	}
+}
+
+// Applies a command to the session and records it so that it can be undone. The session is marked dirty.
+// Returns ErrNoChange, recording nothing, if the command left the session unchanged.
+// The session is locked while the command is applied, so that it is never autosaved half changed.
+// Applying a new command discards every command that could have been redone.
+func (h *History) Execute(c Command) error {
+	h.session.lock.Lock()
+	defer h.session.lock.Unlock()
+	if err := c.Do(h.session); err != nil {
+		return err
+	}
+	h.done = append(h.done, c)
+	h.undone = h.undone[:0]
+	h.session.changes++
+	return nil
+}
+
+// Reverts the most recently applied command. Does nothing if there is nothing to undo.
+func (h *History) Undo() error {
+	if !h.CanUndo() {
+		return nil
+	}
+	h.session.lock.Lock()
+	defer h.session.lock.Unlock()
+	c := h.done[len(h.done)-1]
+	if err := c.Undo(h.session); err != nil {
+		return err
+	}
+	h.done = h.done[:len(h.done)-1]
+	h.undone = append(h.undone, c)
+	h.session.changes++
+	return nil
+}
+
+// Reapplies the most recently undone command. Does nothing if there is nothing to redo.
+func (h *History) Redo() error {
+	if !h.CanRedo() {
+		return nil
+	}
+	h.session.lock.Lock()
+	defer h.session.lock.Unlock()
+	c := h.undone[len(h.undone)-1]
+	if err := c.Do(h.session); err != nil {
+		return err
+	}
+	h.undone = h.undone[:len(h.undone)-1]
+	h.done = append(h.done, c)
+	h.session.changes++
+	return nil
+}
+
+// Reports whether there is a command that can be undone.
+func (h *History) CanUndo() bool {
+	return len(h.done) > 0
+}
+
+// Reports whether there is a command that can be redone.
+func (h *History) CanRedo() bool {
+	return len(h.undone) > 0
+}
+
+// Adds a note to the end of a session.
+type AddNoteCommand struct {
+	Note Note // the note to add
+}
+
+// Adds the note. Empty notes are not added.
+func (c *AddNoteCommand) Do(s *Session) error {
+	before := len(s.Notes)
+	s.AddNote(c.Note)
+	if len(s.Notes) == before {
+		return ErrNoChange
+	}
+	return nil
+}
+
+// Removes the added note.
+func (c *AddNoteCommand) Undo(s *Session) error {
+	return s.DeleteNote(len(s.Notes) - 1)
+}
+
+// Replaces the content of a note in a session.
+type UpdateNoteCommand struct {
+	Index    int       // the index of the note to update
+	Content  string    // the new content of the note
+	Time     time.Time // the time at which the note was edited
+	previous Note      // the note as it was before the update
+}
+
+// Updates the note, remembering how it was before.
+func (c *UpdateNoteCommand) Do(s *Session) error {
+	if err := s.checkNoteIndex(c.Index); err != nil {
+		return err
+	}
+	previous := s.Notes[c.Index]
+	if err := s.UpdateNote(c.Index, c.Content, c.Time); err != nil {
+		return err
+	}
+	c.previous = previous
+	return nil
+}
+
+// Restores the note as it was before the update.
+func (c *UpdateNoteCommand) Undo(s *Session) error {
+	if err := s.checkNoteIndex(c.Index); err != nil {
+		return err
+	}
+	s.Notes[c.Index] = c.previous
+	return nil
+}
+
+// Removes a note from a session.
+type DeleteNoteCommand struct {
+	Index   int  // the index of the note to delete
+	deleted Note // the note that was deleted
+}
+
+// Deletes the note, remembering it so that it can be restored.
+func (c *DeleteNoteCommand) Do(s *Session) error {
+	if err := s.checkNoteIndex(c.Index); err != nil {
+		return err
+	}
+	c.deleted = s.Notes[c.Index]
+	return s.DeleteNote(c.Index)
+}
+
+// Puts the deleted note back where it was.
+func (c *DeleteNoteCommand) Undo(s *Session) error {
+	return s.InsertNote(c.Index, c.deleted)
+}
+
+// Moves a note to a different position in a session.
+type MoveNoteCommand struct {
+	From int // the index of the note to move
+	To   int // the index the note is moved to
+}
+
+// Moves the note.
+func (c *MoveNoteCommand) Do(s *Session) error {
+	if c.From == c.To {
+		return ErrNoChange
+	}
+	return s.MoveNote(c.From, c.To)
+}
+
+// Moves the note back to where it was.
+func (c *MoveNoteCommand) Undo(s *Session) error {
+	return s.MoveNote(c.To, c.From)
+}
+
+// Changes the title of a session.
+type SetTitleCommand struct {
+	Title    string // the new title of the session
+	previous string // the title of the session before the change
+}
+
+// Changes the title, remembering the previous one.
+func (c *SetTitleCommand) Do(s *Session) error {
+	if s.SessionTitle == c.Title {
+		return ErrNoChange
+	}
+	c.previous = s.SessionTitle
+	s.SessionTitle = c.Title
+	return nil
+}
+
+// Restores the previous title.
+func (c *SetTitleCommand) Undo(s *Session) error {
+	s.SessionTitle = c.previous
+	return nil
+}
+
+// Changes the number of a session.
+type SetNumberCommand struct {
+	Number   int // the new number of the session
+	previous int // the number of the session before the change
+}
+
+// Changes the number, remembering the previous one.
+func (c *SetNumberCommand) Do(s *Session) error {
+	if s.SessionNumber == c.Number {
+		return ErrNoChange
+	}
+	if c.Number < NO_SESSION_NUMBER {
+		return errors.New("Session numbers cannot be less than 0")
+	}
+	c.previous = s.SessionNumber
+	s.SessionNumber = c.Number
+	return nil
+}
+
+// Restores the previous number.
+func (c *SetNumberCommand) Undo(s *Session) error {
+	s.SessionNumber = c.previous
+	return nil
+}
+
+// Changes the title and number of a session together, so that both are undone at once.
+type SetSessionInfoCommand struct {
+	Title          string // the new title of the session
+	Number         int    // the new number of the session
+	previousTitle  string // the title of the session before the change
+	previousNumber int    // the number of the session before the change
+}
+
+// Changes the title and number, remembering the previous ones.
+func (c *SetSessionInfoCommand) Do(s *Session) error {
+	if s.SessionTitle == c.Title && s.SessionNumber == c.Number {
+		return ErrNoChange
+	}
+	if c.Number < NO_SESSION_NUMBER {
+		return errors.New("Session numbers cannot be less than 0")
+	}
+	c.previousTitle, c.previousNumber = s.SessionTitle, s.SessionNumber
+	s.SessionTitle, s.SessionNumber = c.Title, c.Number
+	return nil
+}
+
+// Restores the previous title and number.
+func (c *SetSessionInfoCommand) Undo(s *Session) error {
+	s.SessionTitle, s.SessionNumber = c.previousTitle, c.previousNumber
+	return nil
+}
+
+// Changes the kind of a note in a session.
+type SetKindCommand struct {
+	Index    int      // the index of the note to change
+	Kind     NoteKind // the new kind of the note
+	previous NoteKind // the kind of the note before the change
+}
+
+// Changes the kind, remembering the previous one.
+func (c *SetKindCommand) Do(s *Session) error {
+	if err := s.checkNoteIndex(c.Index); err != nil {
+		return err
+	}
+	if s.Notes[c.Index].KindOrDefault() == c.Kind {
+		return ErrNoChange
+	}
+	previous := s.Notes[c.Index].Kind
+	if err := s.SetNoteKind(c.Index, c.Kind); err != nil {
+		return err
+	}
+	c.previous = previous
+	return nil
+}
+
+// Restores the previous kind.
+func (c *SetKindCommand) Undo(s *Session) error {
+	if err := s.checkNoteIndex(c.Index); err != nil {
+		return err
+	}
+	s.Notes[c.Index].Kind = c.previous
+	return nil
+}
+
+// Sets or stops the in-game clock of a session.
+type SetClockCommand struct {
+	Clock    *GameTime // the new in-game time, or nil to stop tracking in-game time
+	previous *GameTime // the in-game time before the change
+}
+
+// Changes the clock, remembering the previous time.
+func (c *SetClockCommand) Do(s *Session) error {
+	if (s.Clock == nil && c.Clock == nil) || (s.Clock != nil && c.Clock != nil && *s.Clock == *c.Clock) {
+		return ErrNoChange
+	}
+	c.previous = s.Clock
+	s.Clock = c.Clock
+	return nil
+}
+
+// Restores the previous time.
+func (c *SetClockCommand) Undo(s *Session) error {
+	s.Clock = c.previous
+	return nil
+}
+
+// Changes the calendar a session writes in-game dates in.
+type SetCalendarCommand struct {
+	Calendar string // the name of the new calendar
+	previous string // the name of the calendar before the change
+}
+
+// Changes the calendar, remembering the previous one. Returns an error if there is no calendar with the name.
+func (c *SetCalendarCommand) Do(s *Session) error {
+	calendar, ok := CalendarNamed(c.Calendar)
+	if !ok {
+		return errors.New("Unknown calendar " + c.Calendar + ", choose one of " + strings.Join(CalendarNames(), ", "))
+	}
+	if s.GameCalendar().Name == calendar.Name {
+		return ErrNoChange
+	}
+	c.previous = s.Calendar
+	s.Calendar = calendar.Name
+	return nil
+}
+
+// Restores the previous calendar.
+func (c *SetCalendarCommand) Undo(s *Session) error {
+	s.Calendar = c.previous
+	return nil
+}
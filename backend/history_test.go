@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var s *Session
+	var h *History
+
+	BeforeEach(func() {
+		s = NewSession("The Conquest at Calimport", 3)
+		h = s.History()
+	})
+
+	It("should have nothing to undo or redo when created", func() {
+		Expect(h.CanUndo()).To(BeFalse())
+		Expect(h.CanRedo()).To(BeFalse())
+	})
+
+	It("should undo and redo adding a note", func() {
+		h.Execute(&AddNoteCommand{Note: NewNote("Xenthe almost died", time.Now())})
+		Expect(s.Notes).To(HaveLen(1))
+
+		Expect(h.Undo()).To(BeNil())
+		Expect(s.Notes).To(BeEmpty())
+
+		Expect(h.Redo()).To(BeNil())
+		Expect(s.Notes).To(HaveLen(1))
+		Expect(s.Notes[0].Content).To(Equal("Xenthe almost died"))
+	})
+
+	It("should not record adding an empty note", func() {
+		Expect(h.Execute(&AddNoteCommand{Note: NewNote("", time.Now())})).To(Equal(ErrNoChange))
+		Expect(h.CanUndo()).To(BeFalse())
+	})
+
+	It("should mark the session dirty when a change is done, undone, or redone", func() {
+		h.Execute(&SetTitleCommand{Title: "The Fall of Calimport"})
+		Expect(s.IsDirty()).To(BeTrue())
+
+		// as if the session was saved
+		s.saved = s.changes
+		h.Undo()
+		Expect(s.IsDirty()).To(BeTrue())
+		s.saved = s.changes
+		h.Redo()
+		Expect(s.IsDirty()).To(BeTrue())
+	})
+
+	It("should undo editing a note", func() {
+		h.Execute(&AddNoteCommand{Note: NewNote("Xenthe almsot died", time.Now())})
+		h.Execute(&UpdateNoteCommand{Index: 0, Content: "Xenthe almost died", Time: time.Now()})
+		Expect(s.Notes[0].Content).To(Equal("Xenthe almost died"))
+
+		h.Undo()
+		Expect(s.Notes[0].Content).To(Equal("Xenthe almsot died"))
+		Expect(s.Notes[0].IsEdited()).To(BeFalse())
+	})
+
+	It("should undo deleting a note by restoring it in place", func() {
+		h.Execute(&AddNoteCommand{Note: NewNote("Arrived in Calimport", time.Now())})
+		h.Execute(&AddNoteCommand{Note: NewNote("Xenthe almost died", time.Now())})
+		h.Execute(&AddNoteCommand{Note: NewNote("Aust Redwyn returned", time.Now())})
+		h.Execute(&DeleteNoteCommand{Index: 1})
+		Expect(s.Notes).To(HaveLen(2))
+
+		h.Undo()
+		Expect(s.Notes).To(HaveLen(3))
+		Expect(s.Notes[1].Content).To(Equal("Xenthe almost died"))
+	})
+
+	It("should undo reordering notes", func() {
+		h.Execute(&AddNoteCommand{Note: NewNote("Arrived in Calimport", time.Now())})
+		h.Execute(&AddNoteCommand{Note: NewNote("Xenthe almost died", time.Now())})
+		h.Execute(&MoveNoteCommand{From: 1, To: 0})
+		Expect(s.Notes[0].Content).To(Equal("Xenthe almost died"))
+
+		h.Undo()
+		Expect(s.Notes[0].Content).To(Equal("Arrived in Calimport"))
+	})
+
+	It("should undo changing the session title and number", func() {
+		h.Execute(&SetTitleCommand{Title: "Reunion in the Face of Adversity"})
+		h.Execute(&SetNumberCommand{Number: 9})
+		Expect(s.SessionTitle).To(Equal("Reunion in the Face of Adversity"))
+		Expect(s.SessionNumber).To(Equal(9))
+
+		h.Undo()
+		Expect(s.SessionNumber).To(Equal(3))
+		h.Undo()
+		Expect(s.SessionTitle).To(Equal("The Conquest at Calimport"))
+	})
+
+	It("should undo changing the session title and number together in one step", func() {
+		Expect(h.Execute(&SetSessionInfoCommand{Title: "Reunion in the Face of Adversity", Number: 9})).To(BeNil())
+		Expect(s.SessionTitle).To(Equal("Reunion in the Face of Adversity"))
+		Expect(s.SessionNumber).To(Equal(9))
+
+		h.Undo()
+		Expect(s.SessionTitle).To(Equal("The Conquest at Calimport"))
+		Expect(s.SessionNumber).To(Equal(3))
+		Expect(h.CanUndo()).To(BeFalse())
+		Expect(h.Execute(&SetSessionInfoCommand{Title: "The Conquest at Calimport", Number: 3})).To(Equal(ErrNoChange))
+		Expect(h.CanUndo()).To(BeFalse())
+	})
+
+	It("should undo changing the kind of a note", func() {
+		s.AddNote(NewNote("Initiative!", time.Now()))
+		Expect(h.Execute(&SetKindCommand{Index: 0, Kind: KIND_COMBAT})).To(BeNil())
+		Expect(s.Notes[0].Kind).To(Equal(KIND_COMBAT))
+		h.Undo()
+		Expect(s.Notes[0].KindOrDefault()).To(Equal(KIND_NARRATIVE))
+	})
+
+	It("should not record commands that fail", func() {
+		Expect(h.Execute(&DeleteNoteCommand{Index: 0})).ToNot(BeNil())
+		Expect(h.Execute(&SetNumberCommand{Number: -5})).ToNot(BeNil())
+		Expect(h.CanUndo()).To(BeFalse())
+	})
+
+	It("should discard undone commands when a new command is executed", func() {
+		h.Execute(&AddNoteCommand{Note: NewNote("Arrived in Calimport", time.Now())})
+		h.Undo()
+		Expect(h.CanRedo()).To(BeTrue())
+
+		h.Execute(&AddNoteCommand{Note: NewNote("Xenthe almost died", time.Now())})
+		Expect(h.CanRedo()).To(BeFalse())
+	})
+
+	It("should do nothing when there is nothing to undo or redo", func() {
+		Expect(h.Undo()).To(BeNil())
+		Expect(h.Redo()).To(BeNil())
+	})
+})
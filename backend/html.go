@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"html/template"
+	"strings"
+)
+
+// The stylesheet embedded in exported HTML. Notes are laid out as a table that prints cleanly on paper.
+const HTML_EXPORT_CSS = `
+body { font-family: Georgia, "Times New Roman", serif; max-width: 50em; margin: 2em auto; padding: 0 1em; }
+body.light { background: #ffffff; color: #222222; }
+body.dark { background: #1e1e1e; color: #dddddd; }
+h1 { margin-bottom: 0.2em; }
+.date { font-style: italic; margin-top: 0; opacity: 0.7; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; vertical-align: top; padding: 0.4em 0.6em; border-bottom: 1px solid rgba(128, 128, 128, 0.4); }
+td.time { white-space: nowrap; opacity: 0.7; font-size: 0.9em; }
+td.content { white-space: pre-wrap; }
+td.break { font-style: italic; opacity: 0.7; }
+tr.scene th { padding-top: 1.2em; font-size: 1.2em; }
+@media print {
+	body, body.dark { background: #ffffff; color: #000000; margin: 0; max-width: none; }
+	tr { page-break-inside: avoid; }
+}
+`
+
+// The template exported HTML is built from.
+var htmlExportTemplate = template.Must(template.New("session").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Heading}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body class="{{.Theme}}">
+<h1>{{.Heading}}</h1>
+<p class="date">{{.Date}}</p>
+<table>
+<thead><tr><th>Time</th><th>Note</th></tr></thead>
+<tbody>
+{{- range .Notes}}
+{{- if .Scene}}
+<tr class="scene"><th colspan="2">{{.Content}}</th></tr>
+{{- else}}
+<tr><td class="time">{{.Time}}</td><td class="content{{if .Break}} break{{end}}">{{.Content}}</td></tr>
+{{- end}}
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
+
+// A note as it is shown in exported HTML.
+type htmlNote struct {
+	Time    string // the formatted time the note was taken
+	Content string // the content of the note
+	Scene   bool   // whether the note starts a scene, shown as a heading row
+	Break   bool   // whether the note marks a break
+}
+
+// The data the HTML export template is filled with.
+type htmlDocument struct {
+	Heading string       // the heading of the session
+	Date    string       // the formatted date of the session
+	Theme   string       // the class selecting the light or dark theme
+	CSS     template.CSS // the embedded stylesheet
+	Notes   []htmlNote   // every note of the session
+}
+
+// Option to export HTML with light text on a dark background. Printing always uses a light background.
+func WithDarkTheme() ExportOption {
+	return func(e *exportSettings) {
+		e.darkTheme = true
+	}
+}
+
+// Returns a self-contained HTML page presenting the session, suitable for reading in a browser or printing.
+// The page embeds its stylesheet and lists every note in a table alongside its time.
+func (s *Session) ToHTML(options ...ExportOption) (string, error) {
+	settings := newExportSettings(options)
+	doc := htmlDocument{
+		Heading: s.Heading(),
+		Date:    s.Date.Format(EXPORT_DATE_FORMAT),
+		Theme:   "light",
+		CSS:     template.CSS(HTML_EXPORT_CSS),
+		Notes:   make([]htmlNote, 0, len(s.Notes)),
+	}
+	if settings.darkTheme {
+		doc.Theme = "dark"
+	}
+	for _, note := range s.Notes {
+		doc.Notes = append(doc.Notes, htmlNote{
+			Time:    note.Time.Format(settings.timeFormat),
+			Content: note.Content,
+			Scene:   note.Marker == MARKER_SCENE,
+			Break:   note.Marker == MARKER_BREAK,
+		})
+	}
+
+	builder := new(strings.Builder)
+	if err := htmlExportTemplate.Execute(builder, doc); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
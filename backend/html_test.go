@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HTML export", func() {
+	var s *Session
+
+	BeforeEach(func() {
+		date := time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC)
+		s = NewSession("The Conquest at Calimport", 3, withCustomDate(date))
+		s.AddNote(NewNote("Xenthe almost died", date.Add(30*time.Minute)))
+	})
+
+	It("should produce a complete page with an embedded stylesheet", func() {
+		page, err := s.ToHTML()
+		Expect(err).To(BeNil())
+		Expect(page).To(HavePrefix("<!DOCTYPE html>"))
+		Expect(page).To(ContainSubstring("<style>"))
+		Expect(page).To(ContainSubstring("@media print"))
+		Expect(page).ToNot(ContainSubstring("<link"))
+	})
+
+	It("should include the heading and date of the session", func() {
+		page, _ := s.ToHTML()
+		Expect(page).To(ContainSubstring("<h1>Session 3: The Conquest at Calimport</h1>"))
+		Expect(page).To(ContainSubstring("Tuesday, June 22, 2021"))
+	})
+
+	It("should list every note in a table alongside its time", func() {
+		page, _ := s.ToHTML(WithTimeFormat("15:04"))
+		Expect(page).To(ContainSubstring(`<td class="time">15:30</td><td class="content">Xenthe almost died</td>`))
+	})
+
+	It("should escape the content of notes", func() {
+		s.AddNote(NewNote("<script>alert('boo')</script>", time.Now()))
+		page, _ := s.ToHTML()
+		Expect(page).ToNot(ContainSubstring("<script>"))
+		Expect(page).To(ContainSubstring("&lt;script&gt;"))
+	})
+
+	It("should use a light theme unless a dark theme is requested", func() {
+		page, _ := s.ToHTML()
+		Expect(page).To(ContainSubstring(`<body class="light">`))
+		page, _ = s.ToHTML(WithDarkTheme())
+		Expect(page).To(ContainSubstring(`<body class="dark">`))
+	})
+})
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const JOURNAL_DIR_NAME = "Archon"
+
+// The name of the single journal kept by earlier versions, which is still recovered.
+const JOURNAL_FILE_NAME = "recovery.journal"
+
+// Matches the names of every journal, each numbered journal kept for a session open in a tab as well as the single journal kept by earlier versions.
+const JOURNAL_FILE_PATTERN = "recovery*.journal"
+
+// An append-only file of session snapshots, used to recover sessions that were never saved to a file.
+// Each snapshot is a single line of JSON, so a snapshot cut short by a crash does not spoil the earlier ones.
+type Journal struct {
+	Path string // the path of the journal file
+}
+
+// Create a journal stored at the passed path.
+func NewJournal(path string) *Journal {
+	return &Journal{Path: path}
+}
+
+// Returns the directory within the user's configuration directory that recovery journals are kept in.
+func DefaultJournalDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, JOURNAL_DIR_NAME), nil
+}
+
+// Returns the path of the journal with the passed number in the passed directory.
+// Each session open at the same time is saved to a journal with a different number.
+func JournalPath(dir string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("recovery-%d.journal", n))
+}
+
+// Returns every journal in the passed directory that holds snapshots to recover, in order of their names.
+func FindJournals(dir string) ([]*Journal, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, JOURNAL_FILE_PATTERN))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	journals := make([]*Journal, 0, len(paths))
+	for _, path := range paths {
+		if journal := NewJournal(path); journal.Exists() {
+			journals = append(journals, journal)
+		}
+	}
+	return journals, nil
+}
+
+// Appends a snapshot of the session to the journal, creating the journal if it does not exist.
+func (j *Journal) Append(s *Session) error {
+	UserRWX := fs.FileMode(0700)
+	UserRW := fs.FileMode(0600)
+	if err := os.MkdirAll(filepath.Dir(j.Path), UserRWX); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(j.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, UserRW)
+	if err != nil {
+		return err
+	}
+	// ToJSON produces a single line terminated by a newline
+	if _, err := file.WriteString(s.ToJSON()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Reports whether the journal holds any snapshots to recover.
+func (j *Journal) Exists() bool {
+	info, err := os.Stat(j.Path)
+	return err == nil && info.Size() > 0
+}
+
+// Returns the session from the most recent complete snapshot in the journal. The session is dirty, as it was never saved to a file.
+// In the case of an error, or if there is no complete snapshot, returns an empty session and an error.
+func (j *Journal) Recover() (*Session, error) {
+	file, err := os.Open(j.Path)
+	if err != nil {
+		return &Session{}, err
+	}
+	defer file.Close()
+
+	var latest *Session
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		// a snapshot without a trailing newline was interrupted while being written
+		if err != nil {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if s, err := FromJSON(line); err == nil {
+			latest = s
+		}
+	}
+
+	if latest == nil {
+		return &Session{}, errors.New("The recovery journal " + j.Path + " holds no complete session")
+	}
+	// the recovered changes were never saved
+	latest.MarkDirty()
+	return latest, nil
+}
+
+// Removes every snapshot from the journal.
+func (j *Journal) Clear() error {
+	err := os.Remove(j.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recovery journal", func() {
+	var dir string
+	var journal *Journal
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-journal")
+		Expect(err).To(BeNil())
+		journal = NewJournal(filepath.Join(dir, "nested", JOURNAL_FILE_NAME))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should not exist before anything is appended", func() {
+		Expect(journal.Exists()).To(BeFalse())
+	})
+
+	It("should recover the most recent snapshot", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		Expect(journal.Append(s)).To(BeNil())
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		Expect(journal.Append(s)).To(BeNil())
+		Expect(journal.Exists()).To(BeTrue())
+
+		recovered, err := journal.Recover()
+		Expect(err).To(BeNil())
+		Expect(recovered.SessionTitle).To(Equal("The Conquest at Calimport"))
+		Expect(recovered.Notes).To(HaveLen(2))
+		Expect(recovered.IsDirty()).To(BeTrue())
+	})
+
+	It("should ignore a snapshot that was cut short", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		journal.Append(s)
+		file, _ := os.OpenFile(journal.Path, os.O_APPEND|os.O_WRONLY, 0600)
+		file.WriteString(`{"Notes":[{"Content":"Xenthe al`)
+		file.Close()
+
+		recovered, err := journal.Recover()
+		Expect(err).To(BeNil())
+		Expect(recovered.Notes).To(HaveLen(1))
+	})
+
+	It("should return an error when there is no complete snapshot", func() {
+		os.MkdirAll(filepath.Dir(journal.Path), 0700)
+		os.WriteFile(journal.Path, []byte(`{"Notes":[`), 0600)
+		_, err := journal.Recover()
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should find every journal holding snapshots", func() {
+		dir := filepath.Dir(journal.Path)
+		journal.Append(NewSession("The Conquest at Calimport", 3))
+		numbered := NewJournal(JournalPath(dir, 2))
+		numbered.Append(NewSession("The Fall of Calimport", 4))
+		empty := NewJournal(JournalPath(dir, 3))
+		empty.Append(NewSession("The Road to Calimport", 2))
+		empty.Clear()
+
+		journals, err := FindJournals(dir)
+		Expect(err).To(BeNil())
+		Expect(journals).To(HaveLen(2))
+		Expect(journals[0].Path).To(Equal(numbered.Path))
+		Expect(journals[1].Path).To(Equal(journal.Path))
+	})
+
+	It("should be empty after being cleared", func() {
+		journal.Append(NewSession("The Conquest at Calimport", 3))
+		Expect(journal.Clear()).To(BeNil())
+		Expect(journal.Exists()).To(BeFalse())
+		Expect(journal.Clear()).To(BeNil())
+	})
+})
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+)
+
+// The category of a note, such as an in-character quote or a rules ruling.
+type NoteKind string
+
+const (
+	KIND_NARRATIVE NoteKind = "narrative"
+	KIND_DIALOGUE  NoteKind = "dialogue"
+	KIND_COMBAT    NoteKind = "combat"
+	KIND_LOOT      NoteKind = "loot"
+	KIND_RULE      NoteKind = "rule"
+	KIND_OOC       NoteKind = "ooc"
+)
+
+// Separates the kind prefix of a note, like "loot: 50 gold", from its content.
+const KIND_PREFIX_SEPARATOR = ":"
+
+// Every kind of note, in the order they are offered to the user.
+var NOTE_KINDS = []NoteKind{KIND_NARRATIVE, KIND_DIALOGUE, KIND_COMBAT, KIND_LOOT, KIND_RULE, KIND_OOC}
+
+// The prefixes that set the kind of a note when it is written, besides the name of each kind.
+var KIND_PREFIX_ALIASES = map[string]NoteKind{
+	"say":   KIND_DIALOGUE,
+	"fight": KIND_COMBAT,
+	"rules": KIND_RULE,
+}
+
+// Reports whether the kind is one of the known kinds of note.
+func (k NoteKind) Valid() bool {
+	for _, kind := range NOTE_KINDS {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the kind of the note. Notes written without a kind are narrative.
+func (n Note) KindOrDefault() NoteKind {
+	if n.Kind == "" {
+		return KIND_NARRATIVE
+	}
+	return n.Kind
+}
+
+// Splits a kind prefix, like "loot:" or "OOC:", from the start of the content of a note.
+// Returns the kind and the content without its prefix, or false and the content unchanged if there is no prefix.
+func ParseKindPrefix(content string) (NoteKind, string, bool) {
+	i := strings.Index(content, KIND_PREFIX_SEPARATOR)
+	if i == -1 {
+		return "", content, false
+	}
+	prefix := strings.ToLower(strings.TrimSpace(content[:i]))
+	rest := content[i+len(KIND_PREFIX_SEPARATOR):]
+	kind := NoteKind(prefix)
+	if alias, ok := KIND_PREFIX_ALIASES[prefix]; ok {
+		kind = alias
+	}
+	if !kind.Valid() {
+		return "", content, false
+	}
+	return kind, strings.TrimSpace(rest), true
+}
+
+// Changes the kind of the note at index i. Returns an error if there is no such note or the kind is unknown.
+func (s *Session) SetNoteKind(i int, kind NoteKind) error {
+	if err := s.checkNoteIndex(i); err != nil {
+		return err
+	}
+	if !kind.Valid() {
+		return errors.New("Unknown kind of note " + string(kind))
+	}
+	s.Notes[i].Kind = kind
+	return nil
+}
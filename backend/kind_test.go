@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Note kinds", func() {
+	It("should treat notes without a kind as narrative", func() {
+		Expect(NewNote("Arrived in Calimport", time.Now()).KindOrDefault()).To(Equal(KIND_NARRATIVE))
+	})
+
+	It("should split a kind prefix from the content of a note", func() {
+		kind, content, ok := ParseKindPrefix("Loot: 50 gold and a wand")
+		Expect(ok).To(BeTrue())
+		Expect(kind).To(Equal(KIND_LOOT))
+		Expect(content).To(Equal("50 gold and a wand"))
+	})
+
+	It("should accept aliases of kinds as prefixes", func() {
+		kind, content, _ := ParseKindPrefix("say: \"You shall not pass\"")
+		Expect(kind).To(Equal(KIND_DIALOGUE))
+		Expect(content).To(Equal("\"You shall not pass\""))
+	})
+
+	It("should leave content without a kind prefix unchanged", func() {
+		_, content, ok := ParseKindPrefix("Note to self: buy rope")
+		Expect(ok).To(BeFalse())
+		Expect(content).To(Equal("Note to self: buy rope"))
+	})
+
+	It("should not set unknown kinds", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		Expect(s.SetNoteKind(0, NoteKind("gossip"))).ToNot(BeNil())
+		Expect(s.SetNoteKind(0, KIND_OOC)).To(BeNil())
+		Expect(s.Notes[0].Kind).To(Equal(KIND_OOC))
+	})
+
+	It("should serialize the kind of a note", func() {
+		s := NewSession("Test", 1)
+		note := NewNote("Initiative!", time.Now())
+		note.Kind = KIND_COMBAT
+		s.AddNote(note)
+		s2, _ := FromJSON(s.ToJSON())
+		Expect(s2.Notes[0].Kind).To(Equal(KIND_COMBAT))
+	})
+})
@@ -0,0 +1,465 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sessions at least this many bytes long are large enough that loading them reports progress.
+const LARGE_SESSION_SIZE = 1 << 20
+
+// How many bytes are read between reports of the progress of loading a session.
+const PROGRESS_INTERVAL = 64 << 10
+
+// Called with the number of bytes of a session read so far and the total size of the session, or 0 if it is not known.
+type LoadProgress func(read int64, total int64)
+
+// Settings that customize how a session is loaded.
+type loadSettings struct {
+	path     string       // the path of the file the session is read from, if it is read from a file
+	size     int64        // the size of the session in bytes, or 0 if it is not known
+	progress LoadProgress // called as the session is read, if set
+}
+
+// An option to customize how a session is loaded.
+type LoadOption func(l *loadSettings)
+
+// Option to report the progress of loading the session to the passed function, every PROGRESS_INTERVAL bytes and once all of it is read.
+func WithProgress(progress LoadProgress) LoadOption {
+	return func(l *loadSettings) {
+		l.progress = progress
+	}
+}
+
+// Option to give the path of the file the session is read from, so that errors can name it.
+func WithPath(path string) LoadOption {
+	return func(l *loadSettings) {
+		l.path = path
+	}
+}
+
+// Option to give the size of the session in bytes, so that progress can be reported as a fraction of it.
+func WithSize(size int64) LoadOption {
+	return func(l *loadSettings) {
+		l.size = size
+	}
+}
+
+// Returned when a session could not be read because it is not valid JSON or a field does not hold the data it should.
+// Says where in the file the problem is, as far as it is known.
+type LoadError struct {
+	Path   string // the path of the file the session was read from, or empty if it was not read from a file
+	Offset int64  // the number of bytes read before the problem, or -1 if the problem is not at a single place in the file
+	Line   int    // the line of the problem, starting at 1, or 0 if it is not known
+	Column int    // the column of the problem in bytes, starting at 1, or 0 if it is not known
+	Field  string // the field that holds the wrong kind of data, like "Notes.3.Time", or empty if the file is not valid JSON
+	Err    error  // the error encountered decoding the session
+}
+
+// Describes where the problem is and what it is. Necessary to implement the error interface.
+func (e *LoadError) Error() string {
+	name := "the session"
+	if e.Path != "" {
+		name = filepath.Base(e.Path)
+	}
+	text := "Could not read " + name
+	if e.Line > 0 {
+		text += fmt.Sprintf(" at line %d, column %d (byte %d)", e.Line, e.Column, e.Offset)
+	}
+	if e.Field != "" {
+		text += ", field " + e.Field
+	}
+	return text + ": " + e.Err.Error()
+}
+
+// Returns the error encountered decoding the session.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// Counts the bytes and lines read from a reader, reporting the progress of loading a session as they are read.
+type progressReader struct {
+	reader   io.Reader    // the reader the session is read from
+	read     int64        // the number of bytes read so far
+	reported int64        // the number of bytes read when progress was last reported
+	newlines []int64      // the offset of every newline read so far, in order
+	settings loadSettings // the settings holding the size of the session and the function progress is reported to
+}
+
+// Reads from the underlying reader, reporting progress every PROGRESS_INTERVAL bytes and at the end. Necessary to implement the io.Reader interface.
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.reader.Read(p)
+	for i, start := 0, 0; ; start += i + 1 {
+		i = bytes.IndexByte(p[start:n], '\n')
+		if i == -1 {
+			break
+		}
+		pr.newlines = append(pr.newlines, pr.read+int64(start+i))
+	}
+	pr.read += int64(n)
+	if pr.settings.progress != nil && (pr.read-pr.reported >= PROGRESS_INTERVAL || (err == io.EOF && pr.read != pr.reported)) {
+		pr.reported = pr.read
+		pr.settings.progress(pr.read, pr.settings.size)
+	}
+	return n, err
+}
+
+// Returns the line and column of the byte at the passed offset, both starting at 1.
+func (pr *progressReader) position(offset int64) (int, int) {
+	line := sort.Search(len(pr.newlines), func(i int) bool {
+		return pr.newlines[i] >= offset
+	})
+	lineStart := int64(0)
+	if line > 0 {
+		lineStart = pr.newlines[line-1] + 1
+	}
+	return line + 1, int(offset-lineStart) + 1
+}
+
+// Describes an error decoding the session, saying where in the input it is if that is known.
+func (pr *progressReader) loadError(err error) *LoadError {
+	loadErr := &LoadError{Path: pr.settings.path, Offset: -1, Err: err}
+	var syntax *json.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		// the offset is just past the byte that could not be read
+		loadErr.Offset = syntax.Offset - 1
+	case errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF):
+		loadErr.Offset = pr.read
+	}
+	if loadErr.Offset >= 0 {
+		loadErr.Line, loadErr.Column = pr.position(loadErr.Offset)
+	}
+	return loadErr
+}
+
+// Builds a session from the JSON read from the reader, however large it is. The JSON is decoded as it is read, one note at a time,
+// and sessions in the current format version are decoded straight into a session. Only sessions written in an older format version
+// keep their notes as they were read, so that they can be migrated to the current one as a Document.
+// If the JSON is invalid, returns a pointer to an empty Session and a LoadError saying where the problem is.
+// If it was written in a newer format version, returns a pointer to an empty Session and an UnsupportedFormatError.
+func ReadSession(r io.Reader, options ...LoadOption) (*Session, error) {
+	settings := loadSettings{}
+	for _, option := range options {
+		option(&settings)
+	}
+	reader := &progressReader{reader: r, settings: settings}
+	sr := &sessionReader{reader: reader, decoder: json.NewDecoder(reader), fields: make(map[string]rawValue)}
+	if err := sr.read(); err != nil {
+		return &Session{}, err
+	}
+	// whatever follows the session, like its final newline, is read so that progress is reported until every byte is read
+	io.Copy(io.Discard, reader)
+	version, err := sr.version()
+	if err != nil {
+		return &Session{}, err
+	}
+	if version > CURRENT_FORMAT_VERSION {
+		return &Session{}, &UnsupportedFormatError{Version: version}
+	}
+
+	var session *Session
+	if version == CURRENT_FORMAT_VERSION {
+		session, err = sr.decode()
+	} else {
+		session, err = sr.migrate()
+	}
+	if err != nil {
+		return &Session{}, err
+	}
+
+	// rectify invalid data modified externally outside of the application
+	if session.SessionNumber < NO_SESSION_NUMBER {
+		session.SessionNumber = NO_SESSION_NUMBER
+	}
+	return session, nil
+}
+
+// A JSON value read from a session, along with where it starts.
+type rawValue struct {
+	data   json.RawMessage // the value as it was read
+	offset int64           // the number of bytes read before the value
+}
+
+// Reads the fields of a session one by one, and its notes one at a time.
+type sessionReader struct {
+	reader   *progressReader     // the reader the session is read from, which knows where problems are
+	decoder  *json.Decoder       // decodes the JSON as it is read
+	fields   map[string]rawValue // every field of the session but its notes, keyed by name
+	hasNotes bool                // whether the session has a field holding its notes
+	decoded  bool                // whether the notes were decoded as they were read, as the session was known to be in the current format version
+	notes    []Note              // the notes decoded as they were read
+	rawNotes []rawValue          // the notes as they were read, if they were not decoded
+}
+
+// Reads the session object, keeping its fields as they were read. Notes are decoded as they are read if the session
+// is known to be in the current format version by then, which it is whenever Archon wrote it.
+func (sr *sessionReader) read() error {
+	if err := sr.expect(json.Delim('{')); err != nil {
+		return err
+	}
+	for sr.decoder.More() {
+		token, err := sr.decoder.Token()
+		if err != nil {
+			return sr.reader.loadError(err)
+		}
+		// the keys of an object are always strings
+		key := token.(string)
+		if key == "Notes" {
+			if err := sr.readNotes(); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := sr.readValue()
+		if err != nil {
+			return err
+		}
+		sr.fields[key] = value
+	}
+	return sr.expect(json.Delim('}'))
+}
+
+// Reads the array of notes, decoding each note as it is read if the session is known to be in the current format version.
+func (sr *sessionReader) readNotes() error {
+	offset := sr.decoder.InputOffset()
+	token, err := sr.decoder.Token()
+	if err != nil {
+		return sr.reader.loadError(err)
+	}
+	sr.hasNotes = true
+	if token == nil {
+		return nil
+	}
+	if token != json.Delim('[') {
+		return sr.fieldError(errors.New("Notes must be a list"), "Notes", "", offset, offset)
+	}
+	version, err := sr.version()
+	sr.decoded = err == nil && version == CURRENT_FORMAT_VERSION
+	sr.notes = make([]Note, 0)
+	sr.rawNotes = make([]rawValue, 0)
+	for sr.decoder.More() {
+		value, err := sr.readValue()
+		if err != nil {
+			return err
+		}
+		if !sr.decoded {
+			sr.rawNotes = append(sr.rawNotes, value)
+			continue
+		}
+		if err := sr.decodeNote(value); err != nil {
+			return err
+		}
+	}
+	return sr.expect(json.Delim(']'))
+}
+
+// Decodes a note read from the session and adds it to the notes.
+func (sr *sessionReader) decodeNote(value rawValue) error {
+	note := Note{}
+	if err := json.Unmarshal(value.data, &note); err != nil {
+		field := fmt.Sprintf("Notes.%d", len(sr.notes))
+		return sr.fieldError(err, field, field+".", value.offset, value.offset)
+	}
+	sr.notes = append(sr.notes, note)
+	return nil
+}
+
+// Reads the next value of the session without decoding it.
+func (sr *sessionReader) readValue() (rawValue, error) {
+	var data json.RawMessage
+	if err := sr.decoder.Decode(&data); err != nil {
+		return rawValue{}, sr.reader.loadError(err)
+	}
+	return rawValue{data: data, offset: sr.decoder.InputOffset() - int64(len(data))}, nil
+}
+
+// Reads the next token of the session, which must be the passed delimiter.
+func (sr *sessionReader) expect(delim json.Delim) error {
+	offset := sr.decoder.InputOffset()
+	token, err := sr.decoder.Token()
+	if err != nil {
+		return sr.reader.loadError(err)
+	}
+	if token != delim {
+		return &LoadError{Path: sr.reader.settings.path, Offset: offset, Err: fmt.Errorf("Expected %v but found %v", delim, token)}
+	}
+	return nil
+}
+
+// Returns the format version of the session from the fields read so far.
+func (sr *sessionReader) version() (int, error) {
+	value, ok := sr.fields["FormatVersion"]
+	if !ok {
+		return documentVersion(Document{})
+	}
+	version, err := decodeDocumentValue(value.data)
+	if err != nil {
+		return 0, err
+	}
+	return documentVersion(Document{"FormatVersion": version})
+}
+
+// Decodes a session in the current format version from the fields read.
+func (sr *sessionReader) decode() (*Session, error) {
+	session := Session{}
+	keys := make([]string, 0, len(sr.fields))
+	for key := range sr.fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := sr.fields[key]
+		// each field is decoded on its own so that a field holding the wrong kind of data is named
+		name, _ := json.Marshal(key)
+		object := make([]byte, 0, len(name)+len(value.data)+3)
+		object = append(append(append(append(object, '{'), name...), ':'), value.data...)
+		object = append(object, '}')
+		if err := json.Unmarshal(object, &session); err != nil {
+			return nil, sr.fieldError(err, key, "", value.offset, value.offset-int64(len(name)+2))
+		}
+	}
+	if !sr.hasNotes {
+		return &session, nil
+	}
+	// notes read before the format version was are decoded now
+	if !sr.decoded {
+		for _, value := range sr.rawNotes {
+			if err := sr.decodeNote(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	session.Notes = sr.notes
+	return &session, nil
+}
+
+// Builds a session written in an older format version from the fields read, by decoding them as a Document,
+// migrating the document to the current format version and decoding that.
+func (sr *sessionReader) migrate() (*Session, error) {
+	doc := Document{}
+	for key, value := range sr.fields {
+		decoded, err := decodeDocumentValue(value.data)
+		if err != nil {
+			return nil, sr.reader.loadError(err)
+		}
+		doc[key] = decoded
+	}
+	if sr.hasNotes {
+		var notes []interface{}
+		if sr.rawNotes != nil {
+			notes = make([]interface{}, 0, len(sr.rawNotes))
+		}
+		for _, value := range sr.rawNotes {
+			decoded, err := decodeDocumentValue(value.data)
+			if err != nil {
+				return nil, sr.reader.loadError(err)
+			}
+			notes = append(notes, decoded)
+		}
+		doc["Notes"] = notes
+	}
+	if err := migrate(doc); err != nil {
+		return nil, err
+	}
+
+	// the migrated document is re-encoded so that it can be decoded into a session
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	session := Session{}
+	if err := json.Unmarshal(migrated, &session); err != nil {
+		// the document is valid JSON, so the problem is a field holding the wrong kind of data.
+		// Migrating may have changed the field, so the problem is placed at the start of the field or note as it was read.
+		loadErr := &LoadError{
+			Path:  sr.reader.settings.path,
+			Field: invalidField("", doc, func() interface{} { return &Session{} }),
+			Err:   err,
+		}
+		loadErr.Offset = sr.offsetOf(loadErr.Field)
+		if loadErr.Offset >= 0 {
+			loadErr.Line, loadErr.Column = sr.reader.position(loadErr.Offset)
+		}
+		return nil, loadErr
+	}
+	return &session, nil
+}
+
+// Describes a field of the session that holds the wrong kind of data. Its value starts after start bytes, and the offset
+// of a problem the error places within the JSON it was decoded from counts from origin. A field within it is named after
+// the passed prefix if the error says which, otherwise the field is named.
+func (sr *sessionReader) fieldError(err error, field string, prefix string, start int64, origin int64) *LoadError {
+	loadErr := &LoadError{Path: sr.reader.settings.path, Offset: start, Field: field, Err: err}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field != "" {
+			loadErr.Field = prefix + typeErr.Field
+		}
+		// the offset is just past the value of the wrong kind
+		loadErr.Offset = origin + typeErr.Offset - 1
+	}
+	loadErr.Line, loadErr.Column = sr.reader.position(loadErr.Offset)
+	return loadErr
+}
+
+// Returns the number of bytes read before the field or note with the passed path, like "Notes.3.Time" or "SessionTitle",
+// or -1 if it was not read.
+func (sr *sessionReader) offsetOf(field string) int64 {
+	parts := strings.SplitN(field, ".", 3)
+	if parts[0] == "Notes" && len(parts) > 1 {
+		if i, err := strconv.Atoi(parts[1]); err == nil && i >= 0 && i < len(sr.rawNotes) {
+			return sr.rawNotes[i].offset
+		}
+	}
+	if value, ok := sr.fields[parts[0]]; ok {
+		return value.offset
+	}
+	return -1
+}
+
+// Decodes a JSON value the way it is held in a Document, keeping numbers as they were written.
+func decodeDocumentValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	return value, err
+}
+
+// Returns the path of the first field of the decoded value that cannot be decoded into a new target, like "Notes.3.Time",
+// or an empty string if every field can be. Fields of notes are checked one by one, so that the note at fault is named.
+func invalidField(prefix string, value map[string]interface{}, newTarget func() interface{}) string {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		data, err := json.Marshal(map[string]interface{}{key: value[key]})
+		if err == nil && json.Unmarshal(data, newTarget()) == nil {
+			continue
+		}
+		field := prefix + key
+		if notes, ok := value[key].([]interface{}); ok && key == "Notes" {
+			for i, note := range notes {
+				if fields, ok := note.(map[string]interface{}); ok {
+					if invalid := invalidField(fmt.Sprintf("%s.%d.", field, i), fields, func() interface{} { return &Note{} }); invalid != "" {
+						return invalid
+					}
+				} else {
+					return fmt.Sprintf("%s.%d", field, i)
+				}
+			}
+		}
+		return field
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing/iotest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session loader", func() {
+	var large *Session
+
+	BeforeEach(func() {
+		large = NewSession("The Long Campaign", 12)
+		for i := 0; i < 20000; i++ {
+			large.AddNote(NewNote("The party walks for another mile, note "+strconv.Itoa(i), time.Now()))
+		}
+	})
+
+	It("should read sessions of any size", func() {
+		data := large.ToJSON()
+		Expect(len(data)).To(BeNumerically(">", LARGE_SESSION_SIZE))
+		s, err := ReadSession(strings.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Notes).To(HaveLen(20000))
+		Expect(s.Notes[19999].Content).To(HaveSuffix("note 19999"))
+	})
+
+	It("should read sessions from readers that return a little at a time", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		loaded, err := ReadSession(iotest.OneByteReader(strings.NewReader(s.ToJSON())))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.Notes).To(HaveLen(1))
+	})
+
+	It("should read sessions whose format version follows their notes", func() {
+		data := `{"Notes":[{"Content":"Xenthe almost died","Time":"2021-06-22T15:00:00Z"}],"SessionTitle":"Test","FormatVersion":` + strconv.Itoa(CURRENT_FORMAT_VERSION) + `}`
+		s, err := ReadSession(strings.NewReader(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.SessionTitle).To(Equal("Test"))
+		Expect(s.Notes).To(HaveLen(1))
+		Expect(s.Notes[0].Content).To(Equal("Xenthe almost died"))
+	})
+
+	It("should report progress until every byte is read", func() {
+		data := large.ToJSON()
+		reports := 0
+		var lastRead, lastTotal int64
+		_, err := ReadSession(strings.NewReader(data), WithSize(int64(len(data))), WithProgress(func(read int64, total int64) {
+			Expect(read).To(BeNumerically(">", lastRead))
+			reports++
+			lastRead, lastTotal = read, total
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reports).To(BeNumerically(">", 1))
+		Expect(lastRead).To(Equal(int64(len(data))))
+		Expect(lastTotal).To(Equal(int64(len(data))))
+	})
+
+	It("should report progress when loading a file", func() {
+		dir, err := os.MkdirTemp("", "archon-loader")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		large.Path = filepath.Join(dir, "session.json")
+		Expect(large.Save()).To(Succeed())
+
+		var lastTotal int64
+		s, err := Load(large.Path, WithProgress(func(read int64, total int64) {
+			lastTotal = total
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.Notes).To(HaveLen(20000))
+		Expect(lastTotal).To(Equal(int64(len(large.ToJSON()))))
+	})
+})
+
+var _ = Describe("Session load errors", func() {
+	It("should say where in the file the JSON is invalid", func() {
+		data := "{\n  \"SessionTitle\": \"Broken\",\n  \"Notes\": [}\n"
+		_, err := ReadSession(strings.NewReader(data), WithPath("/tmp/broken.json"))
+		var loadErr *LoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Path).To(Equal("/tmp/broken.json"))
+		Expect(loadErr.Offset).To(Equal(int64(strings.Index(data, "}"))))
+		Expect(loadErr.Line).To(Equal(3))
+		Expect(loadErr.Column).To(Equal(13))
+		Expect(loadErr.Error()).To(HavePrefix("Could not read broken.json at line 3, column 13"))
+	})
+
+	It("should say where the file ends too soon", func() {
+		_, err := ReadSession(strings.NewReader("{\"SessionTitle\": \"Bro"))
+		var loadErr *LoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Line).To(Equal(1))
+		Expect(loadErr.Offset).To(Equal(int64(21)))
+	})
+
+	It("should name the field that holds the wrong kind of data", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.AddNote(NewNote("Xenthe died", time.Now()))
+		data := strings.Replace(s.ToJSON(), `"Content":"Xenthe died"`, `"Content":42`, 1)
+		_, err := ReadSession(strings.NewReader(data))
+		var loadErr *LoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Field).To(Equal("Notes.1.Content"))
+		// the offset of the last byte of the number, which is also its column less one as the session is on one line
+		last := strings.Index(data, `"Content":42`) + len(`"Content":42`) - 1
+		Expect(loadErr.Offset).To(Equal(int64(last)))
+		Expect(loadErr.Line).To(Equal(1))
+		Expect(loadErr.Column).To(Equal(last + 1))
+		Expect(loadErr.Error()).To(ContainSubstring("field Notes.1.Content"))
+	})
+
+	It("should say where a field of the session holds the wrong kind of data", func() {
+		data := "{\n  \"FormatVersion\": " + strconv.Itoa(CURRENT_FORMAT_VERSION) + ",\n  \"SessionNumber\": \"three\"\n}\n"
+		_, err := ReadSession(strings.NewReader(data))
+		var loadErr *LoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Field).To(Equal("SessionNumber"))
+		Expect(loadErr.Offset).To(Equal(int64(strings.Index(data, `"three"`) + len(`"three"`) - 1)))
+		Expect(loadErr.Line).To(Equal(3))
+	})
+
+	It("should say where a note of an older session holds the wrong kind of data", func() {
+		data := "{\"FormatVersion\": 1,\n\"Notes\": [\n{\"Content\": 42}\n]}"
+		_, err := ReadSession(strings.NewReader(data))
+		var loadErr *LoadError
+		Expect(errors.As(err, &loadErr)).To(BeTrue())
+		Expect(loadErr.Field).To(Equal("Notes.0.Content"))
+		Expect(loadErr.Offset).To(Equal(int64(strings.Index(data, "{\"Content"))))
+		Expect(loadErr.Line).To(Equal(3))
+		Expect(loadErr.Column).To(Equal(1))
+	})
+
+	It("should still report files from newer versions as unsupported", func() {
+		_, err := ReadSession(strings.NewReader(`{"FormatVersion": 9999}`))
+		Expect(err).To(BeAssignableToTypeOf(&UnsupportedFormatError{}))
+	})
+})
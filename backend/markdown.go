@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"strings"
+)
+
+// Returns a Markdown representation of the session, suitable for posting as a recap.
+// The session heading is followed by the date of the session and a bullet for every note, prefixed by its time.
+// Every scene starts with a heading of its title, and breaks are written in italics.
+func (s *Session) ToMarkdown(options ...ExportOption) string {
+	settings := newExportSettings(options)
+	builder := new(strings.Builder)
+
+	builder.WriteString("# " + s.Heading() + "\n\n")
+	builder.WriteString("*" + s.Date.Format(EXPORT_DATE_FORMAT) + "*\n\n")
+
+	for _, note := range s.Notes {
+		switch note.Marker {
+		case MARKER_SCENE:
+			builder.WriteString("\n## " + note.Content + "\n\n")
+			continue
+		case MARKER_BREAK:
+			builder.WriteString("- **" + note.Time.Format(settings.timeFormat) + "** *" + note.Content + "*\n")
+			continue
+		}
+		// continuation lines are indented so that multi-line notes stay within their bullet
+		content := strings.ReplaceAll(note.Content, "\n", "\n  ")
+		builder.WriteString("- **" + note.Time.Format(settings.timeFormat) + "** " + content + "\n")
+	}
+	return builder.String()
+}
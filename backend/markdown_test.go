@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Markdown export", func() {
+	var s *Session
+
+	BeforeEach(func() {
+		date := time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC)
+		s = NewSession("The Conquest at Calimport", 3, withCustomDate(date))
+		s.AddNote(NewNote("Xenthe almost died", date.Add(30*time.Minute)))
+	})
+
+	It("should start with a heading built from the session number and title", func() {
+		Expect(s.ToMarkdown()).To(HavePrefix("# Session 3: The Conquest at Calimport\n"))
+	})
+
+	It("should use only the title or number when the other is missing", func() {
+		s.SessionNumber = NO_SESSION_NUMBER
+		Expect(s.Heading()).To(Equal("The Conquest at Calimport"))
+		s.SessionNumber = 3
+		s.SessionTitle = ""
+		Expect(s.Heading()).To(Equal("Session 3"))
+	})
+
+	It("should name export files after the heading without characters file names cannot hold", func() {
+		Expect(s.ExportFileName()).To(Equal("Session 3_ The Conquest at Calimport"))
+		s.SessionTitle = "Into the Underdark / Part 2?\t..."
+		Expect(s.ExportFileName()).To(Equal("Session 3_ Into the Underdark _ Part 2__"))
+	})
+
+	It("should include the date of the session", func() {
+		Expect(s.ToMarkdown()).To(ContainSubstring("*Tuesday, June 22, 2021*"))
+	})
+
+	It("should list every note as a timestamped bullet", func() {
+		Expect(s.ToMarkdown()).To(ContainSubstring("- **3:30 PM** Xenthe almost died\n"))
+	})
+
+	It("should format note times with a custom layout", func() {
+		Expect(s.ToMarkdown(WithTimeFormat("15:04"))).To(ContainSubstring("- **15:30** Xenthe almost died\n"))
+	})
+
+	It("should keep multi-line notes within their bullet", func() {
+		s.AddNote(NewNote("First line\nSecond line", time.Date(2021, time.June, 22, 16, 0, 0, 0, time.UTC)))
+		Expect(s.ToMarkdown()).To(ContainSubstring("- **4:00 PM** First line\n  Second line\n"))
+	})
+})
@@ -6,8 +6,14 @@ import (
 
 // Represents an entry into the session log made by the user.
 type Note struct {
-	Content string    // the contents of the note as input by a user
-	Time    time.Time // the time at which the note was created
+	Content  string     // the contents of the note as input by a user
+	Time     time.Time  // the time at which the note was created
+	Edited   *time.Time `json:",omitempty"` // the time at which the note was last edited, if it has been
+	Tags     []string   `json:",omitempty"` // the hashtags in the content, lowercased and without their prefix
+	Kind     NoteKind   `json:",omitempty"` // the category of the note, narrative if empty
+	Rolls    []Roll     `json:",omitempty"` // the dice rolled in the note, in the order written
+	GameTime *GameTime  `json:",omitempty"` // the in-game time at which the note was taken, if the session was tracking it
+	Marker   Marker     `json:",omitempty"` // whether the note starts a scene or marks a break, empty for ordinary notes
 }
 
 // Create a new Note.
@@ -15,6 +21,12 @@ func NewNote(content string, currentTime time.Time) Note {
 	note := Note{
 		Content: content,
 		Time:    currentTime,
+		Tags:    ParseTags(content),
 	}
 	return note
 }
+
+// Reports whether the note has been edited since it was created.
+func (n Note) IsEdited() bool {
+	return n.Edited != nil
+}
@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// The most session files remembered as recently opened or saved.
+const MAX_RECENT_SESSIONS = 10
+
+// The session files most recently opened or saved, most recent first.
+type RecentSessions struct {
+	paths []string // the paths of the session files, most recent first
+}
+
+// Builds the list of recent sessions from its stored form, one path per line, as written by String.
+// Blank lines and repeated paths are ignored, and only the first MAX_RECENT_SESSIONS paths are kept.
+func ParseRecentSessions(text string) *RecentSessions {
+	r := &RecentSessions{paths: make([]string, 0)}
+	lines := strings.Split(text, "\n")
+	// added from the oldest so that the most recent ends up first
+	for i := len(lines) - 1; i >= 0; i-- {
+		if path := strings.TrimSpace(lines[i]); path != "" {
+			r.Add(path)
+		}
+	}
+	return r
+}
+
+// Returns the stored form of the list, one path per line, most recent first.
+func (r *RecentSessions) String() string {
+	return strings.Join(r.paths, "\n")
+}
+
+// Records that the session file at the passed path was opened or saved, moving it to the front of the list.
+// The oldest path is forgotten if the list grows longer than MAX_RECENT_SESSIONS.
+func (r *RecentSessions) Add(path string) {
+	path = filepath.Clean(path)
+	r.Remove(path)
+	r.paths = append([]string{path}, r.paths...)
+	if len(r.paths) > MAX_RECENT_SESSIONS {
+		r.paths = r.paths[:MAX_RECENT_SESSIONS]
+	}
+}
+
+// Forgets the session file at the passed path, if it is in the list.
+func (r *RecentSessions) Remove(path string) {
+	path = filepath.Clean(path)
+	for i, recent := range r.paths {
+		if recent == path {
+			r.paths = append(r.paths[:i], r.paths[i+1:]...)
+			return
+		}
+	}
+}
+
+// Returns the paths of the session files, most recent first.
+func (r *RecentSessions) Paths() []string {
+	return append([]string(nil), r.paths...)
+}
+
+// Returns the path of the session file opened or saved most recently, and whether there is one.
+func (r *RecentSessions) Last() (string, bool) {
+	if len(r.paths) == 0 {
+		return "", false
+	}
+	return r.paths[0], true
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recent sessions", func() {
+	It("should keep the most recent session first", func() {
+		r := ParseRecentSessions("")
+		r.Add("/notes/one.json")
+		r.Add("/notes/two.json")
+		r.Add("/notes/one.json")
+		Expect(r.Paths()).To(Equal([]string{"/notes/one.json", "/notes/two.json"}))
+		last, ok := r.Last()
+		Expect(ok).To(BeTrue())
+		Expect(last).To(Equal("/notes/one.json"))
+	})
+
+	It("should have no last session when empty", func() {
+		_, ok := ParseRecentSessions("\n\n").Last()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should forget the oldest sessions", func() {
+		r := ParseRecentSessions("")
+		for i := 0; i < MAX_RECENT_SESSIONS+3; i++ {
+			r.Add("/notes/" + strconv.Itoa(i) + ".json")
+		}
+		Expect(r.Paths()).To(HaveLen(MAX_RECENT_SESSIONS))
+		Expect(r.Paths()[0]).To(Equal("/notes/" + strconv.Itoa(MAX_RECENT_SESSIONS+2) + ".json"))
+	})
+
+	It("should be stored and parsed in the same order", func() {
+		r := ParseRecentSessions("")
+		r.Add("/notes/one.json")
+		r.Add("/notes/../notes/two.json")
+		parsed := ParseRecentSessions(r.String())
+		Expect(parsed.Paths()).To(Equal([]string{"/notes/two.json", "/notes/one.json"}))
+		parsed.Remove("/notes/two.json")
+		Expect(parsed.Paths()).To(Equal([]string{"/notes/one.json"}))
+	})
+})
@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marks a note as a division of the session rather than something that happened in it.
+type Marker string
+
+const (
+	MARKER_SCENE Marker = "scene" // starts a new scene, titled by the content of the note
+	MARKER_BREAK Marker = "break" // marks a pause in play, until the next note
+)
+
+// The content of a break marker.
+const BREAK_CONTENT = "Break"
+
+// The title of the scene made of the notes taken before the first scene marker.
+const UNTITLED_SCENE = "Before the first scene"
+
+// Creates a marker starting a new scene with the passed title. Untitled scenes are numbered after the scenes already in the session.
+func (s *Session) NewSceneMarker(title string, currentTime time.Time) Note {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "Scene " + strconv.Itoa(len(s.SceneMarkers())+1)
+	}
+	note := NewNote(title, currentTime)
+	note.Marker = MARKER_SCENE
+	return note
+}
+
+// Creates a marker for a pause in play.
+func NewBreakMarker(currentTime time.Time) Note {
+	note := NewNote(BREAK_CONTENT, currentTime)
+	note.Marker = MARKER_BREAK
+	return note
+}
+
+// Reports whether the note divides the session rather than recording something that happened.
+func (n Note) IsMarker() bool {
+	return n.Marker != ""
+}
+
+// Returns the indexes of the notes that start scenes, in order.
+func (s *Session) SceneMarkers() []int {
+	markers := make([]int, 0)
+	for i, note := range s.Notes {
+		if note.Marker == MARKER_SCENE {
+			markers = append(markers, i)
+		}
+	}
+	return markers
+}
+
+// A scene of a session and the notes taken during it.
+type Scene struct {
+	Title     string        // the title of the scene
+	Marker    int           // the index of the note starting the scene, or -1 for the notes before the first scene
+	Notes     []int         // the indexes of the notes taken during the scene, including breaks but not the scene marker
+	Began     time.Time     // when the scene began
+	Ended     time.Time     // when the next scene began, or when the last note of the scene was taken
+	BreakTime time.Duration // how long play was paused during the scene
+}
+
+// How long the scene was played for, not counting breaks.
+func (sc Scene) Duration() time.Duration {
+	duration := sc.Ended.Sub(sc.Began) - sc.BreakTime
+	if duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+// Groups the notes of the session by scene, in order. Notes taken before the first scene are grouped into an untitled scene.
+// A break lasts from its marker until the next note, and does not count towards the duration of its scene.
+func (s *Session) Timeline() []Scene {
+	scenes := make([]Scene, 0)
+	var current *Scene
+	for i, note := range s.Notes {
+		if note.Marker == MARKER_SCENE {
+			if current != nil {
+				current.Ended = note.Time
+			}
+			scenes = append(scenes, Scene{Title: note.Content, Marker: i, Notes: make([]int, 0), Began: note.Time, Ended: note.Time})
+			current = &scenes[len(scenes)-1]
+			continue
+		}
+		if current == nil {
+			scenes = append(scenes, Scene{Title: UNTITLED_SCENE, Marker: -1, Notes: make([]int, 0), Began: note.Time})
+			current = &scenes[len(scenes)-1]
+		}
+		current.Notes = append(current.Notes, i)
+		current.Ended = note.Time
+		if note.Marker == MARKER_BREAK && i+1 < len(s.Notes) {
+			current.BreakTime += s.Notes[i+1].Time.Sub(note.Time)
+		}
+	}
+	return scenes
+}
+
+// Formats a duration in hours and minutes, like "1h 05m" or "42m".
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return strconv.Itoa(minutes) + "m"
+	}
+	text := strconv.Itoa(minutes/60) + "h "
+	if minutes%60 < 10 {
+		text += "0"
+	}
+	return text + strconv.Itoa(minutes%60) + "m"
+}
+
+// Starts a new scene titled by the arguments.
+func runScene(call SlashCall) error {
+	s := call.Session
+	return s.History().Execute(&AddNoteCommand{Note: s.NewSceneMarker(strings.Join(call.Args, " "), time.Now())})
+}
+
+// Marks a pause in play.
+func runBreak(call SlashCall) error {
+	return call.Session.History().Execute(&AddNoteCommand{Note: NewBreakMarker(time.Now())})
+}
@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scenes", func() {
+	var s *Session
+	var start time.Time
+
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+
+	BeforeEach(func() {
+		s = NewSession("Test", 1)
+		start = time.Date(2021, 6, 12, 18, 0, 0, 0, time.UTC)
+		s.AddNote(NewNote("The party meets in the tavern", at(0)))
+		s.AddNote(s.NewSceneMarker("The road north", at(10)))
+		s.AddNote(NewNote("Wolves attack", at(20)))
+		s.AddNote(NewBreakMarker(at(30)))
+		s.AddNote(NewNote("The wolves flee", at(45)))
+		s.AddNote(s.NewSceneMarker("", at(70)))
+		s.AddNote(NewNote("The gates of Neverwinter", at(75)))
+	})
+
+	It("should number untitled scenes", func() {
+		Expect(s.Notes[5].Content).To(Equal("Scene 2"))
+		Expect(s.Notes[5].IsMarker()).To(BeTrue())
+		Expect(s.Notes[0].IsMarker()).To(BeFalse())
+		Expect(s.SceneMarkers()).To(Equal([]int{1, 5}))
+	})
+
+	It("should group notes by scene", func() {
+		timeline := s.Timeline()
+		Expect(timeline).To(HaveLen(3))
+		Expect(timeline[0].Title).To(Equal(UNTITLED_SCENE))
+		Expect(timeline[0].Marker).To(Equal(-1))
+		Expect(timeline[0].Notes).To(Equal([]int{0}))
+		Expect(timeline[1].Title).To(Equal("The road north"))
+		Expect(timeline[1].Notes).To(Equal([]int{2, 3, 4}))
+		Expect(timeline[2].Notes).To(Equal([]int{6}))
+	})
+
+	It("should compute durations without breaks", func() {
+		timeline := s.Timeline()
+		Expect(timeline[0].Duration()).To(Equal(10 * time.Minute))
+		Expect(timeline[1].BreakTime).To(Equal(15 * time.Minute))
+		Expect(timeline[1].Duration()).To(Equal(45 * time.Minute))
+		Expect(timeline[2].Duration()).To(Equal(5 * time.Minute))
+	})
+
+	It("should format durations in hours and minutes", func() {
+		Expect(FormatDuration(42 * time.Minute)).To(Equal("42m"))
+		Expect(FormatDuration(65 * time.Minute)).To(Equal("1h 05m"))
+	})
+
+	It("should start scenes and breaks with slash commands that can be undone", func() {
+		registry := NewCommandRegistry(DefaultSlashCommands(NewRoller())...)
+		Expect(registry.Run(s, "/scene Into the crypt", "")).To(Succeed())
+		Expect(s.Notes[len(s.Notes)-1].Marker).To(Equal(MARKER_SCENE))
+		Expect(s.Notes[len(s.Notes)-1].Content).To(Equal("Into the crypt"))
+		Expect(registry.Run(s, "/break", "")).To(Succeed())
+		Expect(s.Notes[len(s.Notes)-1].Marker).To(Equal(MARKER_BREAK))
+		s.History().Undo()
+		s.History().Undo()
+		Expect(s.Notes).To(HaveLen(7))
+	})
+
+	It("should export scenes as headings", func() {
+		markdown := s.ToMarkdown()
+		Expect(markdown).To(ContainSubstring("\n## The road north\n"))
+		Expect(markdown).To(ContainSubstring("*Break*"))
+		html, err := s.ToHTML()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.Count(html, `<tr class="scene">`)).To(Equal(2))
+	})
+})
@@ -0,0 +1,227 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const SNIPPET_RADIUS = 40
+const SNIPPET_ELLIPSIS = "..."
+
+// A parsed search query. Every term and quoted phrase in the query must appear in a note for it to match.
+// Matching is case-insensitive.
+type Query struct {
+	patterns []*regexp.Regexp // one pattern per term or phrase
+}
+
+// Represents a note that matched a search query.
+type SearchResult struct {
+	Session    *Session // the session containing the matching note
+	NoteIndex  int      // the index of the matching note within the session's notes
+	Snippet    string   // an excerpt of the note surrounding the matches
+	Highlights [][]int  // the [start, end) byte offsets within Snippet of every match
+}
+
+// Parses a search query. Words separated by whitespace are matched independently,
+// while words surrounded by double quotes are matched as a single phrase.
+func ParseQuery(query string) Query {
+	q := Query{patterns: make([]*regexp.Regexp, 0)}
+	for i, part := range strings.Split(query, "\"") {
+		// every odd part was surrounded by quotes
+		if i%2 == 1 {
+			if words := strings.Fields(part); len(words) > 0 {
+				q.addPattern(words...)
+			}
+			continue
+		}
+		for _, word := range strings.Fields(part) {
+			q.addPattern(word)
+		}
+	}
+	return q
+}
+
+// Reports whether the query has no terms. An empty query matches nothing.
+func (q Query) Empty() bool {
+	return len(q.patterns) == 0
+}
+
+// Reports whether the passed text contains every term of the query.
+func (q Query) Matches(text string) bool {
+	if q.Empty() {
+		return false
+	}
+	for _, pattern := range q.patterns {
+		if !pattern.MatchString(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the sorted [start, end) byte offsets of every match of the query within the passed text.
+func (q Query) matchIndexes(text string) [][]int {
+	matches := make([][]int, 0)
+	for _, pattern := range q.patterns {
+		matches = append(matches, pattern.FindAllStringIndex(text, -1)...)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i][0] < matches[j][0]
+	})
+	return matches
+}
+
+// Adds a pattern that matches the passed words in order, separated by any whitespace.
+func (q *Query) addPattern(words ...string) {
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	q.patterns = append(q.patterns, regexp.MustCompile("(?i)"+strings.Join(words, `\s+`)))
+}
+
+// Returns the snippet with every highlighted match surrounded by the passed markers.
+func (r SearchResult) Highlighted(open string, close string) string {
+	builder := new(strings.Builder)
+	last := 0
+	for _, h := range r.Highlights {
+		if h[0] < last {
+			continue
+		}
+		builder.WriteString(r.Snippet[last:h[0]])
+		builder.WriteString(open)
+		builder.WriteString(r.Snippet[h[0]:h[1]])
+		builder.WriteString(close)
+		last = h[1]
+	}
+	builder.WriteString(r.Snippet[last:])
+	return builder.String()
+}
+
+// Returns the index of the matching note within the passed session, which may be another copy of the session searched
+// with changes of its own. The note is found by its content and the time it was taken, or by the time alone if it has
+// since been edited. Returns -1 if the note is not in the session.
+func (r SearchResult) IndexIn(s *Session) int {
+	if s == r.Session {
+		return r.NoteIndex
+	}
+	if r.NoteIndex < 0 || r.NoteIndex >= len(r.Session.Notes) {
+		return -1
+	}
+	match := r.Session.Notes[r.NoteIndex]
+	sameTime := -1
+	for i, note := range s.Notes {
+		if !note.Time.Equal(match.Time) {
+			continue
+		}
+		if note.Content == match.Content {
+			return i
+		}
+		if sameTime == -1 {
+			sameTime = i
+		}
+	}
+	return sameTime
+}
+
+// Returns every note in this session that matches the query, in the order the notes were taken.
+func (s *Session) Search(query string) []SearchResult {
+	return s.searchQuery(ParseQuery(query))
+}
+
+// Searches every session of a campaign. Results are ordered by session, then by note.
+func SearchCampaign(c *Campaign, query string) ([]SearchResult, error) {
+	q := ParseQuery(query)
+	results := make([]SearchResult, 0)
+	for i := range c.Sessions {
+		s, err := c.OpenSession(i)
+		if err != nil && !RecoveredFromBackup(err) {
+			return results, err
+		}
+		results = append(results, s.searchQuery(q)...)
+	}
+	return results, nil
+}
+
+// Searches every session file in a directory. Files that cannot be loaded as a session are skipped.
+func SearchDir(dir string, query string) ([]SearchResult, error) {
+	q := ParseQuery(query)
+	results := make([]SearchResult, 0)
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return results, err
+	}
+	for _, path := range paths {
+		if filepath.Base(path) == CAMPAIGN_MANIFEST_NAME {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		s, err := Load(path)
+		if err != nil && !RecoveredFromBackup(err) {
+			continue
+		}
+		s.Path = path
+		results = append(results, s.searchQuery(q)...)
+	}
+	return results, nil
+}
+
+// Returns every note in this session that matches the parsed query.
+func (s *Session) searchQuery(q Query) []SearchResult {
+	results := make([]SearchResult, 0)
+	for i, note := range s.Notes {
+		if !q.Matches(note.Content) {
+			continue
+		}
+		snippet, highlights := buildSnippet(note.Content, q.matchIndexes(note.Content))
+		results = append(results, SearchResult{
+			Session:    s,
+			NoteIndex:  i,
+			Snippet:    snippet,
+			Highlights: highlights,
+		})
+	}
+	return results
+}
+
+// Cuts an excerpt of the text around its first match, adjusting the match offsets to the excerpt.
+func buildSnippet(text string, matches [][]int) (string, [][]int) {
+	start := matches[0][0] - SNIPPET_RADIUS
+	end := matches[0][1] + SNIPPET_RADIUS
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	// do not cut through a multi-byte character
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	snippet := text[start:end]
+	offset := -start
+	if start > 0 {
+		snippet = SNIPPET_ELLIPSIS + snippet
+		offset += len(SNIPPET_ELLIPSIS)
+	}
+	if end < len(text) {
+		snippet += SNIPPET_ELLIPSIS
+	}
+
+	highlights := make([][]int, 0)
+	for _, m := range matches {
+		if m[0] >= start && m[1] <= end {
+			highlights = append(highlights, []int{m[0] + offset, m[1] + offset})
+		}
+	}
+	return snippet, highlights
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Search queries", func() {
+	It("should match text case-insensitively", func() {
+		Expect(ParseQuery("xenthe").Matches("Xenthe almost died")).To(BeTrue())
+	})
+
+	It("should require every term to appear", func() {
+		q := ParseQuery("xenthe goblin")
+		Expect(q.Matches("Xenthe almost died")).To(BeFalse())
+		Expect(q.Matches("A goblin nearly killed Xenthe")).To(BeTrue())
+	})
+
+	It("should match quoted phrases as a whole", func() {
+		q := ParseQuery("\"almost died\"")
+		Expect(q.Matches("Xenthe almost  died")).To(BeTrue())
+		Expect(q.Matches("Xenthe died, almost")).To(BeFalse())
+	})
+
+	It("should treat special characters literally", func() {
+		Expect(ParseQuery("2d6+3").Matches("rolled 2d6+3")).To(BeTrue())
+		Expect(ParseQuery("2d6+3").Matches("rolled 2d663")).To(BeFalse())
+	})
+
+	It("should not match anything with an empty query", func() {
+		q := ParseQuery("  ")
+		Expect(q.Empty()).To(BeTrue())
+		Expect(q.Matches("Xenthe almost died")).To(BeFalse())
+	})
+})
+
+var _ = Describe("Session search", func() {
+	var s *Session
+
+	BeforeEach(func() {
+		s = NewSession("The Conquest at Calimport", 3)
+		s.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.AddNote(NewNote("Aust Redwyn returned, xenthe was relieved", time.Now()))
+	})
+
+	It("should return the index of every matching note", func() {
+		results := s.Search("Xenthe")
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].NoteIndex).To(Equal(1))
+		Expect(results[1].NoteIndex).To(Equal(2))
+		Expect(results[0].Session).To(Equal(s))
+	})
+
+	It("should find the matching note in another copy of the session", func() {
+		result := s.Search("almost died")[0]
+		copy, _ := FromJSON(s.ToJSON())
+		Expect(result.IndexIn(copy)).To(Equal(1))
+
+		copy.InsertNote(0, NewNote("Set sail", time.Now()))
+		Expect(result.IndexIn(copy)).To(Equal(2))
+		copy.UpdateNote(2, "Xenthe nearly died", time.Now())
+		Expect(result.IndexIn(copy)).To(Equal(2))
+		copy.DeleteNote(2)
+		Expect(result.IndexIn(copy)).To(Equal(-1))
+	})
+
+	It("should highlight the matches within the snippet", func() {
+		results := s.Search("xenthe")
+		Expect(results[0].Highlighted("[", "]")).To(Equal("[Xenthe] almost died"))
+	})
+
+	It("should shorten long notes around the match", func() {
+		long := NewSession("Long", 1)
+		padding := "The party walked for a very long time through the desert sands "
+		long.AddNote(NewNote(padding+"until Xenthe almost died "+padding, time.Now()))
+		result := long.Search("xenthe")[0]
+		Expect(result.Snippet).To(HavePrefix(SNIPPET_ELLIPSIS))
+		Expect(result.Snippet).To(HaveSuffix(SNIPPET_ELLIPSIS))
+		Expect(result.Highlighted("[", "]")).To(ContainSubstring("[Xenthe]"))
+	})
+
+	It("should return no results when nothing matches", func() {
+		Expect(s.Search("dragon")).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Searching many sessions", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-search")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should search every session of a campaign", func() {
+		c := NewCampaign("Calimport", dir)
+		first, _ := c.NewSession("")
+		first.AddNote(NewNote("Xenthe almost died", time.Now()))
+		first.Save()
+		second, _ := c.NewSession("")
+		second.AddNote(NewNote("Xenthe recovered", time.Now()))
+		second.Save()
+
+		results, err := SearchCampaign(c, "xenthe")
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Session.SessionNumber).To(Equal(1))
+		Expect(results[1].Session.SessionNumber).To(Equal(2))
+	})
+
+	It("should search every session file in a folder and skip other files", func() {
+		s := NewSession("Loose", 1)
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.Path = filepath.Join(dir, "loose.json")
+		s.Save()
+		os.WriteFile(filepath.Join(dir, "broken.json"), []byte("not a session"), 0600)
+
+		results, err := SearchDir(dir, "xenthe")
+		Expect(err).To(BeNil())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Session.Path).To(Equal(s.Path))
+	})
+})
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -14,11 +15,20 @@ const NO_SESSION_NUMBER = -1
 
 // Represents a single note-taking session.
 type Session struct {
-	Notes         []Note    // the collection of all notes created by the user
-	Date          time.Time // the date and time this session began
-	SessionTitle  string    // the name of the session, if one exists
-	SessionNumber int       // the number of the session, if one exists
-	Path          string    // the path to the file where this session is saved, if one exists
+	FormatVersion int        // the version of the file format this session was written in
+	Notes         []Note     // the collection of all notes created by the user
+	Date          time.Time  // the date and time this session began
+	SessionTitle  string     // the name of the session, if one exists
+	SessionNumber int        // the number of the session, if one exists
+	Path          string     // the path to the file where this session is saved, if one exists
+	Calendar      string     `json:",omitempty"` // the name of the calendar in-game dates are written in, the default calendar if empty
+	Clock         *GameTime  `json:",omitempty"` // the current in-game time, if it is being tracked
+	Combat        *Combat    `json:",omitempty"` // the fight being tracked, if there is one
+	history       *History   // the undoable changes made to this session
+	changes       int        // the number of changes made to this session
+	saved         int        // the number of changes made to this session when it was last loaded or saved
+	lock          sync.Mutex // guards the fields above against the session being saved on another goroutine while it changes
+	writing       sync.Mutex // held while the session is written, so that an older snapshot never replaces a newer one
 }
 
 // An option to customize the constructor for creating a new session.
@@ -27,6 +37,7 @@ type NewSessionOption func(s *Session)
 // Create a new Session.
 func NewSession(sessionTitle string, sessionNumber int, options ...NewSessionOption) *Session {
 	session := Session{
+		FormatVersion: CURRENT_FORMAT_VERSION,
 		Notes:         make([]Note, 0),
 		Date:          time.Now(),
 		SessionTitle:  sessionTitle,
@@ -46,62 +57,211 @@ func withCustomDate(t time.Time) NewSessionOption {
 	}
 }
 
-// Adds a note to this session.
+// Adds a note to this session. If the session is tracking in-game time, notes without an in-game time are stamped with the clock.
 func (s *Session) AddNote(n Note) {
 	// if the note is empty, it is likely user error
 	if n.Content == "" {
 		return
 	}
+	if n.GameTime == nil && s.Clock != nil {
+		clock := *s.Clock
+		n.GameTime = &clock
+	}
 	s.Notes = append(s.Notes, n)
+	s.changes++
+}
+
+// Replaces the content of the note at index i, marking it as edited at the passed time.
+// Returns an error if there is no such note or if the new content is empty.
+func (s *Session) UpdateNote(i int, content string, currentTime time.Time) error {
+	if err := s.checkNoteIndex(i); err != nil {
+		return err
+	}
+	if content == "" {
+		return errors.New("Notes cannot be empty, delete the note instead")
+	}
+	s.Notes[i].Content = content
+	s.Notes[i].Tags = ParseTags(content)
+	s.Notes[i].Edited = &currentTime
+	s.changes++
+	return nil
+}
+
+// Removes the note at index i from this session. Returns an error if there is no such note.
+func (s *Session) DeleteNote(i int) error {
+	if err := s.checkNoteIndex(i); err != nil {
+		return err
+	}
+	s.Notes = append(s.Notes[:i], s.Notes[i+1:]...)
+	s.changes++
+	return nil
+}
+
+// Inserts a note into this session so that it ends up at index i.
+// Returns an error if i is past the end of the notes.
+func (s *Session) InsertNote(i int, n Note) error {
+	if i != len(s.Notes) {
+		if err := s.checkNoteIndex(i); err != nil {
+			return err
+		}
+	}
+	s.Notes = append(s.Notes, Note{})
+	copy(s.Notes[i+1:], s.Notes[i:])
+	s.Notes[i] = n
+	s.changes++
+	return nil
+}
+
+// Moves the note at index from so that it ends up at index to. Returns an error if either note does not exist.
+func (s *Session) MoveNote(from int, to int) error {
+	if err := s.checkNoteIndex(from); err != nil {
+		return err
+	}
+	if err := s.checkNoteIndex(to); err != nil {
+		return err
+	}
+	n := s.Notes[from]
+	s.DeleteNote(from)
+	return s.InsertNote(to, n)
+}
+
+// Returns the history of undoable changes made to this session.
+// Changes should be made through the history so that they can be undone, and so that they are safe from being autosaved on another goroutine.
+func (s *Session) History() *History {
+	if s.history == nil {
+		s.history = NewHistory(s)
+	}
+	return s.history
+}
+
+// Reports whether the session has changed since it was last loaded or saved.
+// Every change made through the methods of the session or through its history counts, even one that was later undone.
+func (s *Session) IsDirty() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.changes != s.saved
+}
+
+// Records that the session has changes that are not saved, such as a session recovered from a journal or a backup.
+func (s *Session) MarkDirty() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.changes++
+}
+
+// Returns the path to the file where this session is saved, which may be set on another goroutine while this session is autosaved.
+func (s *Session) savedPath() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.Path
+}
+
+// Returns an error if there is no note at index i.
+func (s *Session) checkNoteIndex(i int) error {
+	if i < 0 || i >= len(s.Notes) {
+		return errors.New("No note at index " + strconv.Itoa(i) + " in session")
+	}
+	return nil
 }
 
 // Returns a JSON reprsentation of the session for purposes of serialization.
+// The session is always written in the current format version.
 func (s *Session) ToJSON() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.toJSON()
+}
+
+// Returns a JSON representation of the session in the current format version. The session must be locked.
+func (s *Session) toJSON() string {
 	builder := new(strings.Builder)
 	encoder := json.NewEncoder(builder)
-	encoder.Encode(s)
+	// the outer FormatVersion takes the place of the session's own
+	encoder.Encode(struct {
+		FormatVersion int
+		*Session
+	}{CURRENT_FORMAT_VERSION, s})
 	return builder.String()
 }
 
 // Builds a session from a JSON representation of a session.
-// If the input string is invalid, returns a pointer to an empty Session and an error.
+// Sessions written in an older format version are migrated to the current one.
+// If the input string is invalid or was written in a newer format version,
+// returns a pointer to an empty Session and an error.
 func FromJSON(s string) (*Session, error) {
-	reader := strings.NewReader(s)
-	decoder := json.NewDecoder(reader)
-	session := Session{}
-	err := decoder.Decode(&session)
-
-	if err != nil {
-		return &Session{}, err
-	}
+	return ReadSession(strings.NewReader(s))
+}
 
-	// rectify invalid data modified externally outside of the application
-	if session.SessionNumber < NO_SESSION_NUMBER {
-		session.SessionNumber = NO_SESSION_NUMBER
+// Writes Session data to specified file.
+// The file is replaced atomically, and its previous contents are kept as a backup.
+// The session is no longer dirty once it is written.
+func (s *Session) Save() error {
+	UserRW := fs.FileMode(0600)
+	if err := rotateBackups(s.Path, UserRW); err != nil {
+		return err
 	}
+	return s.write()
+}
 
-	return &session, nil
+// Sets the path to the file where this session is saved and writes it there, keeping the previous contents of the file as a backup.
+func (s *Session) SaveAs(path string) error {
+	s.lock.Lock()
+	s.Path = path
+	s.lock.Unlock()
+	return s.Save()
 }
 
-// Writes Session data to specified file.
-func (s *Session) Save() error {
+// Writes Session data to specified file atomically, without keeping its previous contents as a backup.
+// Sessions are written this way when autosaved, so that the backups hold what the user chose to save
+// rather than the last few minutes of autosaves. The session is no longer dirty once it is written,
+// unless it changed while being written. Safe to call on another goroutine while the session changes through its history.
+func (s *Session) write() error {
 	UserRW := fs.FileMode(0600)
-	return os.WriteFile(s.Path, []byte(s.ToJSON()), UserRW)
+	s.writing.Lock()
+	defer s.writing.Unlock()
+
+	s.lock.Lock()
+	path, data, changes := s.Path, s.toJSON(), s.changes
+	s.lock.Unlock()
+	if err := writeFileAtomic(path, []byte(data), UserRW); err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	s.saved = changes
+	s.lock.Unlock()
+	return nil
 }
 
-// Load Session data from specified file.
-// In the case of an error during reading the file or converting it into a session,
-// returns an empty session and an error.
-func Load(path string) (*Session, error) {
-	data, err := os.ReadFile(path)
+// Load Session data from specified file. The file is read as it is decoded, and progress is reported if an option asks for it.
+// In the case of an error during reading the file, returns an empty session and an error.
+// If the file cannot be converted into a session, the most recent valid backup is loaded instead and returned
+// along with a CorruptSessionError, dirty as it differs from the file. If there is no valid backup, returns an empty session and a CorruptSessionError.
+// Files written in a newer format version are never replaced by a backup, an UnsupportedFormatError is returned instead.
+func Load(path string, options ...LoadOption) (*Session, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return &Session{}, err
 	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil {
+		options = append([]LoadOption{WithSize(info.Size())}, options...)
+	}
+	options = append([]LoadOption{WithPath(path)}, options...)
 
-	s, err := FromJSON(string(data))
-	if err != nil {
+	s, err := ReadSession(file, options...)
+	var unsupported *UnsupportedFormatError
+	if errors.As(err, &unsupported) {
 		return &Session{}, err
 	}
+	if err != nil {
+		backup, backupPath := loadNewestBackup(path)
+		if backupPath != "" {
+			// the backup is not what is in the file
+			backup.MarkDirty()
+		}
+		return backup, &CorruptSessionError{Path: path, Backup: backupPath, Err: err}
+	}
 
 	return s, nil
 
@@ -1,6 +1,8 @@
 package backend
 
 import (
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -31,6 +33,68 @@ var _ = Describe("Sessions", func() {
 		session.AddNote(note)
 		Expect(session.Notes).ToNot(ContainElement(note))
 	})
+
+	It("should update the content of a note and mark it as edited", func() {
+		session := NewSession("Test", 1)
+		session.AddNote(NewNote("Xenthe almsot died", time.Now()))
+		edited := time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC)
+
+		Expect(session.UpdateNote(0, "Xenthe almost died", edited)).To(BeNil())
+		Expect(session.Notes[0].Content).To(Equal("Xenthe almost died"))
+		Expect(session.Notes[0].IsEdited()).To(BeTrue())
+		Expect(session.Notes[0].Edited.Equal(edited)).To(BeTrue())
+	})
+
+	It("should not update a note to be empty", func() {
+		session := NewSession("Test", 1)
+		session.AddNote(NewNote("Xenthe almost died", time.Now()))
+
+		Expect(session.UpdateNote(0, "", time.Now())).ToNot(BeNil())
+		Expect(session.Notes[0].Content).To(Equal("Xenthe almost died"))
+		Expect(session.Notes[0].IsEdited()).To(BeFalse())
+	})
+
+	It("should return an error when updating a note that does not exist", func() {
+		session := NewSession("Test", 1)
+		Expect(session.UpdateNote(0, "Xenthe almost died", time.Now())).ToNot(BeNil())
+	})
+
+	It("should delete a note from the session", func() {
+		session := NewSession("Test", 1)
+		session.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		session.AddNote(NewNote("Xenthe almost died", time.Now()))
+
+		Expect(session.DeleteNote(0)).To(BeNil())
+		Expect(session.Notes).To(HaveLen(1))
+		Expect(session.Notes[0].Content).To(Equal("Xenthe almost died"))
+	})
+
+	It("should insert a note at the passed index", func() {
+		session := NewSession("Test", 1)
+		session.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		Expect(session.InsertNote(0, NewNote("Left Waterdeep", time.Now()))).To(BeNil())
+		Expect(session.InsertNote(2, NewNote("Xenthe almost died", time.Now()))).To(BeNil())
+		Expect(session.Notes[0].Content).To(Equal("Left Waterdeep"))
+		Expect(session.Notes[2].Content).To(Equal("Xenthe almost died"))
+		Expect(session.InsertNote(5, NewNote("Too far", time.Now()))).ToNot(BeNil())
+	})
+
+	It("should move a note to a different position", func() {
+		session := NewSession("Test", 1)
+		session.AddNote(NewNote("Arrived in Calimport", time.Now()))
+		session.AddNote(NewNote("Xenthe almost died", time.Now()))
+		session.AddNote(NewNote("Aust Redwyn returned", time.Now()))
+		Expect(session.MoveNote(2, 0)).To(BeNil())
+		Expect(session.Notes[0].Content).To(Equal("Aust Redwyn returned"))
+		Expect(session.Notes[1].Content).To(Equal("Arrived in Calimport"))
+		Expect(session.MoveNote(0, 3)).ToNot(BeNil())
+	})
+
+	It("should return an error when deleting a note that does not exist", func() {
+		session := NewSession("Test", 1)
+		Expect(session.DeleteNote(-1)).ToNot(BeNil())
+		Expect(session.DeleteNote(0)).ToNot(BeNil())
+	})
 })
 
 var _ = Describe("Session number validator", func() {
@@ -130,6 +194,16 @@ var _ = Describe("JSON Deserialization", func() {
 		Expect(s2.Notes[0].Content).To(Equal(note))
 	})
 
+	It("should deserialize when notes were edited", func() {
+		s := NewSession("The Conquest at Calimport", 0)
+		s.AddNote(NewNote("Xenthe almsot died", time.Now()))
+		edited := time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC)
+		s.UpdateNote(0, "Xenthe almost died", edited)
+		s2, _ := FromJSON(s.ToJSON())
+		Expect(s2.Notes[0].IsEdited()).To(BeTrue())
+		Expect(s2.Notes[0].Edited.Equal(edited)).To(BeTrue())
+	})
+
 	It("should return an error if the data is malformed", func() {
 		data := "This isn't a JSON and cannot be loaded"
 		_, err := FromJSON(data)
@@ -143,6 +217,114 @@ var _ = Describe("JSON Deserialization", func() {
 	})
 })
 
-// TODO: add tests for saving functionality
+var _ = Describe("Saving and loading", func() {
+	var dir string
+	var path string
 
-// TODO: add tests for loading functionality
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "archon-session")
+		Expect(err).To(BeNil())
+		path = filepath.Join(dir, "session.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should load a session that was saved", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.Path = path
+		Expect(s.Save()).To(BeNil())
+
+		s2, err := Load(path)
+		Expect(err).To(BeNil())
+		Expect(s2.SessionTitle).To(Equal(s.SessionTitle))
+		Expect(s2.Notes).To(HaveLen(1))
+	})
+
+	It("should only be dirty between a change and the next save", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		Expect(s.IsDirty()).To(BeFalse())
+		s.AddNote(NewNote("", time.Now()))
+		Expect(s.IsDirty()).To(BeFalse())
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		Expect(s.IsDirty()).To(BeTrue())
+		s.Path = path
+		Expect(s.Save()).To(BeNil())
+		Expect(s.IsDirty()).To(BeFalse())
+
+		loaded, err := Load(path)
+		Expect(err).To(BeNil())
+		Expect(loaded.IsDirty()).To(BeFalse())
+	})
+
+	It("should not leave temporary files behind", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		s.Save()
+		s.Save()
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			Expect(entry.Name()).ToNot(ContainSubstring(".tmp-"))
+		}
+	})
+
+	It("should keep the previous contents of the file as a backup", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		s.Save()
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.Save()
+
+		backup, err := Load(BackupPath(path, 1))
+		Expect(err).To(BeNil())
+		Expect(backup.Notes).To(BeEmpty())
+	})
+
+	It("should keep a limited number of backups", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		for i := 0; i < MAX_SESSION_BACKUPS+3; i++ {
+			s.Save()
+		}
+		Expect(BackupPath(path, MAX_SESSION_BACKUPS)).To(BeAnExistingFile())
+		Expect(BackupPath(path, MAX_SESSION_BACKUPS+1)).ToNot(BeAnExistingFile())
+	})
+
+	It("should not back up a corrupt file", func() {
+		os.WriteFile(path, []byte(`{"Notes":[`), 0600)
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		Expect(s.Save()).To(BeNil())
+		Expect(BackupPath(path, 1)).ToNot(BeAnExistingFile())
+	})
+
+	It("should load the newest valid backup when the file is corrupt", func() {
+		s := NewSession("The Conquest at Calimport", 3)
+		s.Path = path
+		s.Save()
+		s.AddNote(NewNote("Xenthe almost died", time.Now()))
+		s.Save()
+		os.WriteFile(path, []byte(`{"Notes":[{"Content":"Xen`), 0600)
+
+		recovered, err := Load(path)
+		Expect(RecoveredFromBackup(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(BackupPath(path, 1)))
+		Expect(recovered.SessionTitle).To(Equal("The Conquest at Calimport"))
+	})
+
+	It("should return an error and an empty session when a corrupt file has no backup", func() {
+		os.WriteFile(path, []byte(`{"Notes":[`), 0600)
+		s, err := Load(path)
+		Expect(err).ToNot(BeNil())
+		Expect(RecoveredFromBackup(err)).To(BeFalse())
+		Expect(s.SessionTitle).To(BeEmpty())
+	})
+
+	It("should return an error when the file does not exist", func() {
+		_, err := Load(path)
+		Expect(err).ToNot(BeNil())
+	})
+})
@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Starts text typed into the entry field that runs a command instead of adding a note, like "/title The Sunless Citadel".
+const SLASH_PREFIX = "/"
+
+// Starts text typed into the entry field that adds a note starting with a slash, like "//shrug" for the note "/shrug".
+const SLASH_ESCAPE = SLASH_PREFIX + SLASH_PREFIX
+
+// A command run by typing its name after a slash, like "/session 12".
+// Register new commands with a CommandRegistry to make them available.
+type SlashCommand interface {
+	Name() string        // the name typed after the slash, like "session"
+	Usage() string       // the arguments the command takes, like "<number>", or empty if it takes none
+	Description() string // a short description of what the command does
+	Run(call SlashCall) error
+}
+
+// The input to a single run of a SlashCommand.
+type SlashCall struct {
+	Session *Session // the session the command was typed into
+	Args    []string // the words typed after the name of the command
+	Kind    NoteKind // the kind of any note the command adds
+}
+
+// Reports whether the text runs a command rather than adding a note. Text starting with SLASH_ESCAPE adds a note.
+func IsSlashCommand(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, SLASH_PREFIX) && len(text) > len(SLASH_PREFIX) && !strings.HasPrefix(text, SLASH_ESCAPE)
+}
+
+// Returns the content of the note added by text that is not a command, removing the first slash of a leading SLASH_ESCAPE.
+func UnescapeSlash(text string) string {
+	if trimmed := strings.TrimLeft(text, " \t"); strings.HasPrefix(trimmed, SLASH_ESCAPE) {
+		return strings.TrimPrefix(trimmed, SLASH_PREFIX)
+	}
+	return text
+}
+
+// The commands that can be run from the entry field, by name.
+type CommandRegistry struct {
+	commands map[string]SlashCommand // every registered command, keyed by lowercase name
+}
+
+// Create a new CommandRegistry with the passed commands registered.
+func NewCommandRegistry(commands ...SlashCommand) *CommandRegistry {
+	r := &CommandRegistry{commands: make(map[string]SlashCommand)}
+	for _, command := range commands {
+		r.Register(command)
+	}
+	return r
+}
+
+// Makes a command available. Registering a command with the same name as another replaces it.
+func (r *CommandRegistry) Register(command SlashCommand) {
+	r.commands[strings.ToLower(command.Name())] = command
+}
+
+// Returns the command with the passed name, ignoring case, and whether there is one.
+func (r *CommandRegistry) Lookup(name string) (SlashCommand, bool) {
+	command, ok := r.commands[strings.ToLower(strings.TrimPrefix(name, SLASH_PREFIX))]
+	return command, ok
+}
+
+// Returns every registered command, sorted by name.
+func (r *CommandRegistry) Commands() []SlashCommand {
+	return r.Suggest(SLASH_PREFIX)
+}
+
+// Returns the commands that the partially typed text could be running, sorted by name.
+// While the name is being typed, every command starting with it is suggested; once arguments are being typed, only the command named.
+// Text that is not a command has no suggestions.
+func (r *CommandRegistry) Suggest(text string) []SlashCommand {
+	suggestions := make([]SlashCommand, 0)
+	text = strings.TrimLeft(text, " \t")
+	if !strings.HasPrefix(text, SLASH_PREFIX) {
+		return suggestions
+	}
+	name := strings.ToLower(strings.TrimPrefix(text, SLASH_PREFIX))
+	if i := strings.IndexAny(name, " \t\n"); i != -1 {
+		if command, ok := r.Lookup(name[:i]); ok {
+			suggestions = append(suggestions, command)
+		}
+		return suggestions
+	}
+	for key, command := range r.commands {
+		if strings.HasPrefix(key, name) {
+			suggestions = append(suggestions, command)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].Name() < suggestions[j].Name()
+	})
+	return suggestions
+}
+
+// Runs the command typed in the text against the session. Notes the command adds are of the passed kind.
+// Returns an error if there is no such command or the command fails.
+func (r *CommandRegistry) Run(session *Session, text string, kind NoteKind) error {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(text), SLASH_PREFIX))
+	if len(fields) == 0 {
+		return errors.New("Type the name of a command after " + SLASH_PREFIX)
+	}
+	command, ok := r.Lookup(fields[0])
+	if !ok {
+		return errors.New("Unknown command " + SLASH_PREFIX + fields[0] + ", start the note with " + SLASH_ESCAPE + " to add it as written")
+	}
+	// a command that leaves the session as it was, like setting the title it already has, still succeeds
+	if err := command.Run(SlashCall{Session: session, Args: fields[1:], Kind: kind}); err != ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// A SlashCommand that calls a function when run.
+type funcCommand struct {
+	name        string                     // the name typed after the slash
+	usage       string                     // the arguments the command takes
+	description string                     // a short description of what the command does
+	run         func(call SlashCall) error // called when the command is run
+}
+
+// Returns the name of the command. Necessary to implement the SlashCommand interface.
+func (fc *funcCommand) Name() string {
+	return fc.name
+}
+
+// Returns the arguments the command takes. Necessary to implement the SlashCommand interface.
+func (fc *funcCommand) Usage() string {
+	return fc.usage
+}
+
+// Returns a short description of the command. Necessary to implement the SlashCommand interface.
+func (fc *funcCommand) Description() string {
+	return fc.description
+}
+
+// Runs the command. Necessary to implement the SlashCommand interface.
+func (fc *funcCommand) Run(call SlashCall) error {
+	return fc.run(call)
+}
+
+// Create a new SlashCommand that calls run when it is run.
+func NewSlashCommand(name string, usage string, description string, run func(call SlashCall) error) SlashCommand {
+	return &funcCommand{name: name, usage: usage, description: description, run: run}
+}
+
+// Returns the commands every session supports: changing its title and number, tagging the latest note,
+// undoing and redoing changes, dividing it into scenes, tracking in-game time and combat, and rolling dice with the passed roller.
+func DefaultSlashCommands(roller *Roller) []SlashCommand {
+	return []SlashCommand{
+		NewSlashCommand("title", "<title>", "Change the title of the session", runTitle),
+		NewSlashCommand("session", "<number>", "Change the number of the session", runSession),
+		NewSlashCommand("tag", "<tag>…", "Tag the latest note", runTag),
+		NewSlashCommand("undo", "", "Undo the latest change", func(call SlashCall) error {
+			return call.Session.History().Undo()
+		}),
+		NewSlashCommand("redo", "", "Redo the latest undone change", func(call SlashCall) error {
+			return call.Session.History().Redo()
+		}),
+		NewSlashCommand("clock", "<date>|+<time>|off", "Set or advance the in-game time, like /clock 1 Hammer 1491 or /clock +2h", runClock),
+		NewSlashCommand("calendar", "<name>", "Choose the calendar in-game dates are written in", runCalendar),
+		NewSlashCommand("scene", "[title]", "Start a new scene", runScene),
+		NewSlashCommand("break", "", "Mark a pause in play", runBreak),
+		NewSlashCommand("combat", "start|next|end", "Start combat, end the current turn, or end combat", runCombat),
+		NewRollCommand(roller),
+	}
+}
+
+// Create a new SlashCommand that rolls dice with the passed roller and adds a note with the result.
+func NewRollCommand(roller *Roller) SlashCommand {
+	return NewSlashCommand(strings.TrimPrefix(ROLL_COMMAND, SLASH_PREFIX), "<dice> [note]", "Roll dice, like 4d6kh3 or 1d20+5", func(call SlashCall) error {
+		content, rolls, err := roller.RollNote(ROLL_COMMAND + " " + strings.Join(call.Args, " "))
+		if err != nil {
+			return err
+		}
+		note := NewNote(content, time.Now())
+		note.Kind = call.Kind
+		note.Rolls = rolls
+		return call.Session.History().Execute(&AddNoteCommand{Note: note})
+	})
+}
+
+// Changes the title of the session to the arguments.
+func runTitle(call SlashCall) error {
+	if len(call.Args) == 0 {
+		return errors.New("Type the new title after /title")
+	}
+	return call.Session.History().Execute(&SetTitleCommand{Title: strings.Join(call.Args, " ")})
+}
+
+// Changes the number of the session to the argument.
+func runSession(call SlashCall) error {
+	if len(call.Args) != 1 {
+		return errors.New("Type the new session number after /session, like /session 12")
+	}
+	number, err := strconv.Atoi(call.Args[0])
+	if err != nil {
+		return errors.New(call.Args[0] + " is not a session number")
+	}
+	return call.Session.History().Execute(&SetNumberCommand{Number: number})
+}
+
+// Adds the arguments as hashtags to the end of the latest note. Tags the note already has are not added again.
+func runTag(call SlashCall) error {
+	if len(call.Args) == 0 {
+		return errors.New("Type the tags to add after /tag, like /tag loot")
+	}
+	s := call.Session
+	if len(s.Notes) == 0 {
+		return errors.New("There is no note to tag")
+	}
+	i := len(s.Notes) - 1
+	content := s.Notes[i].Content
+	for _, tag := range call.Args {
+		tag = NormalizeTag(tag)
+		if tag == "" || (Note{Tags: ParseTags(content)}).HasTag(tag) {
+			continue
+		}
+		content += " " + TAG_PREFIX + tag
+	}
+	if content == s.Notes[i].Content {
+		return nil
+	}
+	return s.History().Execute(&UpdateNoteCommand{Index: i, Content: content, Time: time.Now()})
+}
+
+// Sets the in-game clock to a date, advances or rewinds it by an amount of time written with a sign, or stops it.
+func runClock(call SlashCall) error {
+	s := call.Session
+	text := strings.Join(call.Args, " ")
+	switch {
+	case text == "":
+		return errors.New("Type a date like /clock 1 Hammer 1491, an amount of time like /clock +2h, or /clock off")
+	case strings.EqualFold(text, "off"):
+		return s.History().Execute(&SetClockCommand{Clock: nil})
+	case strings.HasPrefix(text, "+") || strings.HasPrefix(text, "-"):
+		if s.Clock == nil {
+			return errors.New("Set the in-game date before advancing it, like /clock 1 Hammer 1491")
+		}
+		duration, err := ParseGameDuration(text[1:])
+		if err != nil {
+			return err
+		}
+		if text[0] == '-' {
+			duration = -duration
+		}
+		clock := *s.Clock + duration
+		return s.History().Execute(&SetClockCommand{Clock: &clock})
+	}
+	clock, err := s.GameCalendar().Parse(text)
+	if err != nil {
+		return err
+	}
+	return s.History().Execute(&SetClockCommand{Clock: &clock})
+}
+
+// Changes the calendar of the session to the one named by the argument.
+func runCalendar(call SlashCall) error {
+	if len(call.Args) != 1 {
+		return errors.New("Type the name of a calendar after /calendar, one of " + strings.Join(CalendarNames(), ", "))
+	}
+	return call.Session.History().Execute(&SetCalendarCommand{Calendar: call.Args[0]})
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slash commands", func() {
+	var s *Session
+	var registry *CommandRegistry
+
+	BeforeEach(func() {
+		s = NewSession("Untitled Session", NO_SESSION_NUMBER)
+		registry = NewCommandRegistry(DefaultSlashCommands(NewRoller(WithSeed(1)))...)
+	})
+
+	It("should recognize text starting with a slash as a command", func() {
+		Expect(IsSlashCommand("/title The Sunless Citadel")).To(BeTrue())
+		Expect(IsSlashCommand("/")).To(BeFalse())
+		Expect(IsSlashCommand("The party rests")).To(BeFalse())
+	})
+
+	It("should add text starting with an escaped slash as a note", func() {
+		Expect(IsSlashCommand("//shrug")).To(BeFalse())
+		Expect(UnescapeSlash("//shrug")).To(Equal("/shrug"))
+		Expect(UnescapeSlash("///etc/hosts")).To(Equal("//etc/hosts"))
+		Expect(UnescapeSlash("The party rests")).To(Equal("The party rests"))
+	})
+
+	It("should change the title of the session", func() {
+		Expect(registry.Run(s, "/title The Sunless Citadel", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.SessionTitle).To(Equal("The Sunless Citadel"))
+	})
+
+	It("should change the number of the session", func() {
+		Expect(registry.Run(s, "/session 12", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.SessionNumber).To(Equal(12))
+		Expect(registry.Run(s, "/session twelve", KIND_NARRATIVE)).ToNot(Succeed())
+	})
+
+	It("should tag the latest note", func() {
+		s.AddNote(NewNote("Found a wand #magic", time.Now()))
+		Expect(registry.Run(s, "/tag loot #magic loot", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.Notes[0].Content).To(Equal("Found a wand #magic #loot"))
+		Expect(s.Notes[0].Tags).To(ConsistOf("magic", "loot"))
+	})
+
+	It("should not tag a session without notes", func() {
+		Expect(registry.Run(s, "/tag loot", KIND_NARRATIVE)).ToNot(Succeed())
+	})
+
+	It("should undo and redo changes", func() {
+		Expect(registry.Run(s, "/title The Sunless Citadel", KIND_NARRATIVE)).To(Succeed())
+		Expect(registry.Run(s, "/undo", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.SessionTitle).To(Equal("Untitled Session"))
+		Expect(registry.Run(s, "/redo", KIND_NARRATIVE)).To(Succeed())
+		Expect(s.SessionTitle).To(Equal("The Sunless Citadel"))
+	})
+
+	It("should add a note of the passed kind when rolling", func() {
+		Expect(registry.Run(s, "/roll 1d20+5 to hit", KIND_COMBAT)).To(Succeed())
+		Expect(s.Notes).To(HaveLen(1))
+		Expect(s.Notes[0].Kind).To(Equal(KIND_COMBAT))
+		Expect(s.Notes[0].Rolls).To(HaveLen(1))
+	})
+
+	It("should refuse unknown commands", func() {
+		Expect(registry.Run(s, "/teleport", KIND_NARRATIVE)).ToNot(Succeed())
+	})
+
+	It("should run registered commands", func() {
+		ran := false
+		registry.Register(NewSlashCommand("Rest", "", "Take a long rest", func(call SlashCall) error {
+			ran = true
+			Expect(call.Args).To(Equal([]string{"long"}))
+			return nil
+		}))
+		Expect(registry.Run(s, "/rest long", KIND_NARRATIVE)).To(Succeed())
+		Expect(ran).To(BeTrue())
+	})
+
+	It("should return the errors of commands", func() {
+		registry.Register(NewSlashCommand("fail", "", "Always fails", func(call SlashCall) error {
+			return errors.New("failed")
+		}))
+		Expect(registry.Run(s, "/fail", KIND_NARRATIVE)).To(MatchError("failed"))
+	})
+
+	It("should suggest the commands starting with the typed name", func() {
+		names := func(commands []SlashCommand) []string {
+			result := make([]string, 0)
+			for _, command := range commands {
+				result = append(result, command.Name())
+			}
+			return result
+		}
+		Expect(names(registry.Suggest("/r"))).To(Equal([]string{"redo", "roll"}))
+		Expect(names(registry.Suggest("/session 1"))).To(Equal([]string{"session"}))
+		Expect(registry.Suggest("rolled a 20")).To(BeEmpty())
+		Expect(registry.Commands()).To(HaveLen(len(DefaultSlashCommands(NewRoller()))))
+	})
+})
@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The character that marks a word in a note as a tag.
+const TAG_PREFIX = "#"
+
+// Matches a hashtag at the start of the text or after whitespace, capturing the tag without its prefix.
+var hashtagPattern = regexp.MustCompile(`(?:^|\s)#([\p{L}\p{N}_-]+)`)
+
+// Returns the hashtags in the passed text, lowercased and without their prefix, in the order they first appear.
+func ParseTags(content string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range hashtagPattern.FindAllStringSubmatch(content, -1) {
+		tag := NormalizeTag(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Returns the tag in the form it is stored in, lowercased and without its prefix.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), TAG_PREFIX))
+}
+
+// Reports whether the note is tagged with the passed tag.
+func (n Note) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range n.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the note is tagged with every one of the passed tags.
+func (n Note) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !n.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns every tag used by the notes of this session, sorted alphabetically.
+func (s *Session) Tags() []string {
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, note := range s.Notes {
+		for _, tag := range note.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Returns the indexes of the notes in this session tagged with every one of the passed tags.
+func (s *Session) FilterByTags(tags []string) []int {
+	indexes := make([]int, 0)
+	for i, note := range s.Notes {
+		if note.HasTags(tags) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// Adds the tags parsed from their content to the notes of documents written before notes were tagged.
+func migrateTags(doc Document) error {
+	notes, ok := doc["Notes"].([]interface{})
+	if !ok {
+		return nil
+	}
+	for _, n := range notes {
+		note, ok := n.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		content, _ := note["Content"].(string)
+		if tags := ParseTags(content); len(tags) > 0 {
+			note["Tags"] = tags
+		}
+	}
+	return nil
+}
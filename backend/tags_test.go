@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tags", func() {
+	It("should parse hashtags from the content of a note", func() {
+		Expect(ParseTags("Ambushed by goblins #combat, found a wand #loot")).To(Equal([]string{"combat", "loot"}))
+	})
+
+	It("should lowercase tags and remove duplicates", func() {
+		Expect(ParseTags("#NPC met Aust Redwyn #npc")).To(Equal([]string{"npc"}))
+	})
+
+	It("should not treat a # inside a word as a tag", func() {
+		Expect(ParseTags("Room C#4 was empty")).To(BeEmpty())
+	})
+
+	It("should tag new notes from their content", func() {
+		note := NewNote("Xenthe almost died #combat", time.Now())
+		Expect(note.Tags).To(Equal([]string{"combat"}))
+		Expect(note.HasTag("#Combat")).To(BeTrue())
+		Expect(note.HasTag("loot")).To(BeFalse())
+	})
+
+	It("should retag notes when they are updated", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Found a wand #combat", time.Now()))
+		s.UpdateNote(0, "Found a wand #loot", time.Now())
+		Expect(s.Notes[0].Tags).To(Equal([]string{"loot"}))
+	})
+
+	It("should list every tag used in a session", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Found a wand #loot", time.Now()))
+		s.AddNote(NewNote("Goblins attacked #combat #loot", time.Now()))
+		Expect(s.Tags()).To(Equal([]string{"combat", "loot"}))
+	})
+
+	It("should filter notes by every passed tag", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Found a wand #loot", time.Now()))
+		s.AddNote(NewNote("Goblins attacked #combat #loot", time.Now()))
+		s.AddNote(NewNote("Met Aust Redwyn #npc", time.Now()))
+		Expect(s.FilterByTags([]string{"loot"})).To(Equal([]int{0, 1}))
+		Expect(s.FilterByTags([]string{"loot", "combat"})).To(Equal([]int{1}))
+	})
+
+	It("should serialize tags", func() {
+		s := NewSession("Test", 1)
+		s.AddNote(NewNote("Found a wand #loot", time.Now()))
+		Expect(s.ToJSON()).To(ContainSubstring("\"Tags\":[\"loot\"]"))
+		s2, _ := FromJSON(s.ToJSON())
+		Expect(s2.Notes[0].Tags).To(Equal([]string{"loot"}))
+	})
+
+	It("should tag the notes of sessions written before notes were tagged", func() {
+		data := `{"FormatVersion":1,"Notes":[{"Content":"Found a wand #loot","Time":"2021-07-15T14:38:04.732366749-04:00"}],"SessionTitle":"Test session","SessionNumber":2}`
+		s, err := FromJSON(data)
+		Expect(err).To(BeNil())
+		Expect(s.Notes[0].Tags).To(Equal([]string{"loot"}))
+	})
+})
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/archon/backend"
+)
+
+const CLI_EXIT_OK = 0
+const CLI_EXIT_ERROR = 1
+const CLI_EXIT_USAGE = 2
+
+// The layout of the times printed and accepted by the command-line interface.
+const CLI_TIME_FORMAT = time.RFC3339
+
+// Returned by a subcommand when it was passed the wrong arguments.
+var errUsage = errors.New("wrong arguments")
+
+// A subcommand of the command-line interface, such as "archon add".
+type cliCommand struct {
+	name        string                            // the name the command is invoked by
+	usage       string                            // the flags and arguments the command takes
+	description string                            // a short description of what the command does
+	run         func(c *cli, args []string) error // runs the command with the arguments left after its flags
+	setUp       func(c *cli, flags *flag.FlagSet) // declares the flags of the command, if it has any
+}
+
+// The state of a single invocation of the command-line interface.
+type cli struct {
+	in     io.Reader // where notes are read from when they are not passed as arguments
+	out    io.Writer // where the output of a command is written
+	errOut io.Writer // where errors and warnings are written
+
+	title      string // the title of a new session
+	number     int    // the number of a new session
+	noteTime   string // the time a note was taken
+	kind       string // the kind of a note
+	tag        string // the tag notes are filtered by
+	format     string // the format a session is exported in
+	timeFormat string // the layout of the time of each exported note
+	dark       bool   // whether HTML is exported with a dark theme
+	output     string // the file an export is written to
+	force      bool   // whether a session recovered from a backup may be changed, replacing the corrupt file
+}
+
+// Every subcommand of the command-line interface, in the order they are listed in the help.
+var cliCommands = []cliCommand{
+	{
+		name:        "new",
+		usage:       "[-title title] [-number number] <file>",
+		description: "Create an empty session file",
+		run:         runNew,
+		setUp: func(c *cli, flags *flag.FlagSet) {
+			flags.StringVar(&c.title, "title", "", "the title of the session")
+			flags.IntVar(&c.number, "number", backend.NO_SESSION_NUMBER, "the number of the session")
+		},
+	},
+	{
+		name:        "add",
+		usage:       "[-time time] [-kind kind] [-force] <file> [note...]",
+		description: "Add a note to a session, reading it from standard input if it is not passed",
+		run:         runAdd,
+		setUp: func(c *cli, flags *flag.FlagSet) {
+			flags.StringVar(&c.noteTime, "time", "", "when the note was taken, formatted like "+CLI_TIME_FORMAT+" (default now)")
+			flags.StringVar(&c.kind, "kind", "", "the kind of the note, unless it starts with a kind prefix like \"loot:\" (default narrative)")
+			flags.BoolVar(&c.force, "force", false, "add the note even if the session file is corrupt, to the backup loaded instead, replacing the corrupt file")
+		},
+	},
+	{
+		name:        "list",
+		usage:       "[-tag tag] <file>",
+		description: "Print the notes of a session",
+		run:         runList,
+		setUp: func(c *cli, flags *flag.FlagSet) {
+			flags.StringVar(&c.tag, "tag", "", "only print notes with this tag")
+		},
+	},
+	{
+		name:        "export",
+		usage:       "[-format md|html] [-time-format layout] [-dark] [-o file] <file>",
+		description: "Export a session as Markdown or HTML",
+		run:         runExport,
+		setUp: func(c *cli, flags *flag.FlagSet) {
+			flags.StringVar(&c.format, "format", "md", "the format to export, md or html")
+			flags.StringVar(&c.timeFormat, "time-format", backend.DEFAULT_EXPORT_TIME_FORMAT, "the layout of the time of each note")
+			flags.BoolVar(&c.dark, "dark", false, "use a dark theme for HTML")
+			flags.StringVar(&c.output, "o", "", "the file to write the export to (default standard output)")
+		},
+	},
+	{
+		name:        "search",
+		usage:       "<query> <file or directory>...",
+		description: "Search session files, directories of session files, and campaigns",
+		run:         runSearch,
+	},
+	{
+		name:        "info",
+		usage:       "<file>",
+		description: "Print the title, number, date, and tags of a session",
+		run:         runInfo,
+	},
+}
+
+// Reports whether the passed arguments invoke a subcommand of the command-line interface rather than the GUI.
+func isCLI(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if isHelp(args[0]) {
+		return true
+	}
+	_, ok := findCommand(args[0])
+	return ok
+}
+
+// Runs the subcommand named by the first argument with the remaining arguments. Returns the exit code of the process.
+func runCLI(args []string, in io.Reader, out io.Writer, errOut io.Writer) int {
+	c := &cli{in: in, out: out, errOut: errOut}
+	if len(args) == 0 || isHelp(args[0]) {
+		c.printUsage(out)
+		return CLI_EXIT_OK
+	}
+	command, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(errOut, "archon: unknown command %q\n\n", args[0])
+		c.printUsage(errOut)
+		return CLI_EXIT_USAGE
+	}
+
+	flags := flag.NewFlagSet("archon "+command.name, flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() {
+		fmt.Fprintf(errOut, "usage: archon %s %s\n", command.name, command.usage)
+		flags.PrintDefaults()
+	}
+	if command.setUp != nil {
+		command.setUp(c, flags)
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return CLI_EXIT_OK
+		}
+		return CLI_EXIT_USAGE
+	}
+
+	err := command.run(c, flags.Args())
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		return CLI_EXIT_USAGE
+	}
+	if err != nil {
+		fmt.Fprintf(errOut, "archon %s: %v\n", command.name, err)
+		return CLI_EXIT_ERROR
+	}
+	return CLI_EXIT_OK
+}
+
+// Reports whether the argument asks for the list of subcommands.
+func isHelp(arg string) bool {
+	return arg == "help" || arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// Returns the subcommand with the passed name, or false if there is none.
+func findCommand(name string) (cliCommand, bool) {
+	for _, command := range cliCommands {
+		if command.name == name {
+			return command, true
+		}
+	}
+	return cliCommand{}, false
+}
+
+// Writes the list of subcommands and what they do.
+func (c *cli) printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: archon [command] [arguments]")
+	fmt.Fprintln(w, "\nWithout a command, the Archon window is opened. The commands are:")
+	for _, command := range cliCommands {
+		fmt.Fprintf(w, "  %-8s %s\n", command.name, command.description)
+	}
+	fmt.Fprintln(w, "\nRun \"archon <command> -h\" for the arguments of a command.")
+}
+
+// Loads the session file at the passed path. A session recovered from a backup is used after warning about the corrupt file.
+func (c *cli) load(path string) (*backend.Session, error) {
+	s, err := backend.Load(path)
+	return c.loaded(path, s, err)
+}
+
+// Loads the session file at the passed path to change and save it. Saving a session recovered from a backup replaces
+// the corrupt file, which might still be repaired by hand, so such a session is refused unless the change is forced.
+func (c *cli) loadToChange(path string) (*backend.Session, error) {
+	s, err := backend.Load(path)
+	if backend.RecoveredFromBackup(err) && !c.force {
+		return s, fmt.Errorf("%v. Nothing was changed, pass -force to change the backup and replace the corrupt file with it", err)
+	}
+	return c.loaded(path, s, err)
+}
+
+// Returns the session loaded from the passed path, warning about the corrupt file if the session was recovered from a backup.
+func (c *cli) loaded(path string, s *backend.Session, err error) (*backend.Session, error) {
+	if err != nil && !backend.RecoveredFromBackup(err) {
+		return s, err
+	}
+	if err != nil {
+		fmt.Fprintf(c.errOut, "archon: warning: %v\n", err)
+	}
+	s.Path = path
+	return s, nil
+}
+
+// Creates an empty session file. Existing files are never overwritten.
+func runNew(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		return errors.New(args[0] + " already exists")
+	}
+	s := backend.NewSession(c.title, c.number)
+	s.Path = args[0]
+	return s.Save()
+}
+
+// Adds a note to a session file. The note is read from the input if it is not passed as arguments.
+func runAdd(c *cli, args []string) error {
+	if len(args) < 1 {
+		return errUsage
+	}
+	noteTime := time.Now()
+	if c.noteTime != "" {
+		t, err := time.Parse(CLI_TIME_FORMAT, c.noteTime)
+		if err != nil {
+			return err
+		}
+		noteTime = t
+	}
+
+	content := strings.Join(args[1:], " ")
+	if len(args) == 1 {
+		data, err := io.ReadAll(c.in)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	}
+	kind, content, ok := backend.ParseKindPrefix(strings.TrimSpace(content))
+	if !ok {
+		kind = backend.NoteKind(strings.ToLower(c.kind))
+	}
+	if kind != "" && !kind.Valid() {
+		return errors.New("Unknown kind of note " + c.kind)
+	}
+	if content == "" {
+		return errors.New("Notes cannot be empty")
+	}
+
+	s, err := c.loadToChange(args[0])
+	if err != nil {
+		return err
+	}
+	note := backend.NewNote(content, noteTime)
+	note.Kind = kind
+	s.AddNote(note)
+	return s.Save()
+}
+
+// Prints the notes of a session file, one per line, prefixed by the time each was taken.
+func runList(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	s, err := c.load(args[0])
+	if err != nil {
+		return err
+	}
+	for _, note := range s.Notes {
+		if c.tag != "" && !note.HasTag(c.tag) {
+			continue
+		}
+		fmt.Fprintf(c.out, "%s  %s\n", note.Time.Format(CLI_TIME_FORMAT), note.Content)
+	}
+	return nil
+}
+
+// Exports a session file as Markdown or HTML, to a file or the output.
+func runExport(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	s, err := c.load(args[0])
+	if err != nil {
+		return err
+	}
+
+	options := []backend.ExportOption{backend.WithTimeFormat(c.timeFormat)}
+	if c.dark {
+		options = append(options, backend.WithDarkTheme())
+	}
+	var content string
+	switch strings.ToLower(c.format) {
+	case "md", "markdown":
+		content = s.ToMarkdown(options...)
+	case "html":
+		content, err = s.ToHTML(options...)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.New("Unknown export format " + c.format + ", use md or html")
+	}
+
+	if c.output == "" {
+		_, err = io.WriteString(c.out, content)
+		return err
+	}
+	UserRW := os.FileMode(0600)
+	return os.WriteFile(c.output, []byte(content), UserRW)
+}
+
+// Searches session files, directories of session files, and campaign directories, printing every matching note.
+func runSearch(c *cli, args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	query := args[0]
+	for _, path := range args[1:] {
+		results, err := c.search(path, query)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			fmt.Fprintf(c.out, "%s:%d: %s\n", result.Session.Path, result.NoteIndex+1, result.Snippet)
+		}
+	}
+	return nil
+}
+
+// Searches a single session file, directory of session files, or campaign directory.
+func (c *cli) search(path string, query string) ([]backend.SearchResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		s, err := c.load(path)
+		if err != nil {
+			return nil, err
+		}
+		return s.Search(query), nil
+	}
+	if backend.IsCampaignDir(path) {
+		campaign, err := backend.LoadCampaign(path)
+		if err != nil {
+			return nil, err
+		}
+		return backend.SearchCampaign(campaign, query)
+	}
+	return backend.SearchDir(path, query)
+}
+
+// Prints the title, number, date, note count, and tags of a session file.
+func runInfo(c *cli, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	s, err := c.load(args[0])
+	if err != nil {
+		return err
+	}
+	number := "none"
+	if s.SessionNumber > backend.NO_SESSION_NUMBER {
+		number = strconv.Itoa(s.SessionNumber)
+	}
+	fmt.Fprintf(c.out, "Title:          %s\n", s.SessionTitle)
+	fmt.Fprintf(c.out, "Number:         %s\n", number)
+	fmt.Fprintf(c.out, "Date:           %s\n", s.Date.Format(CLI_TIME_FORMAT))
+	fmt.Fprintf(c.out, "Notes:          %d\n", len(s.Notes))
+	fmt.Fprintf(c.out, "Tags:           %s\n", strings.Join(s.Tags(), ", "))
+	return nil
+}
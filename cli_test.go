@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CLI", func() {
+	var dir string
+	var path string
+	var out *bytes.Buffer
+	var errOut *bytes.Buffer
+
+	run := func(stdin string, args ...string) int {
+		out.Reset()
+		errOut.Reset()
+		return runCLI(args, strings.NewReader(stdin), out, errOut)
+	}
+
+	BeforeEach(func() {
+		dir, _ = os.MkdirTemp("", "archon-cli")
+		path = filepath.Join(dir, "session.json")
+		out = new(bytes.Buffer)
+		errOut = new(bytes.Buffer)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should only run without the GUI when passed a command", func() {
+		Expect(isCLI([]string{})).To(BeFalse())
+		Expect(isCLI([]string{"-psn_0_12345"})).To(BeFalse())
+		Expect(isCLI([]string{"list", path})).To(BeTrue())
+	})
+
+	It("should create a new session file", func() {
+		Expect(run("", "new", "-title", "Ambush", "-number", "3", path)).To(Equal(CLI_EXIT_OK))
+		s, err := backend.Load(path)
+		Expect(err).To(BeNil())
+		Expect(s.SessionTitle).To(Equal("Ambush"))
+		Expect(s.SessionNumber).To(Equal(3))
+	})
+
+	It("should not overwrite an existing session file", func() {
+		run("", "new", path)
+		Expect(run("", "new", path)).To(Equal(CLI_EXIT_ERROR))
+	})
+
+	It("should add notes from arguments and from standard input", func() {
+		run("", "new", path)
+		Expect(run("", "add", "-time", "2021-06-22T15:00:00Z", path, "Xenthe", "almost", "died")).To(Equal(CLI_EXIT_OK))
+		Expect(run("Found a wand #loot\n", "add", path)).To(Equal(CLI_EXIT_OK))
+
+		Expect(run("", "list", path)).To(Equal(CLI_EXIT_OK))
+		Expect(out.String()).To(HavePrefix("2021-06-22T15:00:00Z  Xenthe almost died\n"))
+		Expect(out.String()).To(ContainSubstring("Found a wand #loot"))
+
+		run("", "list", "-tag", "loot", path)
+		Expect(strings.Count(out.String(), "\n")).To(Equal(1))
+	})
+
+	It("should set the kind of added notes", func() {
+		run("", "new", path)
+		Expect(run("", "add", "-kind", "ooc", path, "Pizza is here")).To(Equal(CLI_EXIT_OK))
+		Expect(run("", "add", path, "loot:", "50 gold")).To(Equal(CLI_EXIT_OK))
+		Expect(run("", "add", "-kind", "gossip", path, "Pizza is here")).To(Equal(CLI_EXIT_ERROR))
+		s, _ := backend.Load(path)
+		Expect(s.Notes[0].Kind).To(Equal(backend.KIND_OOC))
+		Expect(s.Notes[1].Kind).To(Equal(backend.KIND_LOOT))
+		Expect(s.Notes[1].Content).To(Equal("50 gold"))
+	})
+
+	It("should only add notes to a session recovered from a backup when forced", func() {
+		run("", "new", path)
+		run("", "add", path, "Xenthe almost died")
+		Expect(backend.BackupPath(path, 1)).To(BeAnExistingFile())
+		Expect(os.WriteFile(path, []byte(`{"Notes": [`), 0600)).To(Succeed())
+
+		Expect(run("", "add", path, "Found a wand")).To(Equal(CLI_EXIT_ERROR))
+		Expect(errOut.String()).To(ContainSubstring("-force"))
+		data, _ := os.ReadFile(path)
+		Expect(string(data)).To(Equal(`{"Notes": [`))
+
+		Expect(run("", "add", "-force", path, "Found a wand")).To(Equal(CLI_EXIT_OK))
+		Expect(errOut.String()).To(ContainSubstring("warning"))
+		s, err := backend.Load(path)
+		Expect(err).To(BeNil())
+		Expect(s.Notes[len(s.Notes)-1].Content).To(Equal("Found a wand"))
+	})
+
+	It("should export a session", func() {
+		run("", "new", "-title", "Ambush", path)
+		run("", "add", path, "Xenthe almost died")
+		Expect(run("", "export", path)).To(Equal(CLI_EXIT_OK))
+		Expect(out.String()).To(HavePrefix("# Ambush"))
+
+		output := filepath.Join(dir, "recap.html")
+		Expect(run("", "export", "-format", "html", "-o", output, path)).To(Equal(CLI_EXIT_OK))
+		data, _ := os.ReadFile(output)
+		Expect(string(data)).To(HavePrefix("<!DOCTYPE html>"))
+	})
+
+	It("should search session files and directories", func() {
+		run("", "new", path)
+		run("", "add", path, "Xenthe almost died")
+		Expect(run("", "search", "xenthe", dir)).To(Equal(CLI_EXIT_OK))
+		Expect(out.String()).To(Equal(path + ":1: Xenthe almost died\n"))
+	})
+
+	It("should print information about a session", func() {
+		run("", "new", "-title", "Ambush", path)
+		run("", "add", path, "Found a wand #loot")
+		Expect(run("", "info", path)).To(Equal(CLI_EXIT_OK))
+		Expect(out.String()).To(ContainSubstring("Ambush"))
+		Expect(out.String()).To(ContainSubstring("Notes:          1"))
+		Expect(out.String()).To(ContainSubstring("loot"))
+	})
+
+	It("should report wrong arguments and unknown commands", func() {
+		Expect(run("", "info")).To(Equal(CLI_EXIT_USAGE))
+		Expect(errOut.String()).To(ContainSubstring("usage: archon info"))
+		Expect(run("", "frobnicate")).To(Equal(CLI_EXIT_USAGE))
+	})
+})
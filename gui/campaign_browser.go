@@ -0,0 +1,118 @@
+package gui
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// Handles the rendering for CampaignBrowsers. Implements the fyne.WidgetRenderer interface.
+type CampaignBrowserRenderer struct {
+	cont    *fyne.Container  // the container holding the heading, session list, and new session button
+	heading *widget.Label    // the label displaying the campaign name
+	list    *widget.List     // the list of sessions in the campaign
+	browser *CampaignBrowser // reference to the campaign browser being rendered
+}
+
+// The minimum size of a CampaignBrowser. Necessary to implement the fyne.WidgetRenderer interface.
+func (cbr *CampaignBrowserRenderer) MinSize() fyne.Size {
+	var min_width, min_height float32 = 300, 250
+	return cbr.cont.MinSize().Max(fyne.NewSize(min_width, min_height))
+}
+
+// Position and resize the items within the CampaignBrowser. Necessary to implement the fyne.WidgetRenderer interface.
+func (cbr *CampaignBrowserRenderer) Layout(size fyne.Size) {
+	cbr.cont.Resize(size)
+}
+
+// Triggers when the campaign changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (cbr *CampaignBrowserRenderer) Refresh() {
+	cbr.heading.SetText(cbr.browser.campaign.Name)
+	cbr.list.Refresh()
+	canvas.Refresh(cbr.cont)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (cbr *CampaignBrowserRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{cbr.cont}
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (cbr *CampaignBrowserRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A list of every session in a campaign. Implements the fyne.Widget interface.
+type CampaignBrowser struct {
+	widget.BaseWidget
+	campaign     *backend.Campaign // the campaign being browsed
+	OnSelected   func(i int)       // called with the index of a session when it is selected
+	OnNewSession func()            // called when the user asks to create a new session
+}
+
+// Creates a CampaignBrowser renderer. Necessary to implement the fyne.Widget interface.
+func (cb *CampaignBrowser) CreateRenderer() fyne.WidgetRenderer {
+	heading := widget.NewLabelWithStyle(cb.campaign.Name, fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	list := widget.NewList(
+		func() int {
+			return len(cb.campaign.Sessions)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(CampaignEntryText(cb.campaign.Sessions[i]))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		list.Unselect(i)
+		if cb.OnSelected != nil {
+			cb.OnSelected(i)
+		}
+	}
+	newButton := widget.NewButtonWithIcon("New session", theme.ContentAddIcon(), func() {
+		if cb.OnNewSession != nil {
+			cb.OnNewSession()
+		}
+	})
+
+	return &CampaignBrowserRenderer{
+		cont:    container.NewBorder(heading, newButton, nil, nil, list),
+		heading: heading,
+		list:    list,
+		browser: cb,
+	}
+}
+
+// Sets the campaign being browsed.
+func (cb *CampaignBrowser) SetCampaign(campaign *backend.Campaign) {
+	cb.campaign = campaign
+	cb.Refresh()
+}
+
+// Builds the text describing a single session of a campaign.
+func CampaignEntryText(entry backend.CampaignEntry) string {
+	text := entry.Date.Format("1/2/2006")
+	if entry.SessionNumber > backend.NO_SESSION_NUMBER {
+		text += " Session " + strconv.Itoa(entry.SessionNumber)
+		if entry.SessionTitle != "" {
+			text += ":"
+		}
+	}
+	if entry.SessionTitle != "" {
+		text += " " + entry.SessionTitle
+	}
+	return text
+}
+
+// Creates a new CampaignBrowser listing the sessions of the passed campaign.
+func NewCampaignBrowser(campaign *backend.Campaign) *CampaignBrowser {
+	cb := &CampaignBrowser{campaign: campaign}
+	cb.ExtendBaseWidget(cb)
+	return cb
+}
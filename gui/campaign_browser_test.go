@@ -0,0 +1,38 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CampaignBrowser widget", func() {
+	It("should render without crashing", func() {
+		browser := NewCampaignBrowser(backend.NewCampaign("Calimport", ""))
+		render := func() {
+			test.NewWindow(browser)
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should describe sessions by date, number, and title", func() {
+		entry := backend.CampaignEntry{
+			SessionTitle:  "The Conquest at Calimport",
+			SessionNumber: 3,
+			Date:          time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC),
+		}
+		Expect(CampaignEntryText(entry)).To(Equal("6/22/2021 Session 3: The Conquest at Calimport"))
+	})
+
+	It("should leave out the session number of unnumbered sessions", func() {
+		entry := backend.CampaignEntry{
+			SessionTitle:  "Side quest",
+			SessionNumber: backend.NO_SESSION_NUMBER,
+			Date:          time.Date(2021, time.June, 22, 15, 0, 0, 0, time.UTC),
+		}
+		Expect(CampaignEntryText(entry)).To(Equal("6/22/2021 Side quest"))
+	})
+})
@@ -0,0 +1,220 @@
+package gui
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// Marks the combatant whose turn it is.
+const TURN_MARKER = "▶"
+
+// Shown in place of the conditions of a combatant that has none.
+const NO_CONDITIONS = "No conditions"
+
+// Handles the rendering for CombatPanels. Implements the fyne.WidgetRenderer interface.
+type CombatPanelRenderer struct {
+	cont        *fyne.Container // the container holding the heading, combatant list, and buttons
+	heading     *widget.Label   // the label displaying the current round
+	list        *widget.List    // the list of combatants in initiative order
+	startButton *widget.Button  // the button starting or ending combat
+	nextButton  *widget.Button  // the button ending the current turn
+	addButton   *widget.Button  // the button adding a combatant
+	panel       *CombatPanel    // reference to the combat panel being rendered
+}
+
+// The minimum size of a CombatPanel. Necessary to implement the fyne.WidgetRenderer interface.
+func (cpr *CombatPanelRenderer) MinSize() fyne.Size {
+	var min_width, min_height float32 = 280, 200
+	return cpr.cont.MinSize().Max(fyne.NewSize(min_width, min_height))
+}
+
+// Position and resize the items within the CombatPanel. Necessary to implement the fyne.WidgetRenderer interface.
+func (cpr *CombatPanelRenderer) Layout(size fyne.Size) {
+	cpr.cont.Resize(size)
+}
+
+// Triggers when the combat changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (cpr *CombatPanelRenderer) Refresh() {
+	combat := cpr.panel.combat
+	cpr.heading.SetText(RoundText(combat))
+	if combat == nil {
+		cpr.startButton.SetText("Start combat")
+		cpr.startButton.SetIcon(theme.MediaPlayIcon())
+		cpr.nextButton.Disable()
+		cpr.addButton.Disable()
+	} else {
+		cpr.startButton.SetText("End combat")
+		cpr.startButton.SetIcon(theme.MediaStopIcon())
+		cpr.nextButton.Enable()
+		cpr.addButton.Enable()
+	}
+	cpr.list.Refresh()
+	canvas.Refresh(cpr.cont)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (cpr *CombatPanelRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{cpr.cont}
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (cpr *CombatPanelRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A side panel tracking a fight: the combatants in initiative order with their hit points and conditions, and the round.
+// Implements the fyne.Widget interface.
+type CombatPanel struct {
+	widget.BaseWidget
+	combat       *backend.Combat // the fight being tracked, or nil if there is none
+	OnStart      func()          // called when the user asks to start combat, if set
+	OnEnd        func()          // called when the user asks to end combat, if set
+	OnNextTurn   func()          // called when the user ends the current turn, if set
+	OnAdd        func()          // called when the user asks to add a combatant, if set
+	OnRemove     func(i int)     // called with the index of a combatant the user removes, if set
+	OnHP         func(i int)     // called with the index of a combatant whose hit points the user changes, if set
+	OnConditions func(i int)     // called with the index of a combatant whose conditions the user changes, if set
+}
+
+// Creates a CombatPanel renderer. Necessary to implement the fyne.Widget interface.
+func (cp *CombatPanel) CreateRenderer() fyne.WidgetRenderer {
+	heading := widget.NewLabelWithStyle(RoundText(cp.combat), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	list := widget.NewList(
+		func() int {
+			if cp.combat == nil {
+				return 0
+			}
+			return len(cp.combat.Combatants)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabel("")
+			hp := widget.NewButton("", nil)
+			conditions := widget.NewButton("", nil)
+			remove := widget.NewButtonWithIcon("", theme.DeleteIcon(), nil)
+			return container.NewBorder(nil, nil, nil, container.NewHBox(hp, conditions, remove), name)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			cp.updateRow(i, o.(*fyne.Container))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		list.Unselect(i)
+	}
+
+	startButton := widget.NewButtonWithIcon("", theme.MediaPlayIcon(), func() {
+		if cp.combat == nil && cp.OnStart != nil {
+			cp.OnStart()
+		} else if cp.combat != nil && cp.OnEnd != nil {
+			cp.OnEnd()
+		}
+	})
+	nextButton := widget.NewButtonWithIcon("Next turn", theme.MediaSkipNextIcon(), func() {
+		if cp.OnNextTurn != nil {
+			cp.OnNextTurn()
+		}
+	})
+	addButton := widget.NewButtonWithIcon("Add combatant", theme.ContentAddIcon(), func() {
+		if cp.OnAdd != nil {
+			cp.OnAdd()
+		}
+	})
+
+	cpr := &CombatPanelRenderer{
+		cont: container.NewBorder(
+			container.NewVBox(heading, container.NewHBox(startButton, nextButton)),
+			addButton,
+			nil,
+			nil,
+			list,
+		),
+		heading:     heading,
+		list:        list,
+		startButton: startButton,
+		nextButton:  nextButton,
+		addButton:   addButton,
+		panel:       cp,
+	}
+	cpr.Refresh()
+	return cpr
+}
+
+// Sets a row of the list to display the combatant at index i.
+func (cp *CombatPanel) updateRow(i int, row *fyne.Container) {
+	combatant := cp.combat.Combatants[i]
+	buttons := row.Objects[1].(*fyne.Container).Objects
+	row.Objects[0].(*widget.Label).SetText(CombatantText(combatant, i == cp.combat.Turn))
+
+	hp := buttons[0].(*widget.Button)
+	hp.SetText(HPText(combatant))
+	hp.OnTapped = func() {
+		if cp.OnHP != nil {
+			cp.OnHP(i)
+		}
+	}
+	conditions := buttons[1].(*widget.Button)
+	conditions.SetText(ConditionsText(combatant))
+	conditions.OnTapped = func() {
+		if cp.OnConditions != nil {
+			cp.OnConditions(i)
+		}
+	}
+	buttons[2].(*widget.Button).OnTapped = func() {
+		if cp.OnRemove != nil {
+			cp.OnRemove(i)
+		}
+	}
+}
+
+// Sets the fight being tracked, or nil if there is none.
+func (cp *CombatPanel) SetCombat(combat *backend.Combat) {
+	cp.combat = combat
+	cp.Refresh()
+}
+
+// Builds the text describing the round of a fight.
+func RoundText(combat *backend.Combat) string {
+	if combat == nil {
+		return "No combat"
+	}
+	return "Round " + strconv.Itoa(combat.Round)
+}
+
+// Builds the text naming a combatant and its initiative, like "▶ Goblin 1 (18)" if it is its turn.
+func CombatantText(combatant backend.Combatant, current bool) string {
+	text := combatant.Name + " (" + strconv.Itoa(combatant.Initiative) + ")"
+	if current {
+		text = TURN_MARKER + " " + text
+	}
+	return text
+}
+
+// Builds the text describing the hit points of a combatant, like "7/12 HP".
+func HPText(combatant backend.Combatant) string {
+	text := strconv.Itoa(combatant.HP)
+	if combatant.MaxHP > 0 {
+		text += "/" + strconv.Itoa(combatant.MaxHP)
+	}
+	return text + " HP"
+}
+
+// Builds the text listing the conditions of a combatant.
+func ConditionsText(combatant backend.Combatant) string {
+	if len(combatant.Conditions) == 0 {
+		return NO_CONDITIONS
+	}
+	return strings.Join(combatant.Conditions, ", ")
+}
+
+// Creates a new CombatPanel tracking the passed fight, or nil if there is none.
+func NewCombatPanel(combat *backend.Combat) *CombatPanel {
+	cp := &CombatPanel{combat: combat}
+	cp.ExtendBaseWidget(cp)
+	return cp
+}
@@ -0,0 +1,64 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CombatPanel widget", func() {
+	It("should render without crashing", func() {
+		render := func() {
+			test.NewWindow(NewCombatPanel(nil))
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should render a combat without crashing", func() {
+		combat := backend.NewCombat()
+		combat.AddCombatant(backend.Combatant{Name: "Goblin 1", Initiative: 18, HP: 7, MaxHP: 7})
+		render := func() {
+			test.NewWindow(NewCombatPanel(combat))
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should describe the round", func() {
+		Expect(RoundText(nil)).To(Equal("No combat"))
+		combat := backend.NewCombat()
+		combat.NextTurn()
+		Expect(RoundText(combat)).To(Equal("Round 1"))
+	})
+
+	It("should mark the combatant whose turn it is", func() {
+		goblin := backend.Combatant{Name: "Goblin 1", Initiative: 18}
+		Expect(CombatantText(goblin, false)).To(Equal("Goblin 1 (18)"))
+		Expect(CombatantText(goblin, true)).To(Equal(TURN_MARKER + " Goblin 1 (18)"))
+	})
+
+	It("should describe hit points and conditions", func() {
+		goblin := backend.Combatant{Name: "Goblin 1", HP: 3, MaxHP: 7}
+		Expect(HPText(goblin)).To(Equal("3/7 HP"))
+		Expect(ConditionsText(goblin)).To(Equal(NO_CONDITIONS))
+		goblin.Conditions = []string{"poisoned", "prone"}
+		Expect(ConditionsText(goblin)).To(Equal("poisoned, prone"))
+	})
+
+	It("should notify when combat is started", func() {
+		panel := NewCombatPanel(nil)
+		w := test.NewWindow(panel)
+		started := false
+		panel.OnStart = func() {
+			started = true
+		}
+		w.Resize(panel.MinSize())
+		renderer := test.WidgetRenderer(panel).(*CombatPanelRenderer)
+		test.Tap(renderer.startButton)
+		Expect(started).To(BeTrue())
+		Expect(renderer.nextButton.Disabled()).To(BeTrue())
+
+		panel.SetCombat(backend.NewCombat())
+		Expect(renderer.nextButton.Disabled()).To(BeFalse())
+	})
+})
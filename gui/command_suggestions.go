@@ -0,0 +1,120 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// The most commands suggested at once.
+const MAX_SUGGESTIONS = 6
+
+// Handles the rendering for CommandSuggestions. Implements the fyne.WidgetRenderer interface.
+type CommandSuggestionsRenderer struct {
+	background *canvas.Rectangle   // the rectangle behind the suggestions
+	box        *fyne.Container     // the container stacking a button for each suggestion
+	objects    []fyne.CanvasObject // a list of the objects declared above
+	list       *CommandSuggestions // reference to the command suggestions being rendered
+}
+
+// The minimum size of CommandSuggestions, large enough to fit every suggestion. Necessary to implement the fyne.WidgetRenderer interface.
+func (csr *CommandSuggestionsRenderer) MinSize() fyne.Size {
+	return csr.box.MinSize()
+}
+
+// Position and resize the items within the CommandSuggestions. Necessary to implement the fyne.WidgetRenderer interface.
+func (csr *CommandSuggestionsRenderer) Layout(size fyne.Size) {
+	csr.background.Resize(size)
+	csr.box.Resize(size)
+}
+
+// Triggers when the suggestions change or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (csr *CommandSuggestionsRenderer) Refresh() {
+	csr.background.FillColor = theme.BackgroundColor()
+	objects := make([]fyne.CanvasObject, 0, len(csr.list.suggestions))
+	for _, command := range csr.list.suggestions {
+		command := command
+		button := widget.NewButton(SuggestionText(command), func() {
+			if csr.list.OnChosen != nil {
+				csr.list.OnChosen(command)
+			}
+		})
+		button.Alignment = widget.ButtonAlignLeading
+		button.Importance = widget.LowImportance
+		objects = append(objects, button)
+	}
+	csr.box.Objects = objects
+	csr.Layout(csr.list.Size())
+	canvas.Refresh(csr.list)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (csr *CommandSuggestionsRenderer) Objects() []fyne.CanvasObject {
+	return csr.objects
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (csr *CommandSuggestionsRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A list of the slash commands that the text being typed could be running, shown above the entry field.
+// Hidden while there is nothing to suggest. Implements the fyne.Widget interface.
+type CommandSuggestions struct {
+	widget.BaseWidget
+	suggestions []backend.SlashCommand             // the commands suggested, at most MAX_SUGGESTIONS
+	OnChosen    func(command backend.SlashCommand) // called with a suggested command when it is tapped, if set
+}
+
+// Creates a CommandSuggestions renderer. Necessary to implement the fyne.Widget interface.
+func (cs *CommandSuggestions) CreateRenderer() fyne.WidgetRenderer {
+	background := canvas.NewRectangle(theme.BackgroundColor())
+	box := container.NewVBox()
+	csr := &CommandSuggestionsRenderer{
+		background: background,
+		box:        box,
+		objects:    []fyne.CanvasObject{background, box},
+		list:       cs,
+	}
+	csr.Refresh()
+	return csr
+}
+
+// Replaces the suggested commands, hiding the list if there are none.
+func (cs *CommandSuggestions) SetSuggestions(suggestions []backend.SlashCommand) {
+	if len(suggestions) > MAX_SUGGESTIONS {
+		suggestions = suggestions[:MAX_SUGGESTIONS]
+	}
+	cs.suggestions = suggestions
+	if len(suggestions) == 0 {
+		cs.Hide()
+	} else {
+		cs.Show()
+	}
+	cs.Refresh()
+}
+
+// Returns the suggested commands.
+func (cs *CommandSuggestions) Suggestions() []backend.SlashCommand {
+	return cs.suggestions
+}
+
+// Returns how a command is described when suggested, like "/session <number> — Change the number of the session".
+func SuggestionText(command backend.SlashCommand) string {
+	text := backend.SLASH_PREFIX + command.Name()
+	if command.Usage() != "" {
+		text += " " + command.Usage()
+	}
+	return text + " — " + command.Description()
+}
+
+// Creates new, hidden CommandSuggestions.
+func NewCommandSuggestions() *CommandSuggestions {
+	cs := &CommandSuggestions{suggestions: make([]backend.SlashCommand, 0)}
+	cs.ExtendBaseWidget(cs)
+	cs.Hide()
+	return cs
+}
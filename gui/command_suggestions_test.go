@@ -0,0 +1,59 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CommandSuggestions widget", func() {
+	var suggestions *CommandSuggestions
+	var commands []backend.SlashCommand
+
+	BeforeEach(func() {
+		suggestions = NewCommandSuggestions()
+		commands = backend.DefaultSlashCommands(backend.NewRoller())
+	})
+
+	It("should render without crashing", func() {
+		render := func() {
+			test.NewWindow(suggestions)
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should be hidden while there is nothing to suggest", func() {
+		Expect(suggestions.Visible()).To(BeFalse())
+		suggestions.SetSuggestions(commands)
+		Expect(suggestions.Visible()).To(BeTrue())
+		suggestions.SetSuggestions(nil)
+		Expect(suggestions.Visible()).To(BeFalse())
+	})
+
+	It("should suggest at most MAX_SUGGESTIONS commands", func() {
+		for len(commands) <= MAX_SUGGESTIONS {
+			commands = append(commands, commands[0])
+		}
+		suggestions.SetSuggestions(commands)
+		Expect(suggestions.Suggestions()).To(HaveLen(MAX_SUGGESTIONS))
+	})
+
+	It("should describe commands with their usage", func() {
+		command := backend.NewSlashCommand("session", "<number>", "Change the number of the session", nil)
+		Expect(SuggestionText(command)).To(Equal("/session <number> — Change the number of the session"))
+	})
+
+	It("should notify when a suggestion is tapped", func() {
+		w := test.NewWindow(suggestions)
+		var chosen backend.SlashCommand
+		suggestions.OnChosen = func(command backend.SlashCommand) {
+			chosen = command
+		}
+		suggestions.SetSuggestions(commands[:1])
+		w.Resize(suggestions.MinSize().Max(w.Canvas().Size()))
+		test.TapCanvas(w.Canvas(), fyne.NewPos(10, 10))
+		Expect(chosen).To(Equal(commands[0]))
+	})
+})
@@ -1,47 +1,209 @@
 package gui
 
 import (
+	"strings"
 	"time"
 
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/widget"
 	"github.com/archon/backend"
 )
 
-// An Entry field that submits some text when the Enter key is pressed while this is focused. Multiline by default.
+// An Entry field that submits some text when the Enter key is pressed while this is focused.
+// Multiline by default, Shift+Enter starts a new line.
 type EnterEntry struct {
 	widget.Entry
-	session *backend.Session // a session state that this entry is allowed to modify
+	shift       shiftState                               // whether Shift is held down
+	session     *backend.Session                         // a session state that this entry is allowed to modify
+	shortcuts   fyne.ShortcutHandler                     // custom shortcuts handled while this entry is focused
+	OnNoteAdded func()                                   // called after a note has been added to the session, if set
+	Kind        backend.NoteKind                         // the kind of the notes added, unless a note starts with a kind prefix
+	OnError     func(err error)                          // called when the text cannot be added as a note, such as an invalid roll, if set
+	OnCommand   func()                                   // called after a slash command has been run, if set
+	OnSuggest   func(suggestions []backend.SlashCommand) // called with the commands the text could be running whenever it changes, if set
+	roller      *backend.Roller                          // rolls the dice written in notes
+	commands    *backend.CommandRegistry                 // the slash commands that can be typed into this entry
 }
 
 // Handler for enter key presses. Clears the text in the entry.
+// A kind prefix at the start of the text, like "loot:", sets the kind of the note and is removed from its content.
+// Dice written after /roll or between double brackets, like [[1d20+5]], are rolled and their results recorded on the note.
+// Text starting with a slash, like "/session 12", runs a command instead of adding a note.
+// If the dice cannot be rolled or the command fails, the text is kept and OnError is called.
 func (e *EnterEntry) onEnter() {
-	e.session.AddNote(backend.NewNote(e.Text, time.Now()))
+	kind, content, ok := backend.ParseKindPrefix(strings.TrimSpace(e.Text))
+	if !ok {
+		kind = e.Kind
+	}
+	if backend.IsSlashCommand(content) {
+		e.runCommand(content, kind)
+		return
+	}
+	content = backend.UnescapeSlash(content)
+	content, rolls, err := e.roller.RollNote(content)
+	if err != nil {
+		if e.OnError != nil {
+			e.OnError(err)
+		}
+		return
+	}
+	note := backend.NewNote(content, time.Now())
+	note.Kind = kind
+	if len(rolls) > 0 {
+		note.Rolls = rolls
+	}
+	if err := e.session.History().Execute(&backend.AddNoteCommand{Note: note}); err != nil {
+		// an empty note is not added, which is not worth reporting
+		if err != backend.ErrNoChange && e.OnError != nil {
+			e.OnError(err)
+		}
+		return
+	}
+	e.Entry.SetText("")
+	if e.OnNoteAdded != nil {
+		e.OnNoteAdded()
+	}
+}
+
+// Runs the command typed into the entry. Notes the command adds are of the passed kind.
+func (e *EnterEntry) runCommand(text string, kind backend.NoteKind) {
+	length := len(e.session.Notes)
+	if err := e.commands.Run(e.session, text, kind); err != nil {
+		if e.OnError != nil {
+			e.OnError(err)
+		}
+		return
+	}
 	e.Entry.SetText("")
+	if e.OnCommand != nil {
+		e.OnCommand()
+	}
+	if len(e.session.Notes) > length && e.OnNoteAdded != nil {
+		e.OnNoteAdded()
+	}
+}
+
+// Suggests the commands the text could be running.
+func (e *EnterEntry) suggest(text string) {
+	if e.OnSuggest != nil {
+		e.OnSuggest(e.commands.Suggest(text))
+	}
+}
+
+// Replaces the text with the name of the passed command, ready for its arguments to be typed.
+func (e *EnterEntry) Complete(command backend.SlashCommand) {
+	text := backend.SLASH_PREFIX + command.Name()
+	if command.Usage() != "" {
+		text += " "
+	}
+	e.Entry.SetText(text)
+	e.CursorRow = 0
+	e.CursorColumn = len(text)
+	e.Refresh()
+}
+
+// Returns the slash commands that can be typed into this entry. Register commands with it to make them available.
+func (e *EnterEntry) Commands() *backend.CommandRegistry {
+	return e.commands
+}
+
+// Overrides the KeyDown method of the desktop.Keyable interface to track whether Shift is held down.
+func (e *EnterEntry) KeyDown(key *fyne.KeyEvent) {
+	e.shift.keyDown(key)
+	e.Entry.KeyDown(key)
+}
+
+// Overrides the KeyUp method of the desktop.Keyable interface to track whether Shift is held down.
+func (e *EnterEntry) KeyUp(key *fyne.KeyEvent) {
+	e.shift.keyUp(key)
+	e.Entry.KeyUp(key)
+}
+
+// Overrides the FocusLost method of the fyne.Focusable interface. Shift released while the entry was not focused
+// is never reported, so it is no longer counted as held.
+func (e *EnterEntry) FocusLost() {
+	e.shift.reset()
+	e.Entry.FocusLost()
 }
 
 // Overrides the TypedKey method of the fyne.Focusable interface.
+// Enter adds the note, while Shift+Enter starts a new line within it.
 func (e *EnterEntry) TypedKey(key *fyne.KeyEvent) {
 	switch key.Name {
-	case fyne.KeyEnter:
-		e.onEnter()
-	case fyne.KeyReturn:
+	case fyne.KeyEnter, fyne.KeyReturn:
+		if e.shift.held {
+			e.Entry.TypedKey(key)
+			return
+		}
 		e.onEnter()
 	default:
 		e.Entry.TypedKey(key)
 	}
 }
 
+// Overrides the TypedShortcut method of the fyne.Shortcutable interface.
+// Custom shortcuts are handled by the shortcuts added to this entry, the rest by the underlying Entry.
+func (e *EnterEntry) TypedShortcut(shortcut fyne.Shortcut) {
+	if _, ok := shortcut.(*desktop.CustomShortcut); ok {
+		e.shortcuts.TypedShortcut(shortcut)
+		return
+	}
+	e.Entry.TypedShortcut(shortcut)
+}
+
+// Registers a handler for a custom shortcut typed while this entry is focused.
+// Focused widgets receive shortcuts before the window canvas does, so window-wide shortcuts must also be added here.
+func (e *EnterEntry) AddShortcut(shortcut fyne.Shortcut, handler func(shortcut fyne.Shortcut)) {
+	e.shortcuts.AddShortcut(shortcut, handler)
+}
+
 // Sets this entry's seesion to the passed session.
 func (e *EnterEntry) SetSession(session *backend.Session) {
 	e.session = session
 }
 
+// Sets the roller used to roll the dice written in notes, such as a seeded roller for repeatable rolls.
+func (e *EnterEntry) SetRoller(roller *backend.Roller) {
+	e.roller = roller
+	e.commands.Register(backend.NewRollCommand(roller))
+}
+
 // Creates a new Entry that adds a note to the passed session when Enter is pressed.
 func NewEnterEntry(session *backend.Session) *EnterEntry {
-	entry := &EnterEntry{session: session}
+	roller := backend.NewRoller()
+	entry := &EnterEntry{
+		session:  session,
+		roller:   roller,
+		commands: backend.NewCommandRegistry(backend.DefaultSlashCommands(roller)...),
+	}
+	entry.OnChanged = entry.suggest
 	entry.Entry.MultiLine = true
 	entry.Entry.Wrapping = fyne.TextWrapWord
 	entry.ExtendBaseWidget(entry)
 	return entry
 }
+
+// Tracks whether either Shift key is held down, from the key events of a focused widget.
+type shiftState struct {
+	held bool // whether Shift is held down
+}
+
+// Records a key being pressed.
+func (s *shiftState) keyDown(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		s.held = true
+	}
+}
+
+// Records a key being released.
+func (s *shiftState) keyUp(key *fyne.KeyEvent) {
+	if key.Name == desktop.KeyShiftLeft || key.Name == desktop.KeyShiftRight {
+		s.held = false
+	}
+}
+
+// Forgets that Shift is held down, as when the widget loses focus and stops receiving key events.
+func (s *shiftState) reset() {
+	s.held = false
+}
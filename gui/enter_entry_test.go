@@ -2,6 +2,7 @@ package gui
 
 import (
 	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/test"
 	"github.com/archon/backend"
 	. "github.com/onsi/ginkgo"
@@ -53,4 +54,176 @@ var _ = Describe("EnterEntry widget", func() {
 		test.Type(entry, note)
 		Expect(session.Notes).To(BeEmpty())
 	})
+
+	It("should notify when a note has been added", func() {
+		test.NewWindow(entry)
+		added := false
+		entry.OnNoteAdded = func() {
+			added = true
+		}
+		test.Type(entry, "Hello world!")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(added).To(BeTrue())
+	})
+
+	It("should add notes that can be undone", func() {
+		test.NewWindow(entry)
+		test.Type(entry, "Hello world!")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		session.History().Undo()
+		Expect(session.Notes).To(BeEmpty())
+	})
+
+	It("should run custom shortcuts added to it", func() {
+		test.NewWindow(entry)
+		shortcut := &desktop.CustomShortcut{KeyName: fyne.KeyZ, Modifier: desktop.ControlModifier}
+		ran := false
+		entry.AddShortcut(shortcut, func(fyne.Shortcut) {
+			ran = true
+		})
+		entry.TypedShortcut(shortcut)
+		Expect(ran).To(BeTrue())
+	})
+
+	It("should give notes the kind chosen for the entry", func() {
+		test.NewWindow(entry)
+		entry.Kind = backend.KIND_DIALOGUE
+		test.Type(entry, "Well met")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes[0].Kind).To(Equal(backend.KIND_DIALOGUE))
+	})
+
+	It("should give notes the kind of their prefix", func() {
+		test.NewWindow(entry)
+		entry.Kind = backend.KIND_DIALOGUE
+		test.Type(entry, "loot: 50 gold")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes[0].Kind).To(Equal(backend.KIND_LOOT))
+		Expect(session.Notes[0].Content).To(Equal("50 gold"))
+	})
+
+	It("should record the dice rolled with /roll", func() {
+		w := test.NewWindow(entry)
+		w.Resize(fyne.NewSize(400, 200))
+		entry.SetRoller(backend.NewRoller(backend.WithSeed(4)))
+		test.Type(entry, "/roll 4d6kh3")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes[0].Rolls).To(HaveLen(1))
+		Expect(session.Notes[0].Rolls[0].Dice).To(HaveLen(4))
+		Expect(session.Notes[0].Content).To(Equal("Rolled " + session.Notes[0].Rolls[0].String()))
+	})
+
+	It("should not notify when an empty note is entered", func() {
+		test.NewWindow(entry)
+		added := false
+		entry.OnNoteAdded = func() {
+			added = true
+		}
+		reported := false
+		entry.OnError = func(error) {
+			reported = true
+		}
+		test.Type(entry, "   ")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(added).To(BeFalse())
+		Expect(reported).To(BeFalse())
+		Expect(session.Notes).To(BeEmpty())
+	})
+
+	It("should keep the text and report an error when the dice cannot be rolled", func() {
+		test.NewWindow(entry)
+		var reported error
+		entry.OnError = func(err error) {
+			reported = err
+		}
+		test.Type(entry, "/roll lots")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(reported).ToNot(BeNil())
+		Expect(session.Notes).To(BeEmpty())
+		Expect(entry.Text).To(Equal("/roll lots"))
+	})
+
+	It("should run slash commands instead of adding notes", func() {
+		w := test.NewWindow(entry)
+		w.Resize(fyne.NewSize(400, 200))
+		ran := false
+		entry.OnCommand = func() {
+			ran = true
+		}
+		test.Type(entry, "/session 12")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(ran).To(BeTrue())
+		Expect(session.SessionNumber).To(Equal(12))
+		Expect(session.Notes).To(BeEmpty())
+		Expect(entry.Text).To(BeEmpty())
+	})
+
+	It("should keep the text and report an error for unknown commands", func() {
+		test.NewWindow(entry)
+		var reported error
+		entry.OnError = func(err error) {
+			reported = err
+		}
+		test.Type(entry, "/teleport")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(reported).ToNot(BeNil())
+		Expect(entry.Text).To(Equal("/teleport"))
+	})
+
+	It("should add a note starting with a slash when the slash is escaped", func() {
+		test.NewWindow(entry)
+		test.Type(entry, "//shrug")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes).To(HaveLen(1))
+		Expect(session.Notes[0].Content).To(Equal("/shrug"))
+	})
+
+	It("should suggest commands while one is typed", func() {
+		test.NewWindow(entry)
+		var suggested []backend.SlashCommand
+		entry.OnSuggest = func(suggestions []backend.SlashCommand) {
+			suggested = suggestions
+		}
+		test.Type(entry, "/ti")
+		Expect(suggested).To(HaveLen(1))
+		Expect(suggested[0].Name()).To(Equal("title"))
+
+		entry.Complete(suggested[0])
+		Expect(entry.Text).To(Equal("/title "))
+	})
+
+	It("should run commands registered with it", func() {
+		test.NewWindow(entry)
+		entry.Commands().Register(backend.NewSlashCommand("rest", "", "Take a long rest", func(call backend.SlashCall) error {
+			return call.Session.History().Execute(&backend.SetTitleCommand{Title: "Rested"})
+		}))
+		test.Type(entry, "/rest")
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.SessionTitle).To(Equal("Rested"))
+	})
+
+	It("should start a new line instead of adding the note when Shift+Enter is pressed", func() {
+		w := test.NewWindow(entry)
+		w.Resize(fyne.NewSize(400, 200))
+		test.Type(entry, "First line")
+		entry.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		entry.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+		test.Type(entry, "Second line")
+		Expect(session.Notes).To(BeEmpty())
+
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes[0].Content).To(Equal("First line\nSecond line"))
+	})
+
+	It("should forget that Shift is held when focus leaves", func() {
+		w := test.NewWindow(entry)
+		w.Resize(fyne.NewSize(400, 200))
+		test.Type(entry, "Xenthe almost died")
+		entry.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+		entry.FocusLost()
+		entry.FocusGained()
+		entry.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(session.Notes).To(HaveLen(1))
+	})
 })
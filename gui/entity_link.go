@@ -0,0 +1,93 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Handles the rendering for EntityLinks. Implements the fyne.WidgetRenderer interface.
+type EntityLinkRenderer struct {
+	text      *canvas.Text        // the text of the mention
+	underline *canvas.Rectangle   // the line drawn beneath the text
+	objects   []fyne.CanvasObject // a list of the objects declared above
+	link      *EntityLink         // reference to the entity link being rendered
+}
+
+// The minimum size of an EntityLink, large enough to fit its text. Necessary to implement the fyne.WidgetRenderer interface.
+func (elr *EntityLinkRenderer) MinSize() fyne.Size {
+	return elr.text.MinSize()
+}
+
+// Position and resize the items within the EntityLink. Necessary to implement the fyne.WidgetRenderer interface.
+func (elr *EntityLinkRenderer) Layout(size fyne.Size) {
+	elr.text.Resize(size)
+	elr.underline.Move(fyne.NewPos(0, size.Height-1))
+	elr.underline.Resize(fyne.NewSize(size.Width, 1))
+}
+
+// Triggers when the EntityLink changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (elr *EntityLinkRenderer) Refresh() {
+	elr.text.Text = elr.link.text
+	elr.text.Color = theme.PrimaryColor()
+	elr.underline.FillColor = theme.PrimaryColor()
+	elr.Layout(elr.link.Size())
+	canvas.Refresh(elr.link)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (elr *EntityLinkRenderer) Objects() []fyne.CanvasObject {
+	return elr.objects
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (elr *EntityLinkRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A mention of an entity within a note, drawn as a link to the entity. Links written in Markdown are also drawn as EntityLinks.
+// Implements the fyne.Widget, fyne.Tappable, and desktop.Cursorable interfaces.
+type EntityLink struct {
+	widget.BaseWidget
+	text     string              // the text of the mention, as written in the note
+	entity   string              // the name of the entity mentioned, or the address of a Markdown link
+	OnTapped func(entity string) // called with the name of the entity when the link is tapped, if set
+}
+
+// Creates an EntityLink renderer. Necessary to implement the fyne.Widget interface.
+func (el *EntityLink) CreateRenderer() fyne.WidgetRenderer {
+	text := canvas.NewText(el.text, theme.PrimaryColor())
+	underline := canvas.NewRectangle(theme.PrimaryColor())
+	return &EntityLinkRenderer{
+		text:      text,
+		underline: underline,
+		objects:   []fyne.CanvasObject{text, underline},
+		link:      el,
+	}
+}
+
+// Handles taps on the link. Necessary to implement the fyne.Tappable interface.
+func (el *EntityLink) Tapped(*fyne.PointEvent) {
+	if el.OnTapped != nil {
+		el.OnTapped(el.entity)
+	}
+}
+
+// Shows a pointer when the mouse is over the link. Necessary to implement the desktop.Cursorable interface.
+func (el *EntityLink) Cursor() desktop.Cursor {
+	return desktop.PointerCursor
+}
+
+// Returns the name of the entity the link leads to.
+func (el *EntityLink) Entity() string {
+	return el.entity
+}
+
+// Creates a new EntityLink displaying the passed text and leading to the entity with the passed name.
+func NewEntityLink(text string, entity string) *EntityLink {
+	el := &EntityLink{text: text, entity: entity}
+	el.ExtendBaseWidget(el)
+	return el
+}
@@ -0,0 +1,27 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EntityLink widget", func() {
+	It("should render without crashing", func() {
+		link := NewEntityLink("Aust", "Aust Redwyn")
+		render := func() {
+			test.NewWindow(link)
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should report the entity it leads to when tapped", func() {
+		link := NewEntityLink("Aust", "Aust Redwyn")
+		tapped := ""
+		link.OnTapped = func(entity string) {
+			tapped = entity
+		}
+		test.Tap(link)
+		Expect(tapped).To(Equal("Aust Redwyn"))
+	})
+})
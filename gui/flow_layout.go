@@ -0,0 +1,92 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// Describes how a piece of wrapped text is separated from the piece before it.
+type flowToken struct {
+	breaks int     // the number of line breaks before the piece
+	space  bool    // whether a space separates the piece from the one before it on the same line
+	indent float32 // how far the line is indented, if the piece starts a line
+	hang   float32 // how far the lines the line wraps onto are indented, if the piece starts a line
+}
+
+// Lays out pieces of text like a paragraph, left to right, wrapping onto a new line when a piece does not fit.
+// Each object is described by the token at the same index.
+type flowLayout struct {
+	tokens []flowToken // how each object is separated from the one before it
+}
+
+// Positions every object at its minimum size, wrapping within the width of the container. Necessary to implement the fyne.Layout interface.
+func (fl *flowLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	positions, _ := fl.arrange(objects, size.Width)
+	for i, object := range objects {
+		object.Move(positions[i])
+		object.Resize(object.MinSize())
+	}
+}
+
+// The width of the widest object and the height of a single line. Necessary to implement the fyne.Layout interface.
+// The height of the wrapped text depends on its width, see heightForWidth.
+func (fl *flowLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	var width float32
+	for _, object := range objects {
+		width = fyne.Max(width, object.MinSize().Width)
+	}
+	return fyne.NewSize(width, fl.lineHeight(objects))
+}
+
+// Returns the height of the objects when wrapped within the passed width.
+func (fl *flowLayout) heightForWidth(objects []fyne.CanvasObject, width float32) float32 {
+	_, height := fl.arrange(objects, width)
+	return height
+}
+
+// Returns the position of every object when wrapped within the passed width, and the height of the wrapped text.
+func (fl *flowLayout) arrange(objects []fyne.CanvasObject, width float32) ([]fyne.Position, float32) {
+	positions := make([]fyne.Position, len(objects))
+	if len(objects) == 0 {
+		return positions, 0
+	}
+	lineHeight := fl.lineHeight(objects)
+	spaceWidth := fyne.MeasureText(" ", theme.TextSize(), fyne.TextStyle{}).Width
+
+	var x, y, lineStart, hang float32
+	for i, object := range objects {
+		token := flowToken{}
+		if i < len(fl.tokens) {
+			token = fl.tokens[i]
+		}
+		if i == 0 || token.breaks > 0 {
+			if i > 0 {
+				y += lineHeight * float32(token.breaks)
+			}
+			lineStart = token.indent
+			hang = fyne.Max(token.hang, token.indent)
+			x = lineStart
+		} else if token.space {
+			x += spaceWidth
+		}
+
+		min := object.MinSize()
+		if x > lineStart && x+min.Width > width {
+			x = hang
+			lineStart = hang
+			y += lineHeight
+		}
+		positions[i] = fyne.NewPos(x, y+(lineHeight-min.Height)/2)
+		x += min.Width
+	}
+	return positions, y + lineHeight
+}
+
+// Returns the height of a single line, the height of the tallest object.
+func (fl *flowLayout) lineHeight(objects []fyne.CanvasObject) float32 {
+	var height float32
+	for _, object := range objects {
+		height = fyne.Max(height, object.MinSize().Height)
+	}
+	return height
+}
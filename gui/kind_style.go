@@ -0,0 +1,67 @@
+package gui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// How the notes of a kind are drawn.
+type KindStyle struct {
+	Label string        // the name of the kind shown to the user
+	Icon  fyne.Resource // the icon drawn beside notes of the kind
+	Color color.Color   // the color of the stripe drawn beside notes of the kind
+}
+
+// The style of every kind of note.
+var KIND_STYLES = map[backend.NoteKind]KindStyle{
+	backend.KIND_NARRATIVE: {Label: "Narrative", Icon: theme.DocumentIcon(), Color: color.NRGBA{R: 0x9e, G: 0x9e, B: 0x9e, A: 0xff}},
+	backend.KIND_DIALOGUE:  {Label: "Dialogue", Icon: theme.MailReplyIcon(), Color: color.NRGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff}},
+	backend.KIND_COMBAT:    {Label: "Combat", Icon: theme.WarningIcon(), Color: color.NRGBA{R: 0xc6, G: 0x28, B: 0x28, A: 0xff}},
+	backend.KIND_LOOT:      {Label: "Loot", Icon: theme.StorageIcon(), Color: color.NRGBA{R: 0xf9, G: 0xa8, B: 0x25, A: 0xff}},
+	backend.KIND_RULE:      {Label: "Rule", Icon: theme.HelpIcon(), Color: color.NRGBA{R: 0x6a, G: 0x1b, B: 0x9a, A: 0xff}},
+	backend.KIND_OOC:       {Label: "OOC", Icon: theme.ComputerIcon(), Color: color.NRGBA{R: 0x2e, G: 0x7d, B: 0x32, A: 0xff}},
+}
+
+// The style of the notes dividing a session into scenes, drawn in place of the style of their kind.
+var MARKER_STYLES = map[backend.Marker]KindStyle{
+	backend.MARKER_SCENE: {Label: "Scene", Icon: theme.MediaPlayIcon(), Color: color.NRGBA{R: 0x00, G: 0x83, B: 0x8f, A: 0xff}},
+	backend.MARKER_BREAK: {Label: "Break", Icon: theme.MediaPauseIcon(), Color: color.NRGBA{R: 0x54, G: 0x6e, B: 0x7a, A: 0xff}},
+}
+
+// Returns the style of the passed kind of note. Unknown kinds are drawn as narrative.
+func StyleOf(kind backend.NoteKind) KindStyle {
+	if style, ok := KIND_STYLES[kind]; ok {
+		return style
+	}
+	return KIND_STYLES[backend.KIND_NARRATIVE]
+}
+
+// Returns the style the passed note is drawn in: the style of its marker if it divides the session, or of its kind otherwise.
+func StyleOfNote(note backend.Note) KindStyle {
+	if style, ok := MARKER_STYLES[note.Marker]; ok {
+		return style
+	}
+	return StyleOf(note.Kind)
+}
+
+// Creates a dropdown to choose a kind of note, starting with narrative selected.
+// The passed function is called with the kind whenever the choice changes.
+func NewKindSelect(onChanged func(kind backend.NoteKind)) *widget.Select {
+	labels := make([]string, 0, len(backend.NOTE_KINDS))
+	for _, kind := range backend.NOTE_KINDS {
+		labels = append(labels, StyleOf(kind).Label)
+	}
+	kindSelect := widget.NewSelect(labels, func(label string) {
+		for _, kind := range backend.NOTE_KINDS {
+			if StyleOf(kind).Label == label && onChanged != nil {
+				onChanged(kind)
+			}
+		}
+	})
+	kindSelect.SetSelected(StyleOf(backend.KIND_NARRATIVE).Label)
+	return kindSelect
+}
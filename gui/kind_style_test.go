@@ -0,0 +1,35 @@
+package gui
+
+import (
+	"time"
+
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Kind styles", func() {
+	It("should have a style for every kind of note", func() {
+		for _, kind := range backend.NOTE_KINDS {
+			Expect(KIND_STYLES).To(HaveKey(kind))
+		}
+	})
+
+	It("should draw notes without a kind as narrative", func() {
+		Expect(StyleOf("")).To(Equal(KIND_STYLES[backend.KIND_NARRATIVE]))
+	})
+
+	It("should draw scene and break markers in their own style", func() {
+		Expect(StyleOfNote(backend.NewBreakMarker(time.Now()))).To(Equal(MARKER_STYLES[backend.MARKER_BREAK]))
+		Expect(StyleOfNote(backend.NewNote("Hello", time.Now()))).To(Equal(KIND_STYLES[backend.KIND_NARRATIVE]))
+	})
+
+	It("should report the kind chosen in the dropdown", func() {
+		chosen := backend.NoteKind("")
+		kindSelect := NewKindSelect(func(kind backend.NoteKind) {
+			chosen = kind
+		})
+		kindSelect.SetSelected(StyleOf(backend.KIND_OOC).Label)
+		Expect(chosen).To(Equal(backend.KIND_OOC))
+	})
+})
@@ -0,0 +1,286 @@
+package gui
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"github.com/archon/backend"
+)
+
+// The bullet drawn in place of the marker of an unordered list item.
+const LIST_BULLET = "•"
+
+// The number of spaces a nested list item is indented by for each level of nesting.
+const LIST_NESTING_SPACES = 2
+
+// Matches an unordered or ordered list item, capturing its indentation, its marker, and its text.
+var listItemPattern = regexp.MustCompile(`^([ \t]*)([-*+]|\d+[.)])[ \t]+`)
+
+// Matches a heading, capturing its marker.
+var headingPattern = regexp.MustCompile(`^#{1,6}[ \t]+`)
+
+// The characters that can be escaped with a backslash to be drawn as written.
+const MARKDOWN_ESCAPABLE = "\\`*_[]()#+-.!"
+
+// A line of a note written in Markdown.
+type mdLine struct {
+	level  int     // how deeply the line is nested within a list, 0 if it is not a list item
+	marker string  // the bullet or number drawn before a list item, empty if the line is not a list item
+	runs   []mdRun // the text of the line, split wherever its style changes
+}
+
+// A piece of the text of a line drawn in a single style.
+// The text is always a contiguous part of the content of the note, so that mentions of entities can be found within it.
+type mdRun struct {
+	text  string         // the text of the run, without any Markdown markers
+	start int            // the byte offset within the content of the note where the text begins
+	style fyne.TextStyle // the style the text is drawn in
+	url   string         // the address the run links to, if it is the text of a link
+}
+
+// Parses the content of a note written in Markdown into lines of styled text.
+// Supports **bold**, _italics_, `code`, [links](https://example.com), headings, and lists.
+func parseMarkdown(content string) []mdLine {
+	lines := make([]mdLine, 0)
+	offset := 0
+	for _, text := range strings.Split(content, "\n") {
+		line := mdLine{}
+		start := 0
+		style := fyne.TextStyle{}
+		if match := listItemPattern.FindStringSubmatchIndex(text); match != nil {
+			indent := strings.ReplaceAll(text[match[2]:match[3]], "\t", strings.Repeat(" ", LIST_NESTING_SPACES))
+			line.level = len(indent)/LIST_NESTING_SPACES + 1
+			line.marker = text[match[4]:match[5]]
+			if _, err := strconv.Atoi(strings.TrimRight(line.marker, ".)")); err != nil {
+				line.marker = LIST_BULLET
+			}
+			start = match[1]
+		} else if match := headingPattern.FindStringIndex(text); match != nil {
+			style.Bold = true
+			start = match[1]
+		}
+		line.runs = parseInline(text[start:], offset+start, style)
+		lines = append(lines, line)
+		offset += len(text) + len("\n")
+	}
+	return lines
+}
+
+// Parses the emphasis, code, and links within a single line of Markdown starting at the passed offset of the note.
+// Every run is drawn in the passed base style in addition to its own.
+func parseInline(text string, offset int, base fyne.TextStyle) []mdRun {
+	runs := make([]mdRun, 0)
+	style := base
+	runStart := 0
+	flush := func(end int) {
+		if end > runStart {
+			runs = append(runs, mdRun{text: text[runStart:end], start: offset + runStart, style: style})
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case style.Monospace && rest[0] != '`':
+			// nothing is styled within code
+			i++
+		case rest[0] == '`' && (style.Monospace || strings.Contains(rest[1:], "`")):
+			flush(i)
+			style.Monospace = !style.Monospace
+			i++
+			runStart = i
+		case rest[0] == '\\' && len(rest) > 1 && strings.IndexByte(MARKDOWN_ESCAPABLE, rest[1]) != -1:
+			// the escaped character starts the next run, so it is drawn as written
+			flush(i)
+			runStart = i + 1
+			i += 2
+		case (strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__")) && emphasisToggles(text, i, 2, style.Bold):
+			flush(i)
+			style.Bold = !style.Bold
+			i += 2
+			runStart = i
+		case (rest[0] == '*' || rest[0] == '_') && emphasisToggles(text, i, 1, style.Italic):
+			flush(i)
+			style.Italic = !style.Italic
+			i++
+			runStart = i
+		case rest[0] == '[':
+			label, address, length, ok := parseLink(rest)
+			if !ok {
+				i++
+				continue
+			}
+			flush(i)
+			runs = append(runs, mdRun{text: label, start: offset + i + 1, style: style, url: address})
+			i += length
+			runStart = i
+		default:
+			i++
+		}
+	}
+	flush(len(text))
+	return runs
+}
+
+// Reports whether the emphasis marker of the passed length at index i of the text opens or closes emphasis.
+// A marker closes emphasis that is open. It opens emphasis if it is followed by text and closed later in the line.
+// Underscores within words, like snake_case, never count.
+func emphasisToggles(text string, i int, length int, open bool) bool {
+	marker := text[i : i+length]
+	before, _ := utf8.DecodeLastRuneInString(text[:i])
+	after, _ := utf8.DecodeRuneInString(text[i+length:])
+	if marker[0] == '_' && i > 0 && isWordChar(before) && i+length < len(text) && isWordChar(after) {
+		return false
+	}
+	if open {
+		return i > 0 && !unicode.IsSpace(before)
+	}
+	if i+length >= len(text) || unicode.IsSpace(after) {
+		return false
+	}
+	return strings.Contains(text[i+length+1:], marker)
+}
+
+// Parses a link like [label](https://example.com) at the start of the text.
+// Returns the label, the address, and the length of the link, or false if the text does not start with a link.
+// The label ends at the first closing bracket, which must be followed by the address.
+func parseLink(text string) (string, string, int, bool) {
+	middle := strings.IndexByte(text, ']')
+	if middle < 2 || !strings.HasPrefix(text[middle:], "](") {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(text[middle:], ')')
+	if end == -1 {
+		return "", "", 0, false
+	}
+	end += middle
+	address := strings.TrimSpace(text[middle+2 : end])
+	if address == "" {
+		return "", "", 0, false
+	}
+	return text[1:middle], address, end + 1, true
+}
+
+// Reports whether the rune can be part of a word.
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Builds the pieces of wrapped text drawn for the content of a note, along with how each is separated from the one before.
+type contentBuilder struct {
+	objects []fyne.CanvasObject // the pieces of text, in order
+	tokens  []flowToken         // how each piece is separated from the one before it
+	pending flowToken           // how the next piece will be separated from the one before it
+}
+
+// Starts a new line. Lines without any pieces leave a blank line.
+func (cb *contentBuilder) newLine() {
+	cb.pending.breaks++
+	cb.pending.space = false
+	cb.pending.indent = 0
+	cb.pending.hang = 0
+}
+
+// Indents the current line, and the lines it wraps onto.
+func (cb *contentBuilder) indent(indent float32, hang float32) {
+	cb.pending.indent = indent
+	cb.pending.hang = hang
+}
+
+// Adds the words of the text in the passed style. The words wrap independently.
+func (cb *contentBuilder) addWords(text string, style fyne.TextStyle) {
+	if strings.IndexFunc(text, unicode.IsSpace) == 0 {
+		cb.pending.space = true
+	}
+	for i, word := range strings.Fields(text) {
+		if i > 0 {
+			cb.pending.space = true
+		}
+		cb.add(newContentText(word, style))
+	}
+	if last, _ := utf8.DecodeLastRuneInString(text); len(text) > 0 && unicode.IsSpace(last) {
+		cb.pending.space = true
+	}
+}
+
+// Adds a piece that does not wrap, such as a link.
+func (cb *contentBuilder) add(object fyne.CanvasObject) {
+	cb.objects = append(cb.objects, object)
+	cb.tokens = append(cb.tokens, cb.pending)
+	cb.pending = flowToken{}
+}
+
+// Creates the text for a plain part of the content of a note.
+func newContentText(text string, style fyne.TextStyle) *canvas.Text {
+	contentText := canvas.NewText(text, theme.ForegroundColor())
+	contentText.Alignment = fyne.TextAlignLeading
+	contentText.TextStyle = style
+	return contentText
+}
+
+// Creates a link to a web page, opened in the browser when tapped.
+func newURLLink(text string, address string) *EntityLink {
+	link := NewEntityLink(text, address)
+	link.OnTapped = func(address string) {
+		u, err := url.Parse(address)
+		if err != nil || fyne.CurrentApp() == nil {
+			return
+		}
+		fyne.CurrentApp().OpenURL(u)
+	}
+	return link
+}
+
+// Builds the pieces of text drawn for the content of a note written in Markdown.
+// Every mention of an entity is drawn as a link that calls onEntityTapped.
+func buildContent(content string, mentions []backend.Mention, onEntityTapped func(entity string)) ([]fyne.CanvasObject, []flowToken) {
+	cb := &contentBuilder{}
+	spaceWidth := fyne.MeasureText(" ", theme.TextSize(), fyne.TextStyle{}).Width
+	for i, line := range parseMarkdown(content) {
+		if i > 0 {
+			cb.newLine()
+		}
+		if line.marker != "" {
+			indent := spaceWidth * float32(LIST_NESTING_SPACES*2*(line.level-1))
+			markerWidth := fyne.MeasureText(line.marker, theme.TextSize(), fyne.TextStyle{}).Width
+			cb.indent(indent, indent+markerWidth+spaceWidth)
+			cb.add(newContentText(line.marker, fyne.TextStyle{}))
+			cb.pending.space = true
+		}
+		for _, run := range line.runs {
+			if run.url != "" {
+				cb.add(newURLLink(run.text, run.url))
+				continue
+			}
+			addRun(cb, run, mentions, onEntityTapped)
+		}
+	}
+	if len(cb.objects) == 0 {
+		cb.add(newContentText("", fyne.TextStyle{}))
+	}
+	return cb.objects, cb.tokens
+}
+
+// Adds the words of a run, drawing every mention of an entity that lies within the run as a link.
+func addRun(cb *contentBuilder, run mdRun, mentions []backend.Mention, onEntityTapped func(entity string)) {
+	last := 0
+	end := run.start + len(run.text)
+	for _, mention := range mentions {
+		if mention.Start < run.start+last || mention.End > end {
+			continue
+		}
+		cb.addWords(run.text[last:mention.Start-run.start], run.style)
+		link := NewEntityLink(run.text[mention.Start-run.start:mention.End-run.start], mention.Entity)
+		link.OnTapped = onEntityTapped
+		cb.add(link)
+		last = mention.End - run.start
+	}
+	cb.addWords(run.text[last:], run.style)
+}
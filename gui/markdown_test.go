@@ -0,0 +1,88 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Markdown", func() {
+	bold := fyne.TextStyle{Bold: true}
+	italic := fyne.TextStyle{Italic: true}
+
+	BeforeEach(func() {
+		test.NewApp()
+	})
+
+	It("should style bold and italic text", func() {
+		lines := parseMarkdown("Xenthe **almost** _died_")
+		Expect(lines).To(HaveLen(1))
+		Expect(lines[0].runs).To(Equal([]mdRun{
+			{text: "Xenthe ", start: 0},
+			{text: "almost", start: 9, style: bold},
+			{text: " ", start: 17},
+			{text: "died", start: 19, style: italic},
+		}))
+	})
+
+	It("should leave markers that do not mark emphasis as written", func() {
+		lines := parseMarkdown("2 * 3 * 4 in snake_case_names")
+		Expect(lines[0].runs).To(Equal([]mdRun{{text: "2 * 3 * 4 in snake_case_names", start: 0}}))
+	})
+
+	It("should not style text within code", func() {
+		lines := parseMarkdown("Cast `*fireball*`")
+		Expect(lines[0].runs[1]).To(Equal(mdRun{text: "*fireball*", start: 6, style: fyne.TextStyle{Monospace: true}}))
+	})
+
+	It("should draw escaped markers as written", func() {
+		lines := parseMarkdown(`\*not italic\*`)
+		Expect(lines[0].runs).To(Equal([]mdRun{{text: "*not italic", start: 1}, {text: "*", start: 13}}))
+	})
+
+	It("should parse links", func() {
+		lines := parseMarkdown("See [the rules](https://example.com/rules) first")
+		Expect(lines[0].runs[1]).To(Equal(mdRun{text: "the rules", start: 5, url: "https://example.com/rules"}))
+		Expect(lines[0].runs[2].text).To(Equal(" first"))
+	})
+
+	It("should not take text in brackets before a link as part of it", func() {
+		lines := parseMarkdown("[a] b [c](https://example.com)")
+		Expect(lines[0].runs).To(Equal([]mdRun{{text: "[a] b "}, {text: "c", start: 7, url: "https://example.com"}}))
+	})
+
+	It("should parse unordered, ordered, and nested list items", func() {
+		lines := parseMarkdown("Loot:\n- 50 gold\n  * a wand\n2. a map")
+		Expect(lines).To(HaveLen(4))
+		Expect(lines[0].marker).To(BeEmpty())
+		Expect(lines[1].marker).To(Equal(LIST_BULLET))
+		Expect(lines[1].level).To(Equal(1))
+		Expect(lines[1].runs[0]).To(Equal(mdRun{text: "50 gold", start: 8}))
+		Expect(lines[2].level).To(Equal(2))
+		Expect(lines[3].marker).To(Equal("2."))
+	})
+
+	It("should draw headings in bold", func() {
+		lines := parseMarkdown("## Act two")
+		Expect(lines[0].runs).To(Equal([]mdRun{{text: "Act two", start: 3, style: bold}}))
+	})
+
+	It("should draw mentions of entities within styled text as links", func() {
+		mentions := []backend.Mention{{Entity: "Aust Redwyn", Start: 6, End: 10}}
+		objects, _ := buildContent("Met **Aust** today", mentions, nil)
+		Expect(objects).To(HaveLen(3))
+		Expect(objects[0].(*canvas.Text).Text).To(Equal("Met"))
+		Expect(objects[1].(*EntityLink).Entity()).To(Equal("Aust Redwyn"))
+		Expect(objects[2].(*canvas.Text).Text).To(Equal("today"))
+	})
+
+	It("should not space apart words that are written together", func() {
+		_, tokens := buildContent("**Aust**'s ship", nil, nil)
+		Expect(tokens).To(HaveLen(3))
+		Expect(tokens[1].space).To(BeFalse())
+		Expect(tokens[2].space).To(BeTrue())
+	})
+})
@@ -0,0 +1,241 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Indicates that no note of a NoteList is selected.
+const NO_SELECTION = -1
+
+// Handles the rendering for NoteLists. Implements the fyne.WidgetRenderer interface.
+type NoteListRenderer struct {
+	scroll  *container.Scroll   // the scrolling container holding the note boxes
+	objects []fyne.CanvasObject // a list of the objects declared above
+	list    *NoteList           // reference to the note list being rendered
+}
+
+// The minimum size of a NoteList. Necessary to implement the fyne.WidgetRenderer interface.
+func (nlr *NoteListRenderer) MinSize() fyne.Size {
+	return nlr.scroll.MinSize()
+}
+
+// Position and resize the scrolling container to fill the NoteList, then show the notes that fit in it.
+// Necessary to implement the fyne.WidgetRenderer interface.
+func (nlr *NoteListRenderer) Layout(size fyne.Size) {
+	nl := nlr.list
+	// the height of each note box depends on the width it is given, so notes measured at another width are measured again
+	if size.Width != nl.width {
+		nl.width = size.Width
+		for i := range nl.heights {
+			nl.heights[i] = 0
+		}
+	}
+	nlr.scroll.Resize(size)
+	nl.update(false)
+}
+
+// Triggers when the NoteList changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (nlr *NoteListRenderer) Refresh() {
+	nlr.Layout(nlr.list.Size())
+	nlr.scroll.Refresh()
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (nlr *NoteListRenderer) Objects() []fyne.CanvasObject {
+	return nlr.objects
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (nlr *NoteListRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A scrolling list of NoteBoxes, each as tall as its note needs. Implements the fyne.Widget interface.
+// Only the notes in view have a note box, so long sessions cost no more to show than short ones.
+// Notes that have not been in view yet are taken to be NOTE_MIN_HEIGHT tall until they are.
+type NoteList struct {
+	widget.BaseWidget
+	Length     func() int                    // returns the number of notes in the list
+	UpdateItem func(i int, noteBox *NoteBox) // sets the note displayed by the note box at position i of the list
+	shown      map[int]*NoteBox              // the note boxes in view, by the position of the note they display
+	spare      []*NoteBox                    // note boxes no longer in view, reused for the notes that come into view
+	heights    []float32                     // the height of the note at each position when it was last in view, or 0 if it has not been
+	width      float32                       // the width of the list the heights were measured at
+	content    *fyne.Container               // the container holding the note boxes in view
+	layout     *noteListLayout               // the layout giving the content the height of every note together
+	scroll     *container.Scroll             // the scrolling container holding the content
+	selected   int                           // the position of the selected note, or NO_SELECTION
+}
+
+// Creates a NoteList renderer. Necessary to implement the fyne.Widget interface.
+func (nl *NoteList) CreateRenderer() fyne.WidgetRenderer {
+	nl.update(true)
+	return &NoteListRenderer{
+		scroll:  nl.scroll,
+		objects: []fyne.CanvasObject{nl.scroll},
+		list:    nl,
+	}
+}
+
+// Updates the note boxes in view and redraws the list.
+func (nl *NoteList) Refresh() {
+	nl.update(true)
+	nl.BaseWidget.Refresh()
+}
+
+// Highlights the note at position i of the list and scrolls to it.
+func (nl *NoteList) Select(i int) {
+	if i < 0 || i >= nl.length() {
+		return
+	}
+	nl.selected = i
+	nl.Refresh()
+	nl.ScrollTo(i)
+}
+
+// Removes the highlight from the selected note, if there is one.
+func (nl *NoteList) Unselect() {
+	nl.selected = NO_SELECTION
+	nl.Refresh()
+}
+
+// Scrolls the list so that the note at position i is at the top, or as near as the list can scroll.
+func (nl *NoteList) ScrollTo(i int) {
+	if i < 0 || i >= nl.length() {
+		return
+	}
+	// the notes above may be taller than estimated once they come into view, so the scroll is corrected once they have
+	for pass := 0; pass < 2; pass++ {
+		nl.scroll.Offset.Y = nl.offsetOf(i)
+		nl.scroll.Refresh()
+	}
+}
+
+// Scrolls the list to its last note.
+func (nl *NoteList) ScrollToBottom() {
+	for pass := 0; pass < 2; pass++ {
+		nl.scroll.Offset.Y = fyne.Max(nl.offsetOf(nl.length())-nl.scroll.Size().Height, 0)
+		nl.scroll.Refresh()
+	}
+}
+
+// Returns the number of notes in the list.
+func (nl *NoteList) length() int {
+	if nl.Length == nil {
+		return 0
+	}
+	return nl.Length()
+}
+
+// Returns the height of the note at position i, as measured when it was last in view or estimated if it has not been.
+func (nl *NoteList) heightOf(i int) float32 {
+	if nl.heights[i] > 0 {
+		return nl.heights[i]
+	}
+	return NOTE_MIN_HEIGHT
+}
+
+// Returns the distance from the top of the list to the note at position i, or to the end of the list if i is its length.
+func (nl *NoteList) offsetOf(i int) float32 {
+	var y float32
+	for j := 0; j < i && j < len(nl.heights); j++ {
+		y += nl.heightOf(j)
+	}
+	return y
+}
+
+// Gives a note box to every note in view and takes them from the notes no longer in view, then stacks them.
+// Note boxes that stayed in view are only updated if the notes changed, as when the list is refreshed.
+func (nl *NoteList) update(changed bool) {
+	length := nl.length()
+	if len(nl.heights) > length {
+		nl.heights = nl.heights[:length]
+	}
+	for len(nl.heights) < length {
+		nl.heights = append(nl.heights, 0)
+	}
+
+	width := fyne.Max(nl.width, NOTE_MIN_WIDTH)
+	top := nl.scroll.Offset.Y
+	bottom := top + nl.scroll.Size().Height
+	shown := make(map[int]*NoteBox)
+	objects := make([]fyne.CanvasObject, 0, len(nl.shown))
+	var y float32
+	for i := 0; i < length && y < bottom; i++ {
+		if y+nl.heightOf(i) <= top {
+			y += nl.heightOf(i)
+			continue
+		}
+		noteBox, ok := nl.shown[i]
+		if ok {
+			delete(nl.shown, i)
+		} else {
+			noteBox = nl.spareBox()
+		}
+		if !ok || changed {
+			if nl.UpdateItem != nil {
+				nl.UpdateItem(i, noteBox)
+			}
+			noteBox.SetSelected(i == nl.selected)
+		}
+		// a note box measures its height from its current width
+		noteBox.Resize(fyne.NewSize(width, noteBox.Size().Height))
+		nl.heights[i] = noteBox.MinSize().Height
+		noteBox.Move(fyne.NewPos(0, y))
+		noteBox.Resize(fyne.NewSize(width, nl.heights[i]))
+		y += nl.heights[i]
+		shown[i] = noteBox
+		objects = append(objects, noteBox)
+	}
+	for _, noteBox := range nl.shown {
+		nl.spare = append(nl.spare, noteBox)
+	}
+	nl.shown = shown
+
+	nl.layout.size = fyne.NewSize(width, nl.offsetOf(length))
+	nl.content.Objects = objects
+	nl.content.Refresh()
+}
+
+// Returns a note box no longer in view, or a new one if there is none.
+func (nl *NoteList) spareBox() *NoteBox {
+	if len(nl.spare) == 0 {
+		return NewNoteBox("", time.Time{})
+	}
+	noteBox := nl.spare[len(nl.spare)-1]
+	nl.spare = nl.spare[:len(nl.spare)-1]
+	return noteBox
+}
+
+// Creates a new NoteList displaying length notes, each set by updateItem.
+func NewNoteList(length func() int, updateItem func(i int, noteBox *NoteBox)) *NoteList {
+	nl := &NoteList{Length: length, UpdateItem: updateItem, shown: make(map[int]*NoteBox), selected: NO_SELECTION}
+	nl.layout = &noteListLayout{}
+	nl.content = fyne.NewContainerWithLayout(nl.layout)
+	nl.scroll = container.NewVScroll(nl.content)
+	nl.scroll.OnScrolled = func(fyne.Position) {
+		nl.update(false)
+	}
+	nl.ExtendBaseWidget(nl)
+	return nl
+}
+
+// Gives the content of a NoteList the size of every note stacked together. The note boxes in view are positioned by the list,
+// as only it knows the heights of the notes out of view.
+type noteListLayout struct {
+	size fyne.Size // the width of the list and the height of every note together
+}
+
+// Does nothing, as the list positions the note boxes in view. Necessary to implement the fyne.Layout interface.
+func (nll *noteListLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	// no-op, the note boxes are positioned as they come into view
+}
+
+// The width of the list and the height of every note together. Necessary to implement the fyne.Layout interface.
+func (nll *noteListLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return nll.size
+}
@@ -0,0 +1,85 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NoteList widget", func() {
+	var notes []backend.Note
+	var list *NoteList
+
+	BeforeEach(func() {
+		notes = []backend.Note{
+			backend.NewNote("Arrived in Calimport", time.Now()),
+			backend.NewNote("Xenthe almost died\nThen she got better", time.Now()),
+		}
+		list = NewNoteList(
+			func() int {
+				return len(notes)
+			},
+			func(i int, noteBox *NoteBox) {
+				noteBox.SetNote(notes[i])
+			},
+		)
+	})
+
+	It("should render without crashing", func() {
+		render := func() {
+			test.NewWindow(list)
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should give each note the height it needs", func() {
+		w := test.NewWindow(list)
+		w.Resize(fyne.NewSize(600, 400))
+		Expect(list.content.Objects).To(HaveLen(2))
+		first := list.shown[0].Size().Height
+		second := list.shown[1].Size().Height
+		Expect(second).To(BeNumerically(">", first))
+		Expect(list.shown[1].Position().Y).To(Equal(first))
+	})
+
+	It("should show notes added to the list when refreshed", func() {
+		w := test.NewWindow(list)
+		w.Resize(fyne.NewSize(600, 400))
+		notes = append(notes, backend.NewNote("Found a wand", time.Now()))
+		list.Refresh()
+		Expect(list.content.Objects).To(HaveLen(3))
+	})
+
+	It("should highlight the selected note", func() {
+		w := test.NewWindow(list)
+		w.Resize(fyne.NewSize(600, 400))
+		list.Select(1)
+		Expect(list.shown[1].selected).To(BeTrue())
+		Expect(list.shown[0].selected).To(BeFalse())
+		list.Unselect()
+		Expect(list.shown[1].selected).To(BeFalse())
+	})
+
+	It("should only create note boxes for the notes in view", func() {
+		for i := 0; i < 1000; i++ {
+			notes = append(notes, backend.NewNote("Rolled for initiative", time.Now()))
+		}
+		w := test.NewWindow(list)
+		w.Resize(fyne.NewSize(600, 400))
+		Expect(len(list.content.Objects)).To(BeNumerically("<", 20))
+		Expect(list.shown).To(HaveKey(0))
+
+		list.ScrollToBottom()
+		Expect(list.shown).To(HaveKey(len(notes) - 1))
+		Expect(list.shown).ToNot(HaveKey(0))
+		Expect(len(list.shown) + len(list.spare)).To(BeNumerically("<", 40))
+
+		list.ScrollTo(500)
+		Expect(list.shown).To(HaveKey(500))
+		Expect(list.shown[500].Position().Y).To(Equal(list.scroll.Offset.Y))
+	})
+})
@@ -1,43 +1,187 @@
 package gui
 
 import (
+	"image/color"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/archon/backend"
 )
 
+const EDITED_SUFFIX = " (edited)"
+
+// The smallest size of a NoteBox. Notes with longer content are taller.
+const NOTE_MIN_WIDTH = 400
+const NOTE_MIN_HEIGHT = 40
+
+// The size of the text displaying the time of a note, relative to the size of its content.
+const NOTE_TIME_TEXT_FACTOR = 0.7
+
+// The width of the colored stripe drawn along the left edge of a NoteBox to show the kind of its note.
+const KIND_STRIPE_WIDTH = 4
+
 // Handles the rendering for NoteBoxes. Implements the fyne.WidgetRenderer interface.
 type NoteBoxRenderer struct {
-	noteContentText *canvas.Text        // the text of the content of the note
-	noteTimeText    *canvas.Text        // the text displaying the date and time the note was taken
-	objects         []fyne.CanvasObject // a list of the objects declared above
-	noteBox         *NoteBox            // reference to the note box being rendered
+	background       *canvas.Rectangle   // the rectangle highlighting the NoteBox when it is selected
+	contentBox       *fyne.Container     // the wrapped text of the content of the note, with any entity mentions drawn as links
+	contentLayout    *flowLayout         // the layout wrapping the text of the content
+	kindStripe       *canvas.Rectangle   // the stripe colored by the kind of the note
+	kindIcon         *widget.Icon        // the icon of the kind of the note
+	noteTimeText     *canvas.Text        // the text displaying the date and time the note was taken
+	editor           *noteEditor         // the field used to edit the content of the note inline
+	tagBox           *fyne.Container     // the chips displaying the tags of the note
+	objects          []fyne.CanvasObject // a list of the objects declared above
+	noteBox          *NoteBox            // reference to the note box being rendered
+	renderedContent  string              // the content the content box was last built from
+	renderedMentions []backend.Mention   // the mentions the content box was last built from
 }
 
-// The minimum size of a NoteBox. Necessary to implement the fyne.WidgetRenderer interface.
+// The minimum size of a NoteBox. Tall enough to fit the content of the note wrapped within the current width of the NoteBox.
+// Necessary to implement the fyne.WidgetRenderer interface.
 func (nbr *NoteBoxRenderer) MinSize() fyne.Size {
-	var min_width, min_height float32 = 400, 40
-	return fyne.NewSize(min_width, min_height)
+	width := fyne.Max(nbr.noteBox.Size().Width, NOTE_MIN_WIDTH)
+	var height float32
+	if nbr.noteBox.editing {
+		height = nbr.editor.MinSize().Height + theme.Padding()*2
+	} else {
+		height = nbr.contentLayout.heightForWidth(nbr.contentBox.Objects, nbr.contentWidth(width)) + nbr.contentTop()*2
+	}
+	return fyne.NewSize(NOTE_MIN_WIDTH, fyne.Max(height, NOTE_MIN_HEIGHT))
 }
 
 // Position and resize the items within the NoteBox based on the input size. Necessary to implement the fyne.WidgetRenderer interface.
 func (nbr *NoteBoxRenderer) Layout(size fyne.Size) {
-	var date_text_factor float32 = 0.7
-	nbr.noteContentText.Move(fyne.NewPos(0+theme.Padding(), size.Height/3))
-	nbr.noteTimeText.TextSize = theme.TextSize() * date_text_factor
-	nbr.noteTimeText.Move(fyne.NewPos(size.Width-theme.Padding(), nbr.noteContentText.Position().Y))
+	nbr.background.Resize(size)
+	nbr.kindStripe.Move(fyne.NewPos(0, 0))
+	nbr.kindStripe.Resize(fyne.NewSize(KIND_STRIPE_WIDTH, size.Height))
+
+	// the icon, time, and tags line up with the first line of the content
+	top := nbr.contentTop()
+	lineHeight := nbr.contentLayout.lineHeight(nbr.contentBox.Objects)
+	iconSize := theme.IconInlineSize()
+	nbr.kindIcon.Move(fyne.NewPos(KIND_STRIPE_WIDTH+theme.Padding(), top+(lineHeight-iconSize)/2))
+	nbr.kindIcon.Resize(fyne.NewSize(iconSize, iconSize))
+
+	contentX := nbr.contentX()
+	contentWidth := nbr.contentWidth(size.Width)
+	nbr.contentBox.Move(fyne.NewPos(contentX, top))
+	nbr.contentBox.Resize(fyne.NewSize(contentWidth, nbr.contentLayout.heightForWidth(nbr.contentBox.Objects, contentWidth)))
+	nbr.noteTimeText.TextSize = theme.TextSize() * NOTE_TIME_TEXT_FACTOR
+	nbr.noteTimeText.Move(fyne.NewPos(size.Width-theme.Padding(), top))
+
+	tagSize := nbr.tagBox.MinSize()
+	tagX := size.Width - nbr.noteTimeText.MinSize().Width - tagSize.Width - theme.Padding()*2
+	nbr.tagBox.Move(fyne.NewPos(tagX, top+(lineHeight-tagSize.Height)/2))
+	nbr.tagBox.Resize(tagSize)
+
+	editorHeight := nbr.editor.MinSize().Height
+	editorWidth := size.Width - contentX - nbr.noteTimeText.MinSize().Width - theme.Padding()*2
+	nbr.editor.Move(fyne.NewPos(contentX, (size.Height-editorHeight)/2))
+	nbr.editor.Resize(fyne.NewSize(editorWidth, editorHeight))
+}
+
+// Returns the distance from the top of the NoteBox to the first line of its content.
+// A note of a single line is vertically centered in a NoteBox of the minimum height.
+func (nbr *NoteBoxRenderer) contentTop() float32 {
+	return (NOTE_MIN_HEIGHT - nbr.contentLayout.lineHeight(nbr.contentBox.Objects)) / 2
+}
+
+// Returns the distance from the left edge of the NoteBox to its content, past the stripe and icon of its kind.
+func (nbr *NoteBoxRenderer) contentX() float32 {
+	return KIND_STRIPE_WIDTH + theme.IconInlineSize() + theme.Padding()*2
+}
+
+// Returns the width the content is wrapped within in a NoteBox of the passed width, leaving room for the tags and time.
+func (nbr *NoteBoxRenderer) contentWidth(width float32) float32 {
+	nbr.noteTimeText.TextSize = theme.TextSize() * NOTE_TIME_TEXT_FACTOR
+	used := nbr.contentX() + nbr.tagBox.MinSize().Width + nbr.noteTimeText.MinSize().Width + theme.Padding()*3
+	return fyne.Max(width-used, 0)
 }
 
 // Triggers when the NoteBox changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
 func (nbr *NoteBoxRenderer) Refresh() {
+	nbr.refreshTags()
+	nbr.refreshContent()
+	style := StyleOfNote(nbr.noteBox.note)
+	nbr.kindStripe.FillColor = style.Color
+	nbr.kindIcon.SetResource(style.Icon)
+	nbr.background.FillColor = color.Transparent
+	if nbr.noteBox.selected {
+		nbr.background.FillColor = theme.FocusColor()
+	}
+	nbr.noteTimeText.Text = nbr.noteBox.timeText()
 	nbr.Layout(nbr.noteBox.Size())
 	canvas.Refresh(nbr.noteBox)
-	nbr.noteContentText.Text = nbr.noteBox.note.Content
-	nbr.noteTimeText.Text = nbr.noteBox.note.Time.Format("Jan 2 3:04 PM")
+	if nbr.noteBox.editing {
+		nbr.contentBox.Hide()
+		nbr.editor.Show()
+	} else {
+		nbr.editor.Hide()
+		nbr.contentBox.Show()
+	}
+}
+
+// Rebuilds the text of the content if the content of the note or its mentions have changed.
+// The content is drawn as Markdown, split into words that wrap and a link for every mention of an entity.
+func (nbr *NoteBoxRenderer) refreshContent() {
+	content := nbr.noteBox.note.Content
+	mentions := nbr.noteBox.mentions
+	if content == nbr.renderedContent && sameMentions(mentions, nbr.renderedMentions) && len(nbr.contentBox.Objects) > 0 {
+		return
+	}
+	nbr.renderedContent = content
+	nbr.renderedMentions = mentions
+
+	words, tokens := buildContent(content, mentions, func(entity string) {
+		if nbr.noteBox.OnEntityTapped != nil {
+			nbr.noteBox.OnEntityTapped(entity)
+		}
+	})
+	nbr.contentLayout.tokens = tokens
+	nbr.contentBox.Objects = words
+	nbr.contentBox.Refresh()
+}
+
+// Reports whether two lists of mentions are identical.
+func sameMentions(a []backend.Mention, b []backend.Mention) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Rebuilds the tag chips if the tags of the note have changed.
+func (nbr *NoteBoxRenderer) refreshTags() {
+	tags := nbr.noteBox.note.Tags
+	same := len(tags) == len(nbr.tagBox.Objects)
+	for i := 0; same && i < len(tags); i++ {
+		same = nbr.tagBox.Objects[i].(*TagChip).Tag() == tags[i]
+	}
+	if same {
+		return
+	}
+
+	chips := make([]fyne.CanvasObject, 0, len(tags))
+	for _, tag := range tags {
+		chip := NewTagChip(tag)
+		chip.OnTapped = func(tag string) {
+			if nbr.noteBox.OnTagTapped != nil {
+				nbr.noteBox.OnTagTapped(tag)
+			}
+		}
+		chips = append(chips, chip)
+	}
+	nbr.tagBox.Objects = chips
+	nbr.tagBox.Refresh()
 }
 
 // Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
@@ -52,7 +196,11 @@ func (nbr *NoteBoxRenderer) Destroy() {
 
 // Apply the current theme to this element.
 func (nbr *NoteBoxRenderer) ApplyTheme() {
-	nbr.noteContentText.Color = theme.ForegroundColor()
+	for _, object := range nbr.contentBox.Objects {
+		if text, ok := object.(*canvas.Text); ok {
+			text.Color = theme.ForegroundColor()
+		}
+	}
 	nbr.noteTimeText.Color = theme.DisabledColor()
 	nbr.Refresh()
 }
@@ -60,24 +208,103 @@ func (nbr *NoteBoxRenderer) ApplyTheme() {
 // A box that displays a user's Note after they have entered it. Implements the fyne.Widget interface.
 type NoteBox struct {
 	widget.BaseWidget
-	note backend.Note
+	note            backend.Note
+	mentions        []backend.Mention           // the mentions of entities within the content of the note
+	editing         bool                        // whether the content of the note is being edited inline
+	selected        bool                        // whether the note is highlighted as selected
+	calendar        *backend.Calendar           // the calendar the in-game time of the note is shown in, or nil to show the real time
+	editor          *noteEditor                 // the field used to edit the content of the note inline
+	OnEdit          func()                      // called when the user chooses to edit the note, if set
+	OnDelete        func()                      // called when the user chooses to delete the note, if set
+	OnMoveUp        func()                      // called when the user chooses to move the note up, if set
+	OnMoveDown      func()                      // called when the user chooses to move the note down, if set
+	OnEdited        func(content string)        // called with the new content when the user finishes editing, if set
+	OnEditCancelled func()                      // called when the user abandons editing the note, if set
+	OnTagTapped     func(tag string)            // called with a tag of the note when its chip is tapped, if set
+	OnEntityTapped  func(entity string)         // called with the name of an entity when a mention of it is tapped, if set
+	OnKindChanged   func(kind backend.NoteKind) // called with the kind the user chooses for the note, if set
 }
 
 // Creates a NoteBox renderer. Necessary to implement the fyne.Widget interface.
 func (nb *NoteBox) CreateRenderer() fyne.WidgetRenderer {
-	contentText := canvas.NewText(nb.note.Content, theme.ForegroundColor())
-	contentText.Alignment = fyne.TextAlignLeading
+	contentLayout := &flowLayout{}
+	contentBox := fyne.NewContainerWithLayout(contentLayout)
+	background := canvas.NewRectangle(color.Transparent)
 
-	timeText := canvas.NewText(nb.note.Time.Format("Jan 2 3:04 PM"), theme.DisabledColor())
+	timeText := canvas.NewText(nb.timeText(), theme.DisabledColor())
 	timeText.Alignment = fyne.TextAlignTrailing
 
-	objects := []fyne.CanvasObject{contentText, timeText}
-	return &NoteBoxRenderer{
-		noteContentText: contentText,
-		noteTimeText:    timeText,
-		objects:         objects,
-		noteBox:         nb,
+	nb.editor.Hide()
+
+	tagBox := container.NewHBox()
+
+	style := StyleOfNote(nb.note)
+	kindStripe := canvas.NewRectangle(style.Color)
+	kindIcon := widget.NewIcon(style.Icon)
+
+	objects := []fyne.CanvasObject{background, kindStripe, kindIcon, contentBox, timeText, tagBox, nb.editor}
+	renderer := &NoteBoxRenderer{
+		background:    background,
+		contentBox:    contentBox,
+		contentLayout: contentLayout,
+		kindStripe:    kindStripe,
+		kindIcon:      kindIcon,
+		noteTimeText:  timeText,
+		editor:        nb.editor,
+		tagBox:        tagBox,
+		objects:       objects,
+		noteBox:       nb,
+	}
+	renderer.refreshTags()
+	renderer.refreshContent()
+	return renderer
+}
+
+// Shows a menu to edit, delete, move, or change the kind of the note when the NoteBox is right-clicked.
+// Implements the fyne.SecondaryTappable interface.
+func (nb *NoteBox) TappedSecondary(pe *fyne.PointEvent) {
+	c := fyne.CurrentApp().Driver().CanvasForObject(nb)
+	if c == nil {
+		return
+	}
+	kindItems := make([]*fyne.MenuItem, 0, len(backend.NOTE_KINDS))
+	for _, kind := range backend.NOTE_KINDS {
+		kind := kind
+		kindItems = append(kindItems, fyne.NewMenuItem(StyleOf(kind).Label, func() {
+			if nb.OnKindChanged != nil {
+				nb.OnKindChanged(kind)
+			}
+		}))
 	}
+	kindItem := fyne.NewMenuItem("Kind", nil)
+	kindItem.ChildMenu = fyne.NewMenu("", kindItems...)
+
+	menu := fyne.NewMenu("",
+		fyne.NewMenuItem("Edit", func() {
+			if nb.OnEdit != nil {
+				nb.OnEdit()
+			}
+		}),
+		fyne.NewMenuItem("Delete", func() {
+			if nb.OnDelete != nil {
+				nb.OnDelete()
+			}
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("Move up", func() {
+			if nb.OnMoveUp != nil {
+				nb.OnMoveUp()
+			}
+		}),
+		fyne.NewMenuItem("Move down", func() {
+			if nb.OnMoveDown != nil {
+				nb.OnMoveDown()
+			}
+		}),
+		fyne.NewMenuItemSeparator(),
+		kindItem,
+	)
+	widget.ShowPopUpMenuAtPosition(menu, c, pe.AbsolutePosition)
 }
 
 // Set the text of the content of a notebox.
@@ -92,10 +319,135 @@ func (nb *NoteBox) SetTime(time time.Time) {
 	nb.Refresh()
 }
 
+// Set the note displayed by a notebox.
+func (nb *NoteBox) SetNote(note backend.Note) {
+	nb.note = note
+	nb.Refresh()
+}
+
+// Set the mentions of entities within the content of a notebox's note. Each mention is drawn as a link.
+func (nb *NoteBox) SetMentions(mentions []backend.Mention) {
+	nb.mentions = mentions
+	nb.Refresh()
+}
+
+// Switch a notebox between displaying its note and editing it inline.
+// The editor is focused when editing begins.
+func (nb *NoteBox) SetEditing(editing bool) {
+	if editing == nb.editing {
+		return
+	}
+	nb.editing = editing
+	if editing {
+		nb.editor.SetText(nb.note.Content)
+	}
+	nb.Refresh()
+	if c := fyne.CurrentApp().Driver().CanvasForObject(nb); editing && c != nil {
+		c.Focus(nb.editor)
+	}
+}
+
+// Highlight a notebox as selected, or remove the highlight.
+func (nb *NoteBox) SetSelected(selected bool) {
+	if selected == nb.selected {
+		return
+	}
+	nb.selected = selected
+	nb.Refresh()
+}
+
+// Reports whether a notebox is editing its note inline.
+func (nb *NoteBox) IsEditing() bool {
+	return nb.editing
+}
+
+// Show the in-game time of a notebox's note in the passed calendar instead of the real time it was taken.
+// Pass nil to show the real time. Notes without an in-game time always show the real time.
+func (nb *NoteBox) ShowGameTime(calendar *backend.Calendar) {
+	nb.calendar = calendar
+	nb.Refresh()
+}
+
+// Builds the text displaying the date and time the note was taken, in the game world if chosen.
+func (nb *NoteBox) timeText() string {
+	text := nb.note.Time.Format("Jan 2 3:04 PM")
+	if nb.calendar != nil && nb.note.GameTime != nil {
+		text = nb.calendar.FormatShort(*nb.note.GameTime)
+	}
+	if nb.note.IsEdited() {
+		text += EDITED_SUFFIX
+	}
+	return text
+}
+
 // Creates a new NoteBox.
 func NewNoteBox(content string, time time.Time) *NoteBox {
 	note := backend.NewNote(content, time)
 	nb := &NoteBox{note: note}
+	nb.editor = newNoteEditor(
+		func(content string) {
+			if nb.OnEdited != nil {
+				nb.OnEdited(content)
+			}
+		},
+		func() {
+			if nb.OnEditCancelled != nil {
+				nb.OnEditCancelled()
+			}
+		},
+	)
 	nb.ExtendBaseWidget(nb)
 	return nb
 }
+
+// An Entry field for editing a note that submits when Enter is pressed and cancels when Escape is pressed.
+// Shift+Enter starts a new line.
+type noteEditor struct {
+	widget.Entry
+	shift    shiftState           // whether Shift is held down
+	onSubmit func(content string) // called with the text of the field when Enter is pressed
+	onCancel func()               // called when Escape is pressed
+}
+
+// Overrides the KeyDown method of the desktop.Keyable interface to track whether Shift is held down.
+func (ne *noteEditor) KeyDown(key *fyne.KeyEvent) {
+	ne.shift.keyDown(key)
+	ne.Entry.KeyDown(key)
+}
+
+// Overrides the KeyUp method of the desktop.Keyable interface to track whether Shift is held down.
+func (ne *noteEditor) KeyUp(key *fyne.KeyEvent) {
+	ne.shift.keyUp(key)
+	ne.Entry.KeyUp(key)
+}
+
+// Overrides the FocusLost method of the fyne.Focusable interface, as Shift released while unfocused is never reported.
+func (ne *noteEditor) FocusLost() {
+	ne.shift.reset()
+	ne.Entry.FocusLost()
+}
+
+// Overrides the TypedKey method of the fyne.Focusable interface.
+func (ne *noteEditor) TypedKey(key *fyne.KeyEvent) {
+	switch key.Name {
+	case fyne.KeyEnter, fyne.KeyReturn:
+		if ne.shift.held {
+			ne.Entry.TypedKey(key)
+			return
+		}
+		ne.onSubmit(ne.Text)
+	case fyne.KeyEscape:
+		ne.onCancel()
+	default:
+		ne.Entry.TypedKey(key)
+	}
+}
+
+// Creates a new field for editing a note.
+func newNoteEditor(onSubmit func(content string), onCancel func()) *noteEditor {
+	editor := &noteEditor{onSubmit: onSubmit, onCancel: onCancel}
+	editor.MultiLine = true
+	editor.Wrapping = fyne.TextWrapWord
+	editor.ExtendBaseWidget(editor)
+	return editor
+}
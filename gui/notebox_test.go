@@ -1,9 +1,13 @@
 package gui
 
 import (
+	"strings"
 	"time"
 
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/test"
+	"github.com/archon/backend"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -16,4 +20,109 @@ var _ = Describe("Notebox", func() {
 		}
 		Expect(render).ToNot(Panic())
 	})
+
+	It("should show the content of the note in the editor when editing begins", func() {
+		notebox := NewNoteBox("Hello world!", time.Now())
+		test.NewWindow(notebox)
+		notebox.SetEditing(true)
+		Expect(notebox.IsEditing()).To(BeTrue())
+		Expect(notebox.editor.Text).To(Equal("Hello world!"))
+	})
+
+	It("should submit the edited content when Enter is pressed", func() {
+		notebox := NewNoteBox("Hello world!", time.Now())
+		test.NewWindow(notebox)
+		edited := ""
+		notebox.OnEdited = func(content string) {
+			edited = content
+		}
+		notebox.SetEditing(true)
+		notebox.editor.SetText("Hello there!")
+		notebox.editor.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		Expect(edited).To(Equal("Hello there!"))
+	})
+
+	It("should cancel editing when Escape is pressed", func() {
+		notebox := NewNoteBox("Hello world!", time.Now())
+		test.NewWindow(notebox)
+		cancelled := false
+		notebox.OnEditCancelled = func() {
+			cancelled = true
+		}
+		notebox.SetEditing(true)
+		notebox.editor.TypedKey(&fyne.KeyEvent{Name: fyne.KeyEscape})
+		Expect(cancelled).To(BeTrue())
+	})
+
+	It("should mark edited notes", func() {
+		note := backend.NewNote("Hello world!", time.Now())
+		edited := time.Now()
+		note.Edited = &edited
+		notebox := NewNoteBox("", time.Now())
+		notebox.SetNote(note)
+		Expect(notebox.timeText()).To(HaveSuffix(EDITED_SUFFIX))
+	})
+
+	It("should draw mentions of entities as links", func() {
+		notebox := NewNoteBox("Met Aust at the docks", time.Now())
+		test.NewWindow(notebox)
+		tapped := ""
+		notebox.OnEntityTapped = func(entity string) {
+			tapped = entity
+		}
+		notebox.SetMentions([]backend.Mention{{Entity: "Aust Redwyn", Start: 4, End: 8}})
+
+		renderer := test.WidgetRenderer(notebox).(*NoteBoxRenderer)
+		Expect(renderer.contentBox.Objects).To(HaveLen(5))
+		test.Tap(renderer.contentBox.Objects[1].(*EntityLink))
+		Expect(tapped).To(Equal("Aust Redwyn"))
+	})
+
+	It("should grow taller to fit notes of several lines", func() {
+		single := NewNoteBox("Xenthe almost died", time.Now())
+		multi := NewNoteBox("Xenthe almost died\nThen she did\n\nThen she got better", time.Now())
+		test.NewWindow(single)
+		test.NewWindow(multi)
+		single.Resize(fyne.NewSize(500, single.MinSize().Height))
+		multi.Resize(fyne.NewSize(500, multi.MinSize().Height))
+		Expect(single.MinSize().Height).To(Equal(float32(NOTE_MIN_HEIGHT)))
+		Expect(multi.MinSize().Height).To(BeNumerically(">", single.MinSize().Height*2))
+	})
+
+	It("should wrap long notes to fit their width", func() {
+		notebox := NewNoteBox(strings.Repeat("Xenthe almost died ", 20), time.Now())
+		test.NewWindow(notebox)
+		notebox.Resize(fyne.NewSize(1000, NOTE_MIN_HEIGHT))
+		wide := notebox.MinSize().Height
+		notebox.Resize(fyne.NewSize(500, NOTE_MIN_HEIGHT))
+		Expect(notebox.MinSize().Height).To(BeNumerically(">", wide))
+	})
+
+	It("should show the in-game time of the note when chosen", func() {
+		notebox := NewNoteBox("Arrived in Waterdeep", time.Date(2021, 6, 22, 15, 0, 0, 0, time.UTC))
+		clock, _ := backend.HARPTOS.Parse("3 Flamerule 1491 14:30")
+		note := notebox.note
+		note.GameTime = &clock
+		notebox.SetNote(note)
+		Expect(notebox.timeText()).To(Equal("Jun 22 3:00 PM"))
+		notebox.ShowGameTime(&backend.HARPTOS)
+		Expect(notebox.timeText()).To(Equal("3 Flamerule 14:30"))
+		notebox.ShowGameTime(nil)
+		Expect(notebox.timeText()).To(Equal("Jun 22 3:00 PM"))
+	})
+
+	It("should insert a new line when Shift+Enter is pressed while editing", func() {
+		notebox := NewNoteBox("Hello", time.Now())
+		test.NewWindow(notebox)
+		edited := false
+		notebox.OnEdited = func(string) {
+			edited = true
+		}
+		notebox.SetEditing(true)
+		notebox.editor.KeyDown(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+		notebox.editor.TypedKey(&fyne.KeyEvent{Name: fyne.KeyReturn})
+		notebox.editor.KeyUp(&fyne.KeyEvent{Name: desktop.KeyShiftLeft})
+		Expect(edited).To(BeFalse())
+		Expect(notebox.editor.Text).To(ContainSubstring("\n"))
+	})
 })
@@ -0,0 +1,153 @@
+package gui
+
+import (
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Shown on the start screen in place of the list of recent sessions when there are none.
+const NO_RECENT_SESSIONS = "No recent sessions"
+
+// Handles the rendering for StartScreens. Implements the fyne.WidgetRenderer interface.
+type StartScreenRenderer struct {
+	cont        *fyne.Container // the container holding the heading, recent session list, buttons, and check
+	list        *widget.List    // the list of recent sessions
+	emptyLabel  *widget.Label   // the label shown in place of the list when there are no recent sessions
+	reopenCheck *widget.Check   // the check choosing whether the last session is reopened on startup
+	screen      *StartScreen    // reference to the start screen being rendered
+}
+
+// The minimum size of a StartScreen. Necessary to implement the fyne.WidgetRenderer interface.
+func (ssr *StartScreenRenderer) MinSize() fyne.Size {
+	var min_width, min_height float32 = 300, 250
+	return ssr.cont.MinSize().Max(fyne.NewSize(min_width, min_height))
+}
+
+// Position and resize the items within the StartScreen. Necessary to implement the fyne.WidgetRenderer interface.
+func (ssr *StartScreenRenderer) Layout(size fyne.Size) {
+	ssr.cont.Resize(size)
+}
+
+// Triggers when the recent sessions change or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (ssr *StartScreenRenderer) Refresh() {
+	if len(ssr.screen.recent) == 0 {
+		ssr.list.Hide()
+		ssr.emptyLabel.Show()
+	} else {
+		ssr.emptyLabel.Hide()
+		ssr.list.Show()
+	}
+	ssr.reopenCheck.SetChecked(ssr.screen.reopenLast)
+	ssr.list.Refresh()
+	canvas.Refresh(ssr.cont)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (ssr *StartScreenRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{ssr.cont}
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (ssr *StartScreenRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// The screen shown when Archon starts, offering to reopen a recent session, open another, or start a new one.
+// Implements the fyne.Widget interface.
+type StartScreen struct {
+	widget.BaseWidget
+	recent              []string              // the paths of the recent sessions, most recent first
+	reopenLast          bool                  // whether the last session is reopened on startup
+	OnOpenRecent        func(path string)     // called with the path of a recent session when it is chosen, if set
+	OnOpen              func()                // called when the user asks to open a session file, if set
+	OnNewSession        func()                // called when the user asks to start a new session, if set
+	OnReopenLastChanged func(reopenLast bool) // called when the user chooses whether the last session is reopened on startup, if set
+}
+
+// Creates a StartScreen renderer. Necessary to implement the fyne.Widget interface.
+func (ss *StartScreen) CreateRenderer() fyne.WidgetRenderer {
+	heading := widget.NewLabelWithStyle("Recent sessions", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	list := widget.NewList(
+		func() int {
+			return len(ss.recent)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(RecentSessionText(ss.recent[i]))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		list.Unselect(i)
+		if ss.OnOpenRecent != nil {
+			ss.OnOpenRecent(ss.recent[i])
+		}
+	}
+	emptyLabel := widget.NewLabel(NO_RECENT_SESSIONS)
+
+	newButton := widget.NewButtonWithIcon("New session", theme.ContentAddIcon(), func() {
+		if ss.OnNewSession != nil {
+			ss.OnNewSession()
+		}
+	})
+	openButton := widget.NewButtonWithIcon("Open…", theme.FolderOpenIcon(), func() {
+		if ss.OnOpen != nil {
+			ss.OnOpen()
+		}
+	})
+	reopenCheck := widget.NewCheck("Reopen the last session on startup", func(checked bool) {
+		if checked == ss.reopenLast {
+			return
+		}
+		ss.reopenLast = checked
+		if ss.OnReopenLastChanged != nil {
+			ss.OnReopenLastChanged(checked)
+		}
+	})
+
+	ssr := &StartScreenRenderer{
+		cont: container.NewBorder(
+			container.NewVBox(heading, emptyLabel),
+			container.NewVBox(container.NewHBox(newButton, openButton), reopenCheck),
+			nil,
+			nil,
+			list,
+		),
+		list:        list,
+		emptyLabel:  emptyLabel,
+		reopenCheck: reopenCheck,
+		screen:      ss,
+	}
+	ssr.Refresh()
+	return ssr
+}
+
+// Sets the paths of the recent sessions, most recent first.
+func (ss *StartScreen) SetRecent(paths []string) {
+	ss.recent = paths
+	ss.Refresh()
+}
+
+// Sets whether the last session is shown as reopened on startup.
+func (ss *StartScreen) SetReopenLast(reopenLast bool) {
+	ss.reopenLast = reopenLast
+	ss.Refresh()
+}
+
+// Builds the text describing a recent session by its file name and the folder it is in, like "session.json — /notes".
+func RecentSessionText(path string) string {
+	return filepath.Base(path) + " — " + filepath.Dir(path)
+}
+
+// Creates a new StartScreen offering the recent sessions at the passed paths, most recent first.
+func NewStartScreen(recent []string) *StartScreen {
+	ss := &StartScreen{recent: recent}
+	ss.ExtendBaseWidget(ss)
+	return ss
+}
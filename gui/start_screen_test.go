@@ -0,0 +1,53 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("StartScreen widget", func() {
+	It("should render without crashing", func() {
+		render := func() {
+			test.NewWindow(NewStartScreen([]string{"/notes/session.json"}))
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should describe recent sessions by file name and folder", func() {
+		Expect(RecentSessionText("/notes/calimport/session3.json")).To(Equal("session3.json — /notes/calimport"))
+	})
+
+	It("should say when there are no recent sessions", func() {
+		screen := NewStartScreen(nil)
+		test.NewWindow(screen)
+		renderer := test.WidgetRenderer(screen).(*StartScreenRenderer)
+		Expect(renderer.emptyLabel.Visible()).To(BeTrue())
+		Expect(renderer.list.Visible()).To(BeFalse())
+
+		screen.SetRecent([]string{"/notes/session.json"})
+		Expect(renderer.emptyLabel.Visible()).To(BeFalse())
+	})
+
+	It("should notify when a recent session is chosen", func() {
+		screen := NewStartScreen([]string{"/notes/one.json", "/notes/two.json"})
+		test.NewWindow(screen)
+		chosen := ""
+		screen.OnOpenRecent = func(path string) {
+			chosen = path
+		}
+		test.WidgetRenderer(screen).(*StartScreenRenderer).list.Select(1)
+		Expect(chosen).To(Equal("/notes/two.json"))
+	})
+
+	It("should notify when reopening the last session is chosen", func() {
+		screen := NewStartScreen(nil)
+		test.NewWindow(screen)
+		reopen := false
+		screen.OnReopenLastChanged = func(reopenLast bool) {
+			reopen = reopenLast
+		}
+		test.Tap(test.WidgetRenderer(screen).(*StartScreenRenderer).reopenCheck)
+		Expect(reopen).To(BeTrue())
+	})
+})
@@ -0,0 +1,110 @@
+package gui
+
+import (
+	"hash/fnv"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// The colors tag chips are drawn in. Each tag is always drawn in the same color.
+var TAG_COLORS = []color.Color{
+	color.NRGBA{R: 0xc6, G: 0x28, B: 0x28, A: 0xff},
+	color.NRGBA{R: 0xef, G: 0x6c, B: 0x00, A: 0xff},
+	color.NRGBA{R: 0x2e, G: 0x7d, B: 0x32, A: 0xff},
+	color.NRGBA{R: 0x00, G: 0x83, B: 0x8f, A: 0xff},
+	color.NRGBA{R: 0x15, G: 0x65, B: 0xc0, A: 0xff},
+	color.NRGBA{R: 0x6a, G: 0x1b, B: 0x9a, A: 0xff},
+	color.NRGBA{R: 0xad, G: 0x14, B: 0x57, A: 0xff},
+	color.NRGBA{R: 0x4e, G: 0x34, B: 0x2e, A: 0xff},
+}
+
+// Returns the color a tag is drawn in.
+func TagColor(tag string) color.Color {
+	hash := fnv.New32a()
+	hash.Write([]byte(backend.NormalizeTag(tag)))
+	return TAG_COLORS[hash.Sum32()%uint32(len(TAG_COLORS))]
+}
+
+// Handles the rendering for TagChips. Implements the fyne.WidgetRenderer interface.
+type TagChipRenderer struct {
+	background *canvas.Rectangle   // the colored rectangle behind the tag
+	text       *canvas.Text        // the text of the tag
+	objects    []fyne.CanvasObject // a list of the objects declared above
+	chip       *TagChip            // reference to the tag chip being rendered
+}
+
+// The minimum size of a TagChip, large enough to fit its text. Necessary to implement the fyne.WidgetRenderer interface.
+func (tcr *TagChipRenderer) MinSize() fyne.Size {
+	textSize := tcr.text.MinSize()
+	return fyne.NewSize(textSize.Width+theme.Padding()*2, textSize.Height)
+}
+
+// Position and resize the items within the TagChip. Necessary to implement the fyne.WidgetRenderer interface.
+func (tcr *TagChipRenderer) Layout(size fyne.Size) {
+	tcr.background.Resize(size)
+	tcr.text.Move(fyne.NewPos(theme.Padding(), 0))
+	tcr.text.Resize(fyne.NewSize(size.Width-theme.Padding()*2, size.Height))
+}
+
+// Triggers when the TagChip changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (tcr *TagChipRenderer) Refresh() {
+	tcr.text.Text = backend.TAG_PREFIX + tcr.chip.tag
+	tcr.background.FillColor = TagColor(tcr.chip.tag)
+	tcr.Layout(tcr.chip.Size())
+	canvas.Refresh(tcr.chip)
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (tcr *TagChipRenderer) Objects() []fyne.CanvasObject {
+	return tcr.objects
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (tcr *TagChipRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A small colored label displaying a tag. Implements the fyne.Widget and fyne.Tappable interfaces.
+type TagChip struct {
+	widget.BaseWidget
+	tag      string           // the tag displayed, without its prefix
+	OnTapped func(tag string) // called with the tag when the chip is tapped, if set
+}
+
+// Creates a TagChip renderer. Necessary to implement the fyne.Widget interface.
+func (tc *TagChip) CreateRenderer() fyne.WidgetRenderer {
+	background := canvas.NewRectangle(TagColor(tc.tag))
+	text := canvas.NewText(backend.TAG_PREFIX+tc.tag, color.White)
+	text.TextSize = theme.TextSize() * 0.8
+	text.Alignment = fyne.TextAlignCenter
+	return &TagChipRenderer{
+		background: background,
+		text:       text,
+		objects:    []fyne.CanvasObject{background, text},
+		chip:       tc,
+	}
+}
+
+// Handles taps on the chip. Necessary to implement the fyne.Tappable interface.
+func (tc *TagChip) Tapped(*fyne.PointEvent) {
+	if tc.OnTapped != nil {
+		tc.OnTapped(tc.tag)
+	}
+}
+
+// Returns the tag displayed by the chip.
+func (tc *TagChip) Tag() string {
+	return tc.tag
+}
+
+// Creates a new TagChip displaying the passed tag.
+func NewTagChip(tag string) *TagChip {
+	tc := &TagChip{tag: backend.NormalizeTag(tag)}
+	tc.ExtendBaseWidget(tc)
+	return tc
+}
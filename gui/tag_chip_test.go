@@ -0,0 +1,35 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TagChip widget", func() {
+	It("should render without crashing", func() {
+		chip := NewTagChip("combat")
+		render := func() {
+			test.NewWindow(chip)
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should display tags without their prefix in lowercase", func() {
+		Expect(NewTagChip("#Loot").Tag()).To(Equal("loot"))
+	})
+
+	It("should always draw a tag in the same color", func() {
+		Expect(TagColor("loot")).To(Equal(TagColor("#LOOT")))
+	})
+
+	It("should report its tag when tapped", func() {
+		chip := NewTagChip("loot")
+		tapped := ""
+		chip.OnTapped = func(tag string) {
+			tapped = tag
+		}
+		test.Tap(chip)
+		Expect(tapped).To(Equal("loot"))
+	})
+})
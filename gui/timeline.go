@@ -0,0 +1,164 @@
+package gui
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+)
+
+// Mark whether the notes of a scene are shown beneath its heading.
+const EXPANDED_MARKER = "▼"
+const COLLAPSED_MARKER = "▶"
+
+// The layout of the time shown beside each note of the timeline.
+const TIMELINE_TIME_FORMAT = "3:04 PM"
+
+// Handles the rendering for Timelines. Implements the fyne.WidgetRenderer interface.
+type TimelineRenderer struct {
+	scroll   *container.Scroll // the scrolling container holding the scenes
+	timeline *Timeline         // reference to the timeline being rendered
+}
+
+// The minimum size of a Timeline. Necessary to implement the fyne.WidgetRenderer interface.
+func (tr *TimelineRenderer) MinSize() fyne.Size {
+	return tr.scroll.MinSize()
+}
+
+// Position and resize the scrolling container to fill the Timeline. Necessary to implement the fyne.WidgetRenderer interface.
+func (tr *TimelineRenderer) Layout(size fyne.Size) {
+	tr.scroll.Resize(size)
+}
+
+// Triggers when the Timeline changes or the theme is altered. Necessary to implement the fyne.WidgetRenderer interface.
+func (tr *TimelineRenderer) Refresh() {
+	tr.scroll.Refresh()
+}
+
+// Returns the list of objects this renderer renders. Necessary to implement the fyne.WidgetRenderer interface.
+func (tr *TimelineRenderer) Objects() []fyne.CanvasObject {
+	return []fyne.CanvasObject{tr.scroll}
+}
+
+// Called when this renderer is no longer needed. Necessary to implement the fyne.WidgetRenderer interface.
+func (tr *TimelineRenderer) Destroy() {
+	// no-op, no resources to close
+}
+
+// A scrolling view of the notes of a session grouped by scene. Each scene has a heading showing how long it was played,
+// which collapses or expands its notes when tapped. Implements the fyne.Widget interface.
+type Timeline struct {
+	widget.BaseWidget
+	session      *backend.Session       // the session whose scenes are shown
+	collapsed    map[int]bool           // whether the scene starting at each marker index has its notes hidden
+	headers      map[int]*widget.Button // the heading of the scene starting at each marker index, as last built
+	content      *fyne.Container        // the container stacking the headings and notes
+	scroll       *container.Scroll      // the scrolling container holding the content
+	OnNoteTapped func(i int)            // called with the index within the session of a note the user taps, if set
+}
+
+// Creates a Timeline renderer. Necessary to implement the fyne.Widget interface.
+func (t *Timeline) CreateRenderer() fyne.WidgetRenderer {
+	t.update()
+	return &TimelineRenderer{scroll: t.scroll, timeline: t}
+}
+
+// Rebuilds the scenes from the session and redraws the timeline.
+func (t *Timeline) Refresh() {
+	t.update()
+	t.BaseWidget.Refresh()
+}
+
+// Sets the session whose scenes are shown. Every scene starts expanded.
+func (t *Timeline) SetSession(session *backend.Session) {
+	t.session = session
+	t.collapsed = make(map[int]bool)
+	t.Refresh()
+}
+
+// Collapses the scene starting at the passed marker index if it is expanded, or expands it if it is collapsed.
+func (t *Timeline) ToggleScene(marker int) {
+	t.collapsed[marker] = !t.collapsed[marker]
+	t.Refresh()
+}
+
+// Reports whether the notes of the scene starting at the passed marker index are hidden.
+func (t *Timeline) IsCollapsed(marker int) bool {
+	return t.collapsed[marker]
+}
+
+// Expands the scene starting at the passed marker index and scrolls so that its heading is at the top, or as near as the timeline can scroll.
+func (t *Timeline) ScrollToScene(marker int) {
+	if t.collapsed[marker] {
+		t.ToggleScene(marker)
+	}
+	header, ok := t.headers[marker]
+	if !ok {
+		return
+	}
+	t.scroll.Offset.Y = header.Position().Y
+	t.scroll.Refresh()
+}
+
+// Rebuilds the headings and notes of every scene of the session.
+func (t *Timeline) update() {
+	objects := make([]fyne.CanvasObject, 0)
+	t.headers = make(map[int]*widget.Button)
+	if t.session != nil {
+		for _, scene := range t.session.Timeline() {
+			scene := scene
+			header := widget.NewButton(SceneHeadingText(scene, t.collapsed[scene.Marker]), func() {
+				t.ToggleScene(scene.Marker)
+			})
+			header.Alignment = widget.ButtonAlignLeading
+			t.headers[scene.Marker] = header
+			objects = append(objects, header)
+			if t.collapsed[scene.Marker] {
+				continue
+			}
+			for _, i := range scene.Notes {
+				i := i
+				row := widget.NewButton(TimelineNoteText(t.session.Notes[i]), func() {
+					if t.OnNoteTapped != nil {
+						t.OnNoteTapped(i)
+					}
+				})
+				row.Alignment = widget.ButtonAlignLeading
+				row.Importance = widget.LowImportance
+				objects = append(objects, row)
+			}
+		}
+	}
+	t.content.Objects = objects
+	t.content.Refresh()
+}
+
+// Builds the heading of a scene, like "▼ The road north — 45m (break 15m)".
+func SceneHeadingText(scene backend.Scene, collapsed bool) string {
+	marker := EXPANDED_MARKER
+	if collapsed {
+		marker = COLLAPSED_MARKER
+	}
+	text := marker + " " + scene.Title + " — " + backend.FormatDuration(scene.Duration())
+	if scene.BreakTime > 0 {
+		text += " (break " + backend.FormatDuration(scene.BreakTime) + ")"
+	}
+	return text
+}
+
+// Builds the line showing a note within the timeline: its time and the first line of its content, like "6:20 PM  Wolves attack".
+func TimelineNoteText(note backend.Note) string {
+	content := strings.SplitN(note.Content, "\n", 2)[0]
+	return note.Time.Format(TIMELINE_TIME_FORMAT) + "  " + content
+}
+
+// Creates a new Timeline showing the scenes of the passed session.
+func NewTimeline(session *backend.Session) *Timeline {
+	t := &Timeline{session: session, collapsed: make(map[int]bool)}
+	t.content = container.NewVBox()
+	t.scroll = container.NewVScroll(t.content)
+	t.ExtendBaseWidget(t)
+	return t
+}
@@ -0,0 +1,64 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/archon/backend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timeline widget", func() {
+	var session *backend.Session
+	var start time.Time
+
+	BeforeEach(func() {
+		session = backend.NewSession("Untitled Session", 0)
+		start = time.Date(2021, 6, 12, 18, 0, 0, 0, time.UTC)
+		session.AddNote(session.NewSceneMarker("The road north", start))
+		session.AddNote(backend.NewNote("Wolves attack\nThree of them", start.Add(20*time.Minute)))
+		session.AddNote(backend.NewBreakMarker(start.Add(30 * time.Minute)))
+		session.AddNote(backend.NewNote("The wolves flee", start.Add(45*time.Minute)))
+	})
+
+	It("should render without crashing", func() {
+		render := func() {
+			test.NewWindow(NewTimeline(session))
+		}
+		Expect(render).ToNot(Panic())
+	})
+
+	It("should describe scenes and their durations", func() {
+		scene := session.Timeline()[0]
+		Expect(SceneHeadingText(scene, false)).To(Equal(EXPANDED_MARKER + " The road north — 30m (break 15m)"))
+		Expect(SceneHeadingText(scene, true)).To(Equal(COLLAPSED_MARKER + " The road north — 30m (break 15m)"))
+	})
+
+	It("should show the first line of each note beside its time", func() {
+		Expect(TimelineNoteText(session.Notes[1])).To(Equal("6:20 PM  Wolves attack"))
+	})
+
+	It("should hide the notes of collapsed scenes", func() {
+		timeline := NewTimeline(session)
+		test.NewWindow(timeline)
+		Expect(timeline.content.Objects).To(HaveLen(4))
+		test.Tap(timeline.headers[0])
+		Expect(timeline.IsCollapsed(0)).To(BeTrue())
+		Expect(timeline.content.Objects).To(HaveLen(1))
+		timeline.ScrollToScene(0)
+		Expect(timeline.IsCollapsed(0)).To(BeFalse())
+	})
+
+	It("should notify when a note is tapped", func() {
+		timeline := NewTimeline(session)
+		test.NewWindow(timeline)
+		tapped := -1
+		timeline.OnNoteTapped = func(i int) {
+			tapped = i
+		}
+		test.Tap(timeline.content.Objects[3].(*widget.Button))
+		Expect(tapped).To(Equal(3))
+	})
+})